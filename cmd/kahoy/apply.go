@@ -2,12 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
@@ -15,13 +20,22 @@ import (
 
 	internalkubernetes "github.com/slok/kahoy/internal/kubernetes"
 	"github.com/slok/kahoy/internal/log"
+	metricsprometheus "github.com/slok/kahoy/internal/metrics/prometheus"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/notify"
+	"github.com/slok/kahoy/internal/notify/webhook"
 	"github.com/slok/kahoy/internal/plan"
+	planoutput "github.com/slok/kahoy/internal/plan/output"
+	internalreport "github.com/slok/kahoy/internal/report"
 	resourcemanage "github.com/slok/kahoy/internal/resource/manage"
 	managebatch "github.com/slok/kahoy/internal/resource/manage/batch"
+	manageconflict "github.com/slok/kahoy/internal/resource/manage/conflict"
 	managedryrun "github.com/slok/kahoy/internal/resource/manage/dryrun"
 	managehook "github.com/slok/kahoy/internal/resource/manage/hook"
 	managekubectl "github.com/slok/kahoy/internal/resource/manage/kubectl"
+	managemeasure "github.com/slok/kahoy/internal/resource/manage/measure"
+	managereplace "github.com/slok/kahoy/internal/resource/manage/replace"
+	manageretry "github.com/slok/kahoy/internal/resource/manage/retry"
 	manageTimeout "github.com/slok/kahoy/internal/resource/manage/timeout"
 	resourceprocess "github.com/slok/kahoy/internal/resource/process"
 	"github.com/slok/kahoy/internal/storage"
@@ -29,10 +43,11 @@ import (
 	storagegit "github.com/slok/kahoy/internal/storage/git"
 	storagekubernetes "github.com/slok/kahoy/internal/storage/kubernetes"
 	storagereport "github.com/slok/kahoy/internal/storage/report"
+	"github.com/slok/kahoy/internal/tracing"
 )
 
 // RunApply runs the apply command.
-func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfig) error {
+func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfig) (err error) {
 	report, err := model.NewState()
 	if err != nil {
 		return fmt.Errorf("could not start the app report: %w", err)
@@ -45,6 +60,156 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 	})
 	logger.Infof("running command")
 
+	// Set up report output, only real executions are reported. The report is
+	// written when the execution ends, whatever the result is.
+	var reportRepo storage.StateRepository = storage.NewNoopStateRepository(logger)
+	if !cmdConfig.Apply.DryRun && !cmdConfig.Apply.DiffMode {
+		switch cmdConfig.Apply.ReportPath {
+		case "":
+			// NOOP.
+
+		// Write output to stdout.
+		case "-":
+			reportRepo = newReportRepository(cmdConfig.Apply.ReportFormat, globalConfig.Stdout)
+
+		// Anything else write as if it was a path to a file.
+		default:
+			outFile, err := os.Create(cmdConfig.Apply.ReportPath)
+			if err != nil {
+				return fmt.Errorf("could not open file %q for out report: %w", cmdConfig.Apply.ReportPath, err)
+			}
+			logger.Infof("report will be written to %q", cmdConfig.Apply.ReportPath)
+			defer outFile.Close()
+
+			reportRepo = newReportRepository(cmdConfig.Apply.ReportFormat, outFile)
+		}
+	}
+	defer func() {
+		// Executions that ended before running (e.g: loading or planning errors)
+		// don't have a result yet.
+		if report.EndedAt.IsZero() {
+			report.EndedAt = time.Now().UTC()
+		}
+		if report.Status == "" {
+			setReportResult(report, err)
+		}
+
+		storeErr := reportRepo.StoreState(context.Background(), *report)
+		if storeErr == nil {
+			return
+		}
+		if err == nil || errors.Is(err, ErrChanges) {
+			err = fmt.Errorf("could not store report: %w", storeErr)
+			return
+		}
+		logger.Errorf("could not store report: %s", storeErr)
+	}()
+
+	// Set up metrics, these will be exported when the execution ends.
+	metricsReg := prometheus.NewRegistry()
+	metricsRec := metricsprometheus.NewRecorder(metricsReg)
+	metricsExporter, err := metricsprometheus.NewExporter(metricsprometheus.ExporterConfig{
+		Gatherer:       metricsReg,
+		TextfilePath:   cmdConfig.Apply.MetricsTextfilePath,
+		PushgatewayURL: cmdConfig.Apply.MetricsPushgatewayURL,
+		Logger:         logger,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create metrics exporter: %w", err)
+	}
+	defer func() {
+		status := report.Status
+		if status == "" {
+			status = model.ExecutionStatusSuccess
+			if err != nil {
+				status = model.ExecutionStatusFailed
+			}
+		}
+		metricsRec.ObserveRun(ctx, status, time.Since(report.StartedAt))
+
+		err := metricsExporter.Export(context.Background())
+		if err != nil {
+			logger.Errorf("could not export metrics: %s", err)
+		}
+	}()
+
+	// Set up tracing, the pending spans will be flushed when the execution ends.
+	tracingConfig := tracing.ProviderConfig{
+		OTLPEndpoint: cmdConfig.Apply.TracingOTLPEndpoint,
+		Version:      Version,
+		Logger:       logger,
+	}
+	if cmdConfig.Apply.TracingFilePath != "" {
+		traceFile, err := os.Create(cmdConfig.Apply.TracingFilePath)
+		if err != nil {
+			return fmt.Errorf("could not open file %q for traces: %w", cmdConfig.Apply.TracingFilePath, err)
+		}
+		defer traceFile.Close()
+		tracingConfig.Out = traceFile
+	}
+	tracingProvider, err := tracing.NewProvider(ctx, tracingConfig)
+	if err != nil {
+		return fmt.Errorf("could not create tracing provider: %w", err)
+	}
+	defer func() {
+		err := tracingProvider.Shutdown(context.Background())
+		if err != nil {
+			logger.Errorf("could not flush traces: %s", err)
+		}
+	}()
+	tracer := tracingProvider.Tracer()
+
+	ctx, span := tracer.Start(ctx, "apply", trace.WithAttributes(
+		attribute.String("kahoy.id", report.ID),
+		attribute.String("kahoy.provider", cmdConfig.Apply.Provider),
+	))
+	defer func() {
+		// Having changes is not an error of the execution.
+		spanErr := err
+		if errors.Is(spanErr, ErrChanges) {
+			spanErr = nil
+		}
+		tracing.EndSpan(span, spanErr, attribute.String("kahoy.status", string(report.Status)))
+	}()
+
+	// Set up the notifications of the execution lifecycle.
+	notifier, err := newNotifier(cmdConfig, logger)
+	if err != nil {
+		return err
+	}
+	notifyMode := notify.ExecutionModeApply
+	switch {
+	case cmdConfig.Apply.DryRun:
+		notifyMode = notify.ExecutionModeDryRun
+	case cmdConfig.Apply.DiffMode:
+		notifyMode = notify.ExecutionModeDiff
+	}
+	sendNotification := func(ctx context.Context, e notify.Event) {
+		e.Mode = notifyMode
+		e.RunID = report.ID
+		e.Time = time.Now().UTC()
+		e.GitCommit = report.GitCommit
+		err := notifier.Notify(ctx, e)
+		if err != nil {
+			logger.Warningf("could not notify %q event: %s", e.Type, err)
+		}
+	}
+	sendNotification(ctx, notify.Event{Type: notify.EventTypeRunStarted})
+	defer func() {
+		e := notify.Event{Type: notify.EventTypeRunSucceeded, Status: string(report.Status)}
+		if err != nil && !errors.Is(err, ErrChanges) {
+			e.Type = notify.EventTypeRunFailed
+			e.Error = err.Error()
+		}
+		if e.Status == "" {
+			e.Status = string(model.ExecutionStatusSuccess)
+			if e.Type == notify.EventTypeRunFailed {
+				e.Status = string(model.ExecutionStatusFailed)
+			}
+		}
+		sendNotification(context.Background(), e)
+	}()
+
 	// Create YAML serializer.
 	kubernetesSerializer := internalkubernetes.NewYAMLObjectSerializer(logger)
 
@@ -70,12 +235,14 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 
 	var (
 		oldResourceRepo, newResourceRepo storage.ResourceRepository
-		newGroupRepo                     storage.GroupRepository
+		oldGroupRepo, newGroupRepo       storage.GroupRepository
 		stateRepo                        storage.StateRepository = storage.NewNoopStateRepository(logger)
 	)
+	_, loadSpan := tracer.Start(ctx, "load", trace.WithAttributes(attribute.String("kahoy.provider", cmdConfig.Apply.Provider)))
+	defer loadSpan.End()
 	switch cmdConfig.Apply.Provider {
 	case ApplyProviderGit:
-		oldRepo, newRepo, err := storagegit.NewRepositories(storagegit.RepositoriesConfig{
+		repos, err := storagegit.NewRepositories(storagegit.RepositoriesConfig{
 			ExcludeRegex:       fsExclude,
 			IncludeRegex:       fsInclude,
 			OldRelPath:         cmdConfig.Apply.ManifestsPathOld,
@@ -91,9 +258,12 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 			return fmt.Errorf("could not create git based fs repos storage: %w", err)
 		}
 
-		oldResourceRepo = oldRepo
-		newResourceRepo = newRepo
-		newGroupRepo = newRepo
+		oldResourceRepo = repos.Old
+		newResourceRepo = repos.New
+		oldGroupRepo = repos.Old
+		newGroupRepo = repos.New
+		report.GitBeforeCommit = repos.OldCommit
+		report.GitCommit = repos.NewCommit
 
 	case ApplyProviderPaths:
 		oldRepo, newRepo, err := storagefs.NewRepositories(storagefs.RepositoriesConfig{
@@ -114,6 +284,7 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 
 		oldResourceRepo = oldRepo
 		newResourceRepo = newRepo
+		oldGroupRepo = oldRepo
 		newGroupRepo = newRepo
 
 	case ApplyProviderK8s:
@@ -145,7 +316,7 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 			return fmt.Errorf("could not create fs repos storage: %w", err)
 		}
 
-		// State store and old repository is from Kubernetes.
+		// State store and old repository is from Kubernetes, it doesn't store the groups.
 		stateRepo = k8sRepo
 		oldResourceRepo = k8sRepo
 		newResourceRepo = newRepo
@@ -165,15 +336,53 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 	if err != nil {
 		return fmt.Errorf("could not retrieve the list of expected resources: %w", err)
 	}
+	tracing.EndSpan(loadSpan, nil,
+		attribute.Int("kahoy.old_resources", len(oldRes.Items)),
+		attribute.Int("kahoy.new_resources", len(newRes.Items)),
+	)
+
+	// Remove the ignored fields from the resources. This needs to be done before planning so
+	// these fields are not used to detect changes, and are not applied.
+	ignoreFieldsProc, err := resourceprocess.NewIgnoreFieldsProcessor(globalConfig.AppConfig.IgnoreFields, logger)
+	if err != nil {
+		return fmt.Errorf("could not create ignore fields processor: %w", err)
+	}
+
+	oldItems, err := ignoreFieldsProc.Process(ctx, oldRes.Items)
+	if err != nil {
+		return fmt.Errorf("error while ignoring fields of current resources: %w", err)
+	}
+
+	newItems, err := ignoreFieldsProc.Process(ctx, newRes.Items)
+	if err != nil {
+		return fmt.Errorf("error while ignoring fields of expected resources: %w", err)
+	}
+
+	// Hook Jobs are not managed as regular resources, they are executed on their group stages.
+	oldItems, newItems, jobHooks := splitJobHooks(oldItems, newItems)
+	if len(jobHooks) > 0 {
+		logger.Infof("%d hook jobs loaded", len(jobHooks))
+	}
 
 	// Plan our actions/states.
-	planner := plan.NewPlanner(cmdConfig.Apply.IncludeChanges, logger)
-	statePlan, err := planner.Plan(ctx, oldRes.Items, newRes.Items)
+	planner, err := plan.NewPlanner(plan.PlannerConfig{
+		OnlyOnDiff:         cmdConfig.Apply.IncludeChanges,
+		GroupRepository:    newGroupRepo,
+		OldGroupRepository: oldGroupRepo,
+		Logger:             logger,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create planner: %w", err)
+	}
+	planner = plan.NewMeasuredPlanner(metricsRec, planner)
+	planner = plan.NewTracedPlanner(tracer, planner)
+
+	statePlan, err := planner.Plan(ctx, oldItems, newItems)
 	if err != nil {
 		return fmt.Errorf("could not get a plan: %w", err)
 	}
 
-	applyRes, deleteRes, err := splitPlan(statePlan)
+	applyRes, deleteRes, movedRes, err := splitPlan(statePlan)
 	if err != nil {
 		return err
 	}
@@ -183,6 +392,7 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 	if err != nil {
 		return err
 	}
+	resProc = resourceprocess.NewTracedResourceProcessor(tracer, resProc)
 
 	resQBefore := len(applyRes)
 	applyRes, err = resProc.Process(ctx, applyRes)
@@ -198,102 +408,287 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 		return fmt.Errorf("error while processing delete state resources: %w", err)
 	}
 
-	if len(applyRes)+len(deleteRes) <= 0 {
-		logger.Infof("no resources to apply/delete, exiting...")
-		return nil
+	movedRes, err = processMoved(ctx, resProc, movedRes)
+	if err != nil {
+		return fmt.Errorf("error while processing moved resources: %w", err)
+	}
+	for _, m := range movedRes {
+		logger.WithValues(log.Kv{"resource-id": m.Resource.ID, "resource-group-id": m.Resource.GroupID}).Infof("resource moved from %q group", m.OldGroupID)
 	}
+
 	resQAfter = len(deleteRes)
 	logger.Infof("delete resources before filter %d, after %d", resQBefore, resQAfter)
 
+	// Split the resources that don't need to be deleted from the cluster, only released from Kahoy management.
+	deleteRes, releaseRes := splitReleased(deleteRes, globalConfig.AppConfig)
+	for _, r := range releaseRes {
+		logger.WithValues(log.Kv{"resource-id": r.ID, "resource-group-id": r.GroupID}).Infof("resource will be released, it will not be deleted from the cluster")
+	}
+
+	sendNotification(ctx, notify.Event{
+		Type: notify.EventTypePlanned,
+		Plan: &notify.PlanSummary{
+			Apply:   len(applyRes),
+			Delete:  len(deleteRes),
+			Release: len(releaseRes),
+			Moved:   len(movedRes),
+		},
+	})
+
+	// Output the plan, this is done in all the execution modes, before executing anything.
+	err = printPlan(ctx, cmdConfig, globalConfig, newGroupRepo, logger, planoutput.Plan{
+		OldResources:     oldItems,
+		ApplyResources:   applyRes,
+		DeleteResources:  deleteRes,
+		ReleaseResources: releaseRes,
+		MovedResources:   movedRes,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(applyRes)+len(deleteRes)+len(releaseRes)+len(movedRes) <= 0 {
+		logger.Infof("no resources to apply/delete, exiting...")
+		return nil
+	}
+
+	// When the plan is written to stdout, the dry-run and diff outputs are written to
+	// stderr, so the plan can be consumed as it is (e.g: piped to `jq`).
+	execOut := globalConfig.Stdout
+	if cmdConfig.Apply.PlanOutput != "" && cmdConfig.Apply.PlanOutputPath == "-" {
+		execOut = globalConfig.Stderr
+	}
+
 	// Select the execution logic based on diff, dry-run...
 	var (
-		manager    resourcemanage.ResourceManager
-		reportRepo storage.StateRepository = storage.NewNoopStateRepository(logger)
+		manager      resourcemanage.ResourceManager
+		eventRepo    storage.StateRepository   = storage.NewNoopStateRepository(logger)
+		runHooks     managehook.RunExecutor    = managehook.NoopRunExecutor
+		movedPrinter managedryrun.MovedPrinter = managedryrun.NoopMovedPrinter
+		recorder                               = internalreport.NewNotifiedRecorder(notifier, report, logger, internalreport.NewMeasuredRecorder(metricsRec, internalreport.NewStateRecorder(report)))
 	)
 	switch {
 	case cmdConfig.Apply.DryRun:
 		stateRepo = storage.NewNoopStateRepository(logger)
-		manager = managedryrun.NewManager(cmdConfig.Global.NoColor, nil)
+		manager, err = managedryrun.NewManager(managedryrun.ManagerConfig{
+			DisableColor:   cmdConfig.Global.NoColor,
+			Out:            execOut,
+			MovedResources: movedRes,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create dry-run resource manager: %w", err)
+		}
+
+		// The moved resources that are not applied are not executed by the manager, so
+		// we print them apart.
+		movedPrinter, err = managedryrun.NewMovedPrinter(managedryrun.ManagerConfig{
+			DisableColor: cmdConfig.Global.NoColor,
+			Out:          execOut,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create dry-run moved resources printer: %w", err)
+		}
 
 	case cmdConfig.Apply.DiffMode:
 		stateRepo = storage.NewNoopStateRepository(logger)
 		manager, err = managekubectl.NewDiffManager(managekubectl.DiffManagerConfig{
-			KubeConfig:  cmdConfig.Apply.KubeConfig,
-			KubeContext: cmdConfig.Apply.KubeContext,
-			KubectlCmd:  cmdConfig.Apply.KubectlPath,
-			YAMLEncoder: kubernetesSerializer,
-			YAMLDecoder: kubernetesSerializer,
-			Logger:      logger,
+			KubeConfig:       cmdConfig.Apply.KubeConfig,
+			KubeContext:      cmdConfig.Apply.KubeContext,
+			KubectlCmd:       cmdConfig.Apply.KubectlPath,
+			KubeFieldManager: cmdConfig.Apply.KubeFieldManager,
+			Out:              execOut,
+			YAMLEncoder:      kubernetesSerializer,
+			YAMLDecoder:      kubernetesSerializer,
+			Recorder:         recorder,
+			Tracer:           tracer,
+			Logger:           logger,
 		})
 		if err != nil {
 			return fmt.Errorf("could not create diff resource manager: %w", err)
 		}
 
 	default:
-		manager, err = managekubectl.NewManager(managekubectl.ManagerConfig{
+		// Create the executor managers, one that forces the field ownership conflicts
+		// and another one that doesn't, and select them based on the conflict policy.
+		forceManager, err := managekubectl.NewManager(managekubectl.ManagerConfig{
+			KubeConfig:       cmdConfig.Apply.KubeConfig,
+			KubeContext:      cmdConfig.Apply.KubeContext,
+			KubectlCmd:       cmdConfig.Apply.KubectlPath,
+			KubeFieldManager: cmdConfig.Apply.KubeFieldManager,
+			YAMLEncoder:      kubernetesSerializer,
+			Recorder:         recorder,
+			Tracer:           tracer,
+			Logger:           logger,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create resource manager: %w", err)
+		}
+
+		noForceManager, err := managekubectl.NewManager(managekubectl.ManagerConfig{
+			KubeConfig:                cmdConfig.Apply.KubeConfig,
+			KubeContext:               cmdConfig.Apply.KubeContext,
+			KubectlCmd:                cmdConfig.Apply.KubectlPath,
+			KubeFieldManager:          cmdConfig.Apply.KubeFieldManager,
+			DisableKubeForceConflicts: true,
+			YAMLEncoder:               kubernetesSerializer,
+			Recorder:                  recorder,
+			Tracer:                    tracer,
+			Logger:                    logger,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create resource manager: %w", err)
+		}
+
+		// The conflicts of the forced resources are detected with a server dry-run without
+		// forcing them, so these are also reported. The results are not recorded because
+		// nothing is applied.
+		checkManager, err := managekubectl.NewManager(managekubectl.ManagerConfig{
+			KubeConfig:                cmdConfig.Apply.KubeConfig,
+			KubeContext:               cmdConfig.Apply.KubeContext,
+			KubectlCmd:                cmdConfig.Apply.KubectlPath,
+			KubeFieldManager:          cmdConfig.Apply.KubeFieldManager,
+			DisableKubeForceConflicts: true,
+			KubeServerDryRun:          true,
+			YAMLEncoder:               kubernetesSerializer,
+			Tracer:                    tracer,
+			Logger:                    logger,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create resource manager: %w", err)
+		}
+
+		manager, err = manageconflict.NewManager(manageconflict.ManagerConfig{
+			Manager:         noForceManager,
+			ForceManager:    forceManager,
+			CheckManager:    checkManager,
+			GroupRepository: newGroupRepo,
+			DefaultPolicy:   model.ConflictPolicy(cmdConfig.Apply.KubeConflictPolicy),
+			Recorder:        recorder,
+			Logger:          logger,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create conflict resource manager: %w", err)
+		}
+
+		// Wrap the executor manager with replace manager, this will replace the resources
+		// that have immutable field changes if they are configured to be replaced.
+		replacer, err := managekubectl.NewReplacer(managekubectl.ManagerConfig{
 			KubeConfig:  cmdConfig.Apply.KubeConfig,
 			KubeContext: cmdConfig.Apply.KubeContext,
 			KubectlCmd:  cmdConfig.Apply.KubectlPath,
 			YAMLEncoder: kubernetesSerializer,
+			Recorder:    recorder,
+			Tracer:      tracer,
 			Logger:      logger,
 		})
 		if err != nil {
-			return fmt.Errorf("could not create resource manager: %w", err)
+			return fmt.Errorf("could not create resource replacer: %w", err)
 		}
 
-		// Wrap the executor manager with hook manager. This is wrapped here because
-		// hooks should only be executed on real executions.
-		manager, err = managehook.NewManager(managehook.ManagerConfig{
+		manager, err = managereplace.NewManager(managereplace.ManagerConfig{
 			Manager:         manager,
+			Replacer:        replacer,
 			GroupRepository: newGroupRepo,
-			KubeConfig:      cmdConfig.Apply.KubeConfig,
-			KubeContext:     cmdConfig.Apply.KubeContext,
-			KubectlCmd:      cmdConfig.Apply.KubectlPath,
+			Recorder:        recorder,
 			Logger:          logger,
 		})
+		if err != nil {
+			return fmt.Errorf("could not create replace resource manager: %w", err)
+		}
+
+		// Retry the resources that fail due to transient errors, wrapped before the hooks
+		// so these are not executed again on retries.
+		manager, err = manageretry.NewManager(manageretry.ManagerConfig{
+			Manager:  manager,
+			Retries:  cmdConfig.Apply.KubeRetries,
+			Backoff:  cmdConfig.Apply.KubeRetryBackoff,
+			Recorder: recorder,
+			Logger:   logger,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create retry resource manager: %w", err)
+		}
+
+		// Wrap the executor manager with hook manager. This is wrapped here because
+		// hooks should only be executed on real executions.
+		manager, err = managehook.NewManager(managehook.ManagerConfig{
+			Manager:            manager,
+			GroupRepository:    newGroupRepo,
+			OldGroupRepository: oldGroupRepo,
+			KubeConfig:         cmdConfig.Apply.KubeConfig,
+			KubeContext:        cmdConfig.Apply.KubeContext,
+			KubectlCmd:         cmdConfig.Apply.KubectlPath,
+			Recorder:           recorder,
+			Tracer:             tracer,
+			YAMLEncoder:        kubernetesSerializer,
+			OldResources:       oldItems,
+			JobHooks:           jobHooks,
+			JobRunner:          kubeCli,
+			Concurrency:        globalConfig.AppConfig.Hooks.Concurrency,
+			Logger:             logger,
+		})
 		if err != nil {
 			return fmt.Errorf("could not create hook resource manager: %w", err)
 		}
 
-		// Set up report output.
-		switch cmdConfig.Apply.ReportPath {
-		case "":
-			// NOOP.
+		// Run level hooks, like group hooks, are only executed on real executions.
+		runHooks, err = managehook.NewRunExecutor(managehook.RunExecutorConfig{
+			Hooks:       globalConfig.AppConfig.Hooks,
+			KubeConfig:  cmdConfig.Apply.KubeConfig,
+			KubeContext: cmdConfig.Apply.KubeContext,
+			KubectlCmd:  cmdConfig.Apply.KubectlPath,
+			Recorder:    recorder,
+			Tracer:      tracer,
+			Logger:      logger,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create run hooks executor: %w", err)
+		}
 
-		// Write output to stdout.
-		case "-":
-			reportRepo = storagereport.NewJSONStateRepository(globalConfig.Stdout)
+		// Wrap the hook manager with the measure manager, so we measure the resources
+		// executed on real executions.
+		manager, err = managemeasure.NewManager(managemeasure.ManagerConfig{
+			Manager:  manager,
+			Recorder: metricsRec,
+			Logger:   logger,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create measure resource manager: %w", err)
+		}
 
-		// Anything else write as if it was a path to a file.
-		default:
-			outFile, err := os.Create(cmdConfig.Apply.ReportPath)
+		// Set up Kubernetes events.
+		if cmdConfig.Apply.KubeEvents {
+			eventRepo, err = storagekubernetes.NewEventStateRepository(storagekubernetes.EventStateRepositoryConfig{
+				Namespace: cmdConfig.Apply.KubeProviderNs,
+				Client:    kubeCli,
+				Logger:    logger,
+			})
 			if err != nil {
-				return fmt.Errorf("could not open file %q for out report: %w", cmdConfig.Apply.ReportPath, err)
+				return fmt.Errorf("could not create Kubernetes event repository: %w", err)
 			}
-			logger.Infof("report will be written to %q", cmdConfig.Apply.ReportPath)
-			defer outFile.Close()
-
-			reportRepo = storagereport.NewJSONStateRepository(outFile)
 		}
 	}
 
-	// Wrap resource manager with timeout manager if timeout is properly set
-	if cmdConfig.Apply.ExecutionTimeout != 0 {
-		manager, err = manageTimeout.NewManager(manageTimeout.ManagerConfig{
-			Timeout: cmdConfig.Apply.ExecutionTimeout,
-			Manager: manager,
-			Logger:  logger,
-		})
-		if err != nil {
-			return fmt.Errorf("could not create timeout manager: %w", err)
-		}
+	// Wrap resource manager with group timeout manager so the groups with timeout
+	// (including their hooks) are executed with their own timeout, and the rest
+	// with the execution timeout.
+	manager, err = manageTimeout.NewGroupManager(manageTimeout.GroupManagerConfig{
+		Manager:         manager,
+		GroupRepository: newGroupRepo,
+		DefaultTimeout:  cmdConfig.Apply.ExecutionTimeout,
+		Logger:          logger,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create group timeout manager: %w", err)
 	}
 
 	// Wrap manager with batch manager. This should wrap the executors managers
 	manager, err = managebatch.NewPriorityManager(managebatch.PriorityManagerConfig{
 		Manager:         manager,
+		Tracer:          tracer,
 		Logger:          logger,
 		GroupRepository: newGroupRepo,
+		Recorder:        recorder,
 	})
 	if err != nil {
 		return fmt.Errorf("could not create batch manager: %w", err)
@@ -307,6 +702,7 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 			KubeConfig:  cmdConfig.Apply.KubeConfig,
 			KubeContext: cmdConfig.Apply.KubeContext,
 			KubectlCmd:  cmdConfig.Apply.KubectlPath,
+			Tracer:      tracer,
 			Logger:      logger,
 		})
 		if err != nil {
@@ -322,20 +718,59 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 		}
 
 		if !proceed {
+			report.Status = model.ExecutionStatusCancelled
+			report.Error = "execution not confirmed"
 			return nil
 		}
 	}
 
-	// Execute actions on resources.
-	err = deleteApplyResources(ctx, manager, applyRes, deleteRes, cmdConfig.Apply.ApplyFirst)
-	if err != nil {
-		return err
+	// Execute actions on resources. Cancellations stop the execution without
+	// error, so we need to check the context to know if it has been cancelled.
+	execErr := runHooks.BeforeRun(ctx)
+	if execErr == nil {
+		execErr = deleteApplyResources(ctx, manager, applyRes, deleteRes, cmdConfig.Apply.ApplyFirst)
+	}
+	if execErr == nil {
+		execErr = movedPrinter.PrintMoved(ctx, movedUnchanged(movedRes, applyRes))
+	}
+	if execErr == nil {
+		execErr = ctx.Err()
+	}
+	if execErr == nil {
+		execErr = runHooks.AfterRun(ctx)
 	}
 
-	// Set applied/deleted resources on report.
+	// Set the execution result on report.
 	report.EndedAt = time.Now().UTC()
+	report.ResourceResults = internalreport.NewResourceResults(report.Batches, oldItems, report.ResourceResults)
+	internalreport.MeasureResourceResults(ctx, metricsRec, report.ResourceResults)
 	report.AppliedResources = applyRes
 	report.DeletedResources = deleteRes
+	report.ReleasedResources = releaseRes
+	report.MovedResources = movedRes
+	setReportResult(report, execErr)
+
+	if execErr != nil {
+		// Only the resources that have been executed correctly are part of the report.
+		report.AppliedResources = internalreport.SucceededResources(report.ResourceResults, model.OperationApply)
+		report.DeletedResources = internalreport.SucceededResources(report.ResourceResults, model.OperationDelete)
+
+		// The execution context could be cancelled, use a new one keeping the trace.
+		hookCtx := trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+		err = runHooks.OnFailure(hookCtx, execErr, report.AppliedResources, report.DeletedResources)
+		if err != nil {
+			logger.Errorf("%s", err)
+		}
+
+		// The report of the partial execution is shown, but the state is not stored
+		// because it wouldn't represent the real state of the cluster.
+		err = eventRepo.StoreState(context.Background(), *report)
+		if err != nil {
+			logger.Warningf("could not create Kubernetes events: %s", err)
+		}
+
+		return execErr
+	}
 
 	// Store executed state.
 	err = stateRepo.StoreState(ctx, *report)
@@ -343,31 +778,175 @@ func RunApply(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfi
 		return fmt.Errorf("could not store state: %w", err)
 	}
 
-	// Show report.
-	err = reportRepo.StoreState(ctx, *report)
+	// Events are informative, they don't fail the execution.
+	err = eventRepo.StoreState(ctx, *report)
 	if err != nil {
-		return fmt.Errorf("could not store report: %w", err)
+		logger.Warningf("could not create Kubernetes events: %s", err)
+	}
+
+	// At this point we had changes if there were resources to apply, delete or release (the
+	// moved resources without changes are not applied), except on diff mode, that we only know
+	// if there are changes after diffing.
+	hasChanges := len(applyRes)+len(deleteRes)+len(releaseRes) > 0
+	if cmdConfig.Apply.DetailedExitCode && hasChanges && (!cmdConfig.Apply.DiffMode || report.DiffChanges) {
+		return ErrChanges
+	}
+
+	return nil
+}
+
+// printPlan prints the plan using the plan output configuration, if enabled.
+func printPlan(ctx context.Context, cmdConfig CmdConfig, globalConfig GlobalConfig, groupRepo storage.GroupRepository, logger log.Logger, p planoutput.Plan) error {
+	if cmdConfig.Apply.PlanOutput == "" {
+		return nil
+	}
+
+	out := globalConfig.Stdout
+	if cmdConfig.Apply.PlanOutputPath != "-" {
+		outFile, err := os.Create(cmdConfig.Apply.PlanOutputPath)
+		if err != nil {
+			return fmt.Errorf("could not open file %q for plan output: %w", cmdConfig.Apply.PlanOutputPath, err)
+		}
+		logger.Infof("plan will be written to %q", cmdConfig.Apply.PlanOutputPath)
+		defer outFile.Close()
+
+		out = outFile
+	}
+
+	printer, err := planoutput.NewPrinter(planoutput.PrinterConfig{
+		Format:          planoutput.Format(cmdConfig.Apply.PlanOutput),
+		Out:             out,
+		GroupRepository: groupRepo,
+		Logger:          logger,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create plan printer: %w", err)
+	}
+
+	err = printer.Print(ctx, p)
+	if err != nil {
+		return fmt.Errorf("could not print plan: %w", err)
 	}
 
 	return nil
 }
 
 // splitPlan takes a list of resources from the plan and splits them by state.
-func splitPlan(statePlan []plan.State) (apply, delete []model.Resource, err error) {
+// Moved resources are also returned as moved, independently if they need to be applied or not.
+func splitPlan(statePlan []plan.State) (apply, delete []model.Resource, moved []model.MovedResource, err error) {
 	applyRes := []model.Resource{}
 	deleteRes := []model.Resource{}
+	movedRes := []model.MovedResource{}
 	for _, s := range statePlan {
 		switch s.State {
 		case plan.ResourceStateExists:
 			applyRes = append(applyRes, s.Resource)
 		case plan.ResourceStateMissing:
 			deleteRes = append(deleteRes, s.Resource)
+		case plan.ResourceStateMoved:
+			applyRes = append(applyRes, s.Resource)
+			movedRes = append(movedRes, model.MovedResource{Resource: s.Resource, OldGroupID: s.OldGroupID})
+		case plan.ResourceStateMovedUnchanged:
+			movedRes = append(movedRes, model.MovedResource{Resource: s.Resource, OldGroupID: s.OldGroupID})
 		default:
-			return nil, nil, fmt.Errorf("unknown resource state on plan: %s-%s", s.Resource.GroupID, s.Resource.ID)
+			return nil, nil, nil, fmt.Errorf("unknown resource state on plan: %s-%s", s.Resource.GroupID, s.Resource.ID)
+		}
+	}
+
+	return applyRes, deleteRes, movedRes, nil
+}
+
+// movedUnchanged returns the moved resources that are not applied.
+func movedUnchanged(moved []model.MovedResource, applyRes []model.Resource) []model.MovedResource {
+	applied := map[string]bool{}
+	for _, r := range applyRes {
+		applied[r.ID] = true
+	}
+
+	unchanged := []model.MovedResource{}
+	for _, m := range moved {
+		if !applied[m.Resource.ID] {
+			unchanged = append(unchanged, m)
+		}
+	}
+
+	return unchanged
+}
+
+// processMoved processes the moved resources with the resource processor, removing the
+// moved resources that have been filtered.
+func processMoved(ctx context.Context, resProc resourceprocess.ResourceProcessor, moved []model.MovedResource) ([]model.MovedResource, error) {
+	resources := make([]model.Resource, 0, len(moved))
+	for _, m := range moved {
+		resources = append(resources, m.Resource)
+	}
+
+	resources, err := resProc.Process(ctx, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := map[string]bool{}
+	for _, r := range resources {
+		keep[r.ID] = true
+	}
+
+	res := []model.MovedResource{}
+	for _, m := range moved {
+		if keep[m.Resource.ID] {
+			res = append(res, m)
+		}
+	}
+
+	return res, nil
+}
+
+// splitReleased takes the resources that need to be deleted and splits the ones that need to be released
+// (orphan delete policy). Released resources stop being managed by Kahoy but are not deleted from the cluster.
+func splitReleased(resources []model.Resource, appConfig model.AppConfig) (delete, release []model.Resource) {
+	deleteRes := []model.Resource{}
+	releaseRes := []model.Resource{}
+	for _, r := range resources {
+		groupPolicy := appConfig.Groups[r.GroupID].DeletePolicy
+		if r.DeletePolicy(groupPolicy) == model.DeletePolicyOrphan {
+			releaseRes = append(releaseRes, r)
+			continue
 		}
+		deleteRes = append(deleteRes, r)
 	}
 
-	return applyRes, deleteRes, nil
+	return deleteRes, releaseRes
+}
+
+// splitJobHooks takes the current and expected resources and splits the hook Jobs from them. The hook
+// Jobs are taken from the expected resources, and the pre delete hook Jobs that are only on the current
+// resources are also used so the deleted groups can execute them.
+func splitJobHooks(current, expected []model.Resource) (currentRes, expectedRes, jobHooks []model.Resource) {
+	expectedRes = []model.Resource{}
+	jobHooks = []model.Resource{}
+	expectedHooks := map[string]struct{}{}
+	for _, r := range expected {
+		if r.HookType() == "" {
+			expectedRes = append(expectedRes, r)
+			continue
+		}
+		jobHooks = append(jobHooks, r)
+		expectedHooks[r.ID] = struct{}{}
+	}
+
+	currentRes = []model.Resource{}
+	for _, r := range current {
+		hookType := r.HookType()
+		if hookType == "" {
+			currentRes = append(currentRes, r)
+			continue
+		}
+		if _, ok := expectedHooks[r.ID]; !ok && hookType == model.HookTypePreDelete {
+			jobHooks = append(jobHooks, r)
+		}
+	}
+
+	return currentRes, expectedRes, jobHooks
 }
 
 // newResourceProcessor will create the resource processor using a chain of multiple resource processors that will
@@ -480,3 +1059,63 @@ func deleteApplyResources(ctx context.Context, manager resourcemanage.ResourceMa
 
 	return nil
 }
+
+// setReportResult sets the final status of the execution on the report based
+// on the execution error.
+func setReportResult(report *model.State, err error) {
+	switch {
+	case err == nil, errors.Is(err, ErrChanges):
+		report.Status = model.ExecutionStatusSuccess
+	case errors.Is(err, context.Canceled):
+		report.Status = model.ExecutionStatusCancelled
+		report.Error = err.Error()
+	default:
+		report.Status = model.ExecutionStatusFailed
+		report.Error = err.Error()
+	}
+}
+
+func newReportRepository(format string, out io.Writer) storage.StateRepository {
+	switch format {
+	case ApplyReportFormatJUnit:
+		return storagereport.NewJUnitStateRepository(out)
+	case ApplyReportFormatMarkdown:
+		return storagereport.NewMarkdownStateRepository(out)
+	default:
+		return storagereport.NewJSONStateRepository(out)
+	}
+}
+
+// newNotifier returns the notifier of the execution lifecycle events, a webhook
+// notifier for each of the configured URLs.
+func newNotifier(cmdConfig CmdConfig, logger log.Logger) (notify.Notifier, error) {
+	if len(cmdConfig.Apply.NotifyWebhookURLs) == 0 {
+		return notify.Noop, nil
+	}
+
+	tpl := ""
+	if cmdConfig.Apply.NotifyWebhookTemplatePath != "" {
+		data, err := ioutil.ReadFile(cmdConfig.Apply.NotifyWebhookTemplatePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read webhook template %q: %w", cmdConfig.Apply.NotifyWebhookTemplatePath, err)
+		}
+		tpl = string(data)
+	}
+
+	notifiers := make([]notify.Notifier, 0, len(cmdConfig.Apply.NotifyWebhookURLs))
+	for _, u := range cmdConfig.Apply.NotifyWebhookURLs {
+		n, err := webhook.NewNotifier(webhook.NotifierConfig{
+			URL:      u,
+			Secret:   cmdConfig.Apply.NotifyWebhookSecret,
+			Template: tpl,
+			Retries:  cmdConfig.Apply.NotifyWebhookRetries,
+			Logger:   logger,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not create webhook notifier: %w", err)
+		}
+		notifiers = append(notifiers, n)
+	}
+
+	return notify.NewMultiNotifier(notifiers...), nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/kahoy/internal/log"
+)
+
+func TestRunApplyReport(t *testing.T) {
+	tests := map[string]struct {
+		config    func(dir string) CmdConfig
+		expErr    bool
+		expReport bool
+		expStatus string
+	}{
+		"Failing before executing, should write the failed report.": {
+			config: func(dir string) CmdConfig {
+				c := CmdConfig{}
+				c.Apply.Provider = ApplyProviderPaths
+				c.Apply.KubeConfig = filepath.Join(dir, "missing-kubeconfig")
+				c.Apply.ReportPath = filepath.Join(dir, "report.json")
+				c.Apply.ReportFormat = ApplyReportFormatJSON
+				return c
+			},
+			expErr:    true,
+			expReport: true,
+			expStatus: "failed",
+		},
+
+		"Failing before executing on dry-run, shouldn't write the report.": {
+			config: func(dir string) CmdConfig {
+				c := CmdConfig{}
+				c.Apply.Provider = ApplyProviderPaths
+				c.Apply.DryRun = true
+				c.Apply.KubeConfig = filepath.Join(dir, "missing-kubeconfig")
+				c.Apply.ReportPath = filepath.Join(dir, "report.json")
+				c.Apply.ReportFormat = ApplyReportFormatJSON
+				return c
+			},
+			expErr:    true,
+			expReport: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			dir := t.TempDir()
+			cmdConfig := test.config(dir)
+			globalConfig := GlobalConfig{
+				Logger: log.Noop,
+				Stdin:  &bytes.Buffer{},
+				Stdout: &bytes.Buffer{},
+				Stderr: &bytes.Buffer{},
+			}
+
+			err := RunApply(context.TODO(), cmdConfig, globalConfig)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			data, err := ioutil.ReadFile(cmdConfig.Apply.ReportPath)
+			if !test.expReport {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			gotReport := map[string]interface{}{}
+			err = json.Unmarshal(data, &gotReport)
+			require.NoError(err)
+			assert.Equal(test.expStatus, gotReport["status"])
+			assert.NotEmpty(gotReport["error"])
+		})
+	}
+}
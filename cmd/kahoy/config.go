@@ -7,6 +7,9 @@ import (
 
 	"gopkg.in/alecthomas/kingpin.v2"
 	"k8s.io/client-go/util/homedir"
+
+	"github.com/slok/kahoy/internal/model"
+	planoutput "github.com/slok/kahoy/internal/plan/output"
 )
 
 // Commandline subcommands IDs.
@@ -34,6 +37,13 @@ const (
 	ApplyProviderK8s   = "kubernetes"
 )
 
+// Apply report formats.
+const (
+	ApplyReportFormatJSON     = "json"
+	ApplyReportFormatJUnit    = "junit"
+	ApplyReportFormatMarkdown = "markdown"
+)
+
 // CmdConfig is the configuration of the command.
 type CmdConfig struct {
 	// Command is the loaded command.
@@ -50,30 +60,47 @@ type CmdConfig struct {
 
 	// Apply is the apply command configuration.
 	Apply struct {
-		KubeContext              string
-		KubeConfig               string
-		KubectlPath              string
-		ManifestsPathOld         string
-		ManifestsPathNew         string
-		DiffMode                 bool
-		ExcludeManifests         []string
-		IncludeManifests         []string
-		ExcludeKubeTypeResources []string
-		KubeLabelSelector        string
-		KubeAnnotationSelector   string
-		GitBeforeCommit          string
-		GitDefaultBranch         string
-		Provider                 string
-		DryRun                   bool
-		IncludeChanges           bool
-		ReportPath               string
-		AutoApprove              bool
-		CreateNamespace          bool
-		KubeProviderID           string
-		KubeProviderNs           string
-		IncludeNamespaces        []string
-		ExecutionTimeout         time.Duration
-		ApplyFirst               bool
+		KubeContext               string
+		KubeConfig                string
+		KubectlPath               string
+		ManifestsPathOld          string
+		ManifestsPathNew          string
+		DiffMode                  bool
+		ExcludeManifests          []string
+		IncludeManifests          []string
+		ExcludeKubeTypeResources  []string
+		KubeLabelSelector         string
+		KubeAnnotationSelector    string
+		GitBeforeCommit           string
+		GitDefaultBranch          string
+		Provider                  string
+		DryRun                    bool
+		IncludeChanges            bool
+		ReportPath                string
+		ReportFormat              string
+		MetricsTextfilePath       string
+		MetricsPushgatewayURL     string
+		TracingOTLPEndpoint       string
+		TracingFilePath           string
+		NotifyWebhookURLs         []string
+		NotifyWebhookSecret       string
+		NotifyWebhookTemplatePath string
+		NotifyWebhookRetries      int
+		AutoApprove               bool
+		CreateNamespace           bool
+		KubeProviderID            string
+		KubeProviderNs            string
+		KubeEvents                bool
+		IncludeNamespaces         []string
+		ExecutionTimeout          time.Duration
+		ApplyFirst                bool
+		KubeFieldManager          string
+		KubeConflictPolicy        string
+		KubeRetries               int
+		KubeRetryBackoff          time.Duration
+		PlanOutput                string
+		PlanOutputPath            string
+		DetailedExitCode          bool
 	}
 }
 
@@ -113,12 +140,29 @@ func NewCmdConfig(args []string) (*CmdConfig, error) {
 	apply.Flag("include-changes", "Alias for `--only-changes`.").BoolVar(&c.Apply.IncludeChanges)
 	apply.Flag("only-changes", "Excludes all the resources without changes (old vs new states).").Short('f').BoolVar(&c.Apply.IncludeChanges)
 	apply.Flag("report-path", "Path to a file where the report data will be written, use `-` for stdout or nothing to disable").Short('r').StringVar(&c.Apply.ReportPath)
+	apply.Flag("report-format", "Selects the report format.").Default(ApplyReportFormatJSON).EnumVar(&c.Apply.ReportFormat, ApplyReportFormatJSON, ApplyReportFormatJUnit, ApplyReportFormatMarkdown)
+	apply.Flag("metrics-textfile-path", "Path to a file where the execution Prometheus metrics will be written, e.g: for the node-exporter textfile collector.").StringVar(&c.Apply.MetricsTextfilePath)
+	apply.Flag("metrics-pushgateway-url", "Prometheus Pushgateway URL where the execution metrics will be pushed.").StringVar(&c.Apply.MetricsPushgatewayURL)
+	apply.Flag("tracing-otlp-endpoint", "OTLP HTTP collector endpoint URL where the execution traces will be exported (e.g: http://127.0.0.1:4318).").StringVar(&c.Apply.TracingOTLPEndpoint)
+	apply.Flag("tracing-file-path", "Path to a file where the execution traces will be written in JSON.").StringVar(&c.Apply.TracingFilePath)
+	apply.Flag("notify-webhook-url", "Webhook URL where the execution lifecycle events (run started, planned, batch finished, run succeeded and run failed) will be posted, the events have the execution mode (apply, dry-run or diff). Can be repeated.").StringsVar(&c.Apply.NotifyWebhookURLs)
+	apply.Flag("notify-webhook-secret", "Secret used to sign the webhook payloads with HMAC SHA256, the signature is set on 'X-Kahoy-Signature-256' header.").StringVar(&c.Apply.NotifyWebhookSecret)
+	apply.Flag("notify-webhook-template-path", "Path to a Go template file used to render the webhook payloads, by default the events are posted as JSON.").StringVar(&c.Apply.NotifyWebhookTemplatePath)
+	apply.Flag("notify-webhook-retries", "Number of retries of the failed webhook notifications, the notifications are synchronous and each event notification (including retries) is limited to 30s.").Default("3").IntVar(&c.Apply.NotifyWebhookRetries)
 	apply.Flag("auto-approve", "applies changes without asking for confirmation. Useful to run Kahoy on non interactive scenarios like CI.").BoolVar(&c.Apply.AutoApprove)
 	apply.Flag("create-namespace", "creates missing namespaces of the applied resources, used in regular and diff exacution modes.").BoolVar(&c.Apply.CreateNamespace)
 	apply.Flag("kube-provider-id", "Kubernetes storage provider ID.").StringVar(&c.Apply.KubeProviderID)
 	apply.Flag("kube-provider-namespace", "Kubernetes storage provider namespace.").Default("default").StringVar(&c.Apply.KubeProviderNs)
+	apply.Flag("kube-events", "Creates Kubernetes events on the applied and deleted resources, and a summary event of the execution on the Kubernetes storage provider namespace.").BoolVar(&c.Apply.KubeEvents)
 	apply.Flag("include-namespace", "Regex to include certain namespaces and ignore everything else. It's useful to scope down the execution. Can be repeated.").StringsVar(&c.Apply.IncludeNamespaces)
-	apply.Flag("execution-timeout", "This argments sets a timeout for each apply and delete execution. Use 0 to disable.").Default("5m").DurationVar(&c.Apply.ExecutionTimeout)
+	apply.Flag("execution-timeout", "This argments sets a timeout for each apply and delete execution of the groups without timeout. Use 0 to disable.").Default("5m").DurationVar(&c.Apply.ExecutionTimeout)
+	apply.Flag("kube-field-manager", "Kubernetes field manager name used to track the ownership of the applied fields. If not set it will use kubectl default field manager.").StringVar(&c.Apply.KubeFieldManager)
+	apply.Flag("kube-conflict-policy", "Default policy used when applied fields are owned by other field managers, can be overridden per group. 'force' takes the ownership, 'fail' fails the apply and 'skip-field' applies without the conflicting fields.").Default(string(model.ConflictPolicyForce)).EnumVar(&c.Apply.KubeConflictPolicy, string(model.ConflictPolicyForce), string(model.ConflictPolicyFail), string(model.ConflictPolicySkipField))
+	apply.Flag("kube-retries", "Number of retries of the resources that fail due to transient apiserver errors (e.g: throttling, timeouts). Use 0 to disable.").Default("3").IntVar(&c.Apply.KubeRetries)
+	apply.Flag("kube-retry-backoff", "Initial wait between retries of resources that fail due to transient errors, doubled on every retry.").Default("1s").DurationVar(&c.Apply.KubeRetryBackoff)
+	apply.Flag("plan-output", "Outputs the execution plan in the selected format on all execution modes, before executing anything.").EnumVar(&c.Apply.PlanOutput, string(planoutput.FormatJSON), string(planoutput.FormatYAML), string(planoutput.FormatMarkdown))
+	apply.Flag("plan-output-path", "Path to a file where the plan output will be written, use `-` for stdout (the dry-run and diff outputs will be written to stderr).").Default("-").StringVar(&c.Apply.PlanOutputPath)
+	apply.Flag("detailed-exit-code", "Returns a detailed exit code: 0 when there are no changes, 1 on errors and 2 when there are changes (planned in dry-run, found in diff or applied).").BoolVar(&c.Apply.DetailedExitCode)
 	apply.Flag("apply-first", "Inverts execution of resource actions, if enabled, resource apply stage happens before delete. By default it will delete and then apply.").BoolVar(&c.Apply.ApplyFirst)
 
 	// Version command.
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -22,6 +23,15 @@ var (
 	Version = "dev"
 )
 
+// ErrChanges is returned by the commands when detailed exit codes are enabled
+// and there are changes.
+var ErrChanges = errors.New("changes present")
+
+const (
+	exitCodeError   = 1
+	exitCodeChanges = 2
+)
+
 // GlobalConfig is the configuration shared by all the commands.
 type GlobalConfig struct {
 	AppConfig model.AppConfig
@@ -160,8 +170,12 @@ func main() {
 
 	err := Run(ctx, os.Args, os.Stdin, os.Stdout, os.Stderr)
 	if err != nil {
+		if errors.Is(err, ErrChanges) {
+			os.Exit(exitCodeChanges)
+		}
+
 		fmt.Fprintf(os.Stderr, "error: %s\n", err)
-		os.Exit(1)
+		os.Exit(exitCodeError)
 	}
 	os.Exit(0)
 }
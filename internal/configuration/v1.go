@@ -3,11 +3,14 @@ package configuration
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ghodss/yaml"
 
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/fieldpath"
 )
 
 type jsonV1 struct {
@@ -16,15 +19,35 @@ type jsonV1 struct {
 		Exclude []string `json:"exclude"`
 		Include []string `json:"include"`
 	} `json:"fs"`
-	Groups []jsonGroupV1 `json:"groups"`
+	Groups       []jsonGroupV1        `json:"groups"`
+	IgnoreFields []jsonIgnoreFieldsV1 `json:"ignoreFields"`
+	Hooks        struct {
+		BeforeRun   *jsonHookV1 `json:"beforeRun,omitempty"`
+		AfterRun    *jsonHookV1 `json:"afterRun,omitempty"`
+		OnFailure   *jsonHookV1 `json:"onFailure,omitempty"`
+		Concurrency int         `json:"concurrency,omitempty"`
+	} `json:"hooks"`
+}
+
+type jsonIgnoreFieldsV1 struct {
+	KubeType string   `json:"kubeType,omitempty"`
+	Group    string   `json:"group,omitempty"`
+	Paths    []string `json:"paths"`
 }
 
 type jsonGroupV1 struct {
-	ID       string `json:"id"`
-	Priority *int   `json:"priority,omitempty"`
-	Hooks    struct {
-		Pre  *jsonHookV1 `json:"pre,omitempty"`
-		Post *jsonHookV1 `json:"post,omitempty"`
+	ID                 string `json:"id"`
+	Priority           *int   `json:"priority,omitempty"`
+	DeletePolicy       string `json:"deletePolicy,omitempty"`
+	ReplaceOnImmutable bool   `json:"replaceOnImmutable,omitempty"`
+	ConflictPolicy     string `json:"conflictPolicy,omitempty"`
+	Timeout            string `json:"timeout,omitempty"`
+	Hooks              struct {
+		Pre        *jsonHookV1 `json:"pre,omitempty"`
+		Post       *jsonHookV1 `json:"post,omitempty"`
+		PreDelete  *jsonHookV1 `json:"preDelete,omitempty"`
+		PostDelete *jsonHookV1 `json:"postDelete,omitempty"`
+		Order      int         `json:"order,omitempty"`
 	} `json:"hooks"`
 	Wait struct {
 		Duration string `json:"duration,omitempty"` // Deprecated.
@@ -32,8 +55,26 @@ type jsonGroupV1 struct {
 }
 
 type jsonHookV1 struct {
-	Cmd     string `json:"cmd,omitempty"`
-	Timeout string `json:"timeout,omitempty"`
+	Cmd          string            `json:"cmd,omitempty"`
+	Args         []string          `json:"args,omitempty"`
+	Shell        bool              `json:"shell,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+	Workdir      string            `json:"workdir,omitempty"`
+	Timeout      string            `json:"timeout,omitempty"`
+	Retries      int               `json:"retries,omitempty"`
+	RetryBackoff string            `json:"retryBackoff,omitempty"`
+	HTTP         *jsonHTTPHookV1   `json:"http,omitempty"`
+}
+
+type jsonHTTPHookV1 struct {
+	Method         string            `json:"method,omitempty"`
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           string            `json:"body,omitempty"`
+	ExpectedStatus []int             `json:"expectedStatus,omitempty"`
+	JSONPath       string            `json:"jsonPath,omitempty"`
+	ExpectedValue  string            `json:"expectedValue,omitempty"`
+	PollInterval   string            `json:"pollInterval,omitempty"`
 }
 
 func (j jsonV1) toModel() (*model.AppConfig, error) {
@@ -57,15 +98,86 @@ func (j jsonV1) toModel() (*model.AppConfig, error) {
 		groups[g.ID] = *gm
 	}
 
+	// Map ignore fields.
+	var ignoreFields []model.IgnoreFieldsRule
+	for _, i := range j.IgnoreFields {
+		if len(i.Paths) == 0 {
+			return nil, fmt.Errorf("ignore fields rule without paths")
+		}
+
+		ignoreFields = append(ignoreFields, model.IgnoreFieldsRule{
+			KubeTypeRegex: i.KubeType,
+			GroupRegex:    i.Group,
+			Paths:         i.Paths,
+		})
+	}
+
+	// Map run hooks.
+	if j.Hooks.Concurrency < 0 {
+		return nil, fmt.Errorf("hooks concurrency can't be negative")
+	}
+	hooks := model.RunHooksConfig{Concurrency: j.Hooks.Concurrency}
+	var err error
+	if j.Hooks.BeforeRun != nil {
+		hooks.BeforeRun, err = j.Hooks.BeforeRun.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("invalid before run hook: %w", err)
+		}
+	}
+
+	if j.Hooks.AfterRun != nil {
+		hooks.AfterRun, err = j.Hooks.AfterRun.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("invalid after run hook: %w", err)
+		}
+	}
+
+	if j.Hooks.OnFailure != nil {
+		hooks.OnFailure, err = j.Hooks.OnFailure.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("invalid on failure hook: %w", err)
+		}
+	}
+
 	return &model.AppConfig{
-		Fs:     fs,
-		Groups: groups,
+		Fs:           fs,
+		Groups:       groups,
+		IgnoreFields: ignoreFields,
+		Hooks:        hooks,
 	}, nil
 }
 
 func (j jsonGroupV1) toModel() (*model.GroupConfig, error) {
 	groupConfig := &model.GroupConfig{
-		Priority: j.Priority,
+		Priority:           j.Priority,
+		ReplaceOnImmutable: j.ReplaceOnImmutable,
+	}
+
+	if j.DeletePolicy != "" {
+		deletePolicy := model.DeletePolicy(j.DeletePolicy)
+		if !deletePolicy.Valid() {
+			return nil, fmt.Errorf("invalid delete policy %q", j.DeletePolicy)
+		}
+		groupConfig.DeletePolicy = deletePolicy
+	}
+
+	if j.ConflictPolicy != "" {
+		conflictPolicy := model.ConflictPolicy(j.ConflictPolicy)
+		if !conflictPolicy.Valid() {
+			return nil, fmt.Errorf("invalid conflict policy %q", j.ConflictPolicy)
+		}
+		groupConfig.ConflictPolicy = conflictPolicy
+	}
+
+	if j.Timeout != "" {
+		t, err := time.ParseDuration(j.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %s: %w", j.Timeout, err)
+		}
+		if t < 0 {
+			return nil, fmt.Errorf("timeout can't be negative")
+		}
+		groupConfig.Timeout = t
 	}
 
 	// Don't allow deprecated waiting schema in configuration.
@@ -73,6 +185,8 @@ func (j jsonGroupV1) toModel() (*model.GroupConfig, error) {
 		return nil, fmt.Errorf("deprecated wait statement is being used, use `hooks` instead")
 	}
 
+	groupConfig.HooksConfig.Order = j.Hooks.Order
+
 	var err error
 	if j.Hooks.Pre != nil {
 		groupConfig.HooksConfig.Pre, err = j.Hooks.Pre.toModel()
@@ -88,12 +202,35 @@ func (j jsonGroupV1) toModel() (*model.GroupConfig, error) {
 		}
 	}
 
+	if j.Hooks.PreDelete != nil {
+		groupConfig.HooksConfig.PreDelete, err = j.Hooks.PreDelete.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("invalid pre delete hook: %w", err)
+		}
+	}
+
+	if j.Hooks.PostDelete != nil {
+		groupConfig.HooksConfig.PostDelete, err = j.Hooks.PostDelete.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("invalid post delete hook: %w", err)
+		}
+	}
+
 	return groupConfig, nil
 }
 
 func (j jsonHookV1) toModel() (*model.GroupHookConfigSpec, error) {
-	if len(j.Cmd) == 0 {
-		return nil, fmt.Errorf("hook command is required")
+	switch {
+	case len(j.Cmd) == 0 && len(j.Args) == 0 && j.HTTP == nil:
+		return nil, fmt.Errorf("hook command or http is required")
+	case len(j.Cmd) != 0 && len(j.Args) != 0:
+		return nil, fmt.Errorf("hook command and args can't be used at the same time")
+	case (len(j.Cmd) != 0 || len(j.Args) != 0) && j.HTTP != nil:
+		return nil, fmt.Errorf("hook command and http can't be used at the same time")
+	case j.Shell && len(j.Cmd) == 0:
+		return nil, fmt.Errorf("hook shell mode requires a command")
+	case j.Retries < 0:
+		return nil, fmt.Errorf("hook retries can't be negative")
 	}
 
 	if j.Timeout == "" {
@@ -104,10 +241,89 @@ func (j jsonHookV1) toModel() (*model.GroupHookConfigSpec, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid duration %s: %w", j.Timeout, err)
 	}
+
+	var backoff time.Duration
+	if j.RetryBackoff != "" {
+		backoff, err = time.ParseDuration(j.RetryBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry backoff %s: %w", j.RetryBackoff, err)
+		}
+	}
+
+	var httpHook *model.HTTPHook
+	if j.HTTP != nil {
+		httpHook, err = j.HTTP.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("invalid http hook: %w", err)
+		}
+
+		// Polling stops only when the hook times out.
+		if httpHook.PollInterval > 0 && t <= 0 {
+			return nil, fmt.Errorf("http hook poll interval requires a timeout")
+		}
+	}
+
 	return &model.GroupHookConfigSpec{
-		Cmd:     j.Cmd,
-		Timeout: t,
-	}, err
+		Cmd:          j.Cmd,
+		Timeout:      t,
+		Args:         j.Args,
+		Shell:        j.Shell,
+		Env:          j.Env,
+		Workdir:      j.Workdir,
+		Retries:      j.Retries,
+		RetryBackoff: backoff,
+		HTTP:         httpHook,
+	}, nil
+}
+
+func (j jsonHTTPHookV1) toModel() (*model.HTTPHook, error) {
+	if j.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	if j.Method == "" {
+		j.Method = http.MethodGet
+	}
+
+	for _, status := range j.ExpectedStatus {
+		if status < 100 || status > 599 {
+			return nil, fmt.Errorf("invalid expected status code %d", status)
+		}
+	}
+
+	if j.ExpectedValue != "" && j.JSONPath == "" {
+		return nil, fmt.Errorf("expected value requires a json path")
+	}
+
+	if j.JSONPath != "" {
+		_, err := fieldpath.Parse(j.JSONPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid json path: %w", err)
+		}
+	}
+
+	var pollInterval time.Duration
+	if j.PollInterval != "" {
+		var err error
+		pollInterval, err = time.ParseDuration(j.PollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid poll interval %s: %w", j.PollInterval, err)
+		}
+		if pollInterval <= 0 {
+			return nil, fmt.Errorf("poll interval must be positive")
+		}
+	}
+
+	return &model.HTTPHook{
+		Method:         strings.ToUpper(j.Method),
+		URL:            j.URL,
+		Headers:        j.Headers,
+		Body:           j.Body,
+		ExpectedStatus: j.ExpectedStatus,
+		JSONPath:       j.JSONPath,
+		ExpectedValue:  j.ExpectedValue,
+		PollInterval:   pollInterval,
+	}, nil
 }
 
 // NewYAMLV1Loader returns a loader that knows how to load configuration from a
@@ -44,6 +44,10 @@ fs:
 groups:
   - id: "prometheus/crd"
     priority: 50
+    deletePolicy: orphan
+    replaceOnImmutable: true
+    conflictPolicy: skip-field
+    timeout: 20m
     hooks:
       pre:
         cmd: cmd1
@@ -51,6 +55,31 @@ groups:
       post:
         timeout: 15s
         cmd: cmd2 --arg1=value1 --arg2 value2
+
+      preDelete:
+        cmd: cmd3
+
+      postDelete:
+        timeout: 5s
+        cmd: cmd4
+
+      order: 2
+ignoreFields:
+  - kubeType: apps/v1/Deployment
+    paths:
+      - spec.replicas
+  - group: "apps/.*"
+    paths:
+      - metadata.annotations[some-controller/*]
+hooks:
+  beforeRun:
+    cmd: cmd5
+    timeout: 1m
+  afterRun:
+    cmd: cmd6
+  onFailure:
+    cmd: cmd7
+  concurrency: 1
 `,
 			expConfig: model.AppConfig{
 				Fs: model.FsConfig{
@@ -65,7 +94,11 @@ groups:
 				},
 				Groups: map[string]model.GroupConfig{
 					"prometheus/crd": {
-						Priority: intVal(50),
+						Priority:           intVal(50),
+						DeletePolicy:       model.DeletePolicyOrphan,
+						ReplaceOnImmutable: true,
+						ConflictPolicy:     model.ConflictPolicySkipField,
+						Timeout:            20 * time.Minute,
 						HooksConfig: model.GroupHooksConfig{
 							Pre: &model.GroupHookConfigSpec{
 								Cmd:     "cmd1",
@@ -75,12 +108,40 @@ groups:
 								Cmd:     "cmd2 --arg1=value1 --arg2 value2",
 								Timeout: 15 * time.Second,
 							},
+							PreDelete: &model.GroupHookConfigSpec{
+								Cmd:     "cmd3",
+								Timeout: 0,
+							},
+							PostDelete: &model.GroupHookConfigSpec{
+								Cmd:     "cmd4",
+								Timeout: 5 * time.Second,
+							},
+							Order: 2,
 						},
 					},
 				},
+				IgnoreFields: []model.IgnoreFieldsRule{
+					{KubeTypeRegex: "apps/v1/Deployment", Paths: []string{"spec.replicas"}},
+					{GroupRegex: "apps/.*", Paths: []string{"metadata.annotations[some-controller/*]"}},
+				},
+				Hooks: model.RunHooksConfig{
+					BeforeRun:   &model.GroupHookConfigSpec{Cmd: "cmd5", Timeout: time.Minute},
+					AfterRun:    &model.GroupHookConfigSpec{Cmd: "cmd6"},
+					OnFailure:   &model.GroupHookConfigSpec{Cmd: "cmd7"},
+					Concurrency: 1,
+				},
 			},
 		},
 
+		"Ignore fields rules without paths should fail.": {
+			data: `
+version: v1
+ignoreFields:
+  - kubeType: apps/v1/Deployment
+`,
+			expErr: true,
+		},
+
 		"Invalid timeout on hook should fail.": {
 			data: `
 version: v1
@@ -108,6 +169,292 @@ groups:
 			expErr: true,
 		},
 
+		"Structured hooks should be loaded correctly.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        args: ["./wait.sh", "--msg", "hello world"]
+        env:
+          K1: V1
+        workdir: /tmp
+        retries: 2
+        retryBackoff: 500ms
+      post:
+        shell: true
+        cmd: kubectl get pods | grep Running
+`,
+			expConfig: model.AppConfig{
+				Groups: map[string]model.GroupConfig{
+					"test": {
+						HooksConfig: model.GroupHooksConfig{
+							Pre: &model.GroupHookConfigSpec{
+								Args:         []string{"./wait.sh", "--msg", "hello world"},
+								Env:          map[string]string{"K1": "V1"},
+								Workdir:      "/tmp",
+								Retries:      2,
+								RetryBackoff: 500 * time.Millisecond,
+							},
+							Post: &model.GroupHookConfigSpec{
+								Cmd:   "kubectl get pods | grep Running",
+								Shell: true,
+							},
+						},
+					},
+				},
+			},
+		},
+
+		"Hook with command and args should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        cmd: cmd1
+        args: ["cmd1"]
+`,
+			expErr: true,
+		},
+
+		"Hook with shell mode and args should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        shell: true
+        args: ["cmd1"]
+`,
+			expErr: true,
+		},
+
+		"Hook with negative retries should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        cmd: cmd1
+        retries: -1
+`,
+			expErr: true,
+		},
+
+		"HTTP hooks should be loaded correctly.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        timeout: 5m
+        http:
+          method: post
+          url: https://gate.test/deploy
+          headers:
+            Authorization: Bearer test
+          body: '{"group":"{{ .Group }}"}'
+          expectedStatus: [200, 202]
+          jsonPath: .status
+          expectedValue: open
+          pollInterval: 10s
+      post:
+        http:
+          url: https://app.test/health
+`,
+			expConfig: model.AppConfig{
+				Groups: map[string]model.GroupConfig{
+					"test": {
+						HooksConfig: model.GroupHooksConfig{
+							Pre: &model.GroupHookConfigSpec{
+								Timeout: 5 * time.Minute,
+								HTTP: &model.HTTPHook{
+									Method:         "POST",
+									URL:            "https://gate.test/deploy",
+									Headers:        map[string]string{"Authorization": "Bearer test"},
+									Body:           `{"group":"{{ .Group }}"}`,
+									ExpectedStatus: []int{200, 202},
+									JSONPath:       ".status",
+									ExpectedValue:  "open",
+									PollInterval:   10 * time.Second,
+								},
+							},
+							Post: &model.GroupHookConfigSpec{
+								HTTP: &model.HTTPHook{
+									Method: "GET",
+									URL:    "https://app.test/health",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		"HTTP hook without URL should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        http:
+          method: GET
+`,
+			expErr: true,
+		},
+
+		"Hook with command and HTTP should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        cmd: cmd1
+        http:
+          url: https://app.test/health
+`,
+			expErr: true,
+		},
+
+		"HTTP hook with poll interval and without timeout should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        http:
+          url: https://app.test/health
+          pollInterval: 10s
+`,
+			expErr: true,
+		},
+
+		"HTTP hook with poll interval and zero timeout should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        timeout: 0s
+        http:
+          url: https://app.test/health
+          pollInterval: 10s
+`,
+			expErr: true,
+		},
+
+		"HTTP hook with a non positive poll interval should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        timeout: 5m
+        http:
+          url: https://app.test/health
+          pollInterval: -1s
+`,
+			expErr: true,
+		},
+
+		"HTTP hook with expected value and without JSON path should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        http:
+          url: https://app.test/health
+          expectedValue: ok
+`,
+			expErr: true,
+		},
+
+		"HTTP hook with invalid expected status should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    hooks:
+      pre:
+        http:
+          url: https://app.test/health
+          expectedStatus: [42]
+`,
+			expErr: true,
+		},
+
+		"Negative hooks concurrency should fail.": {
+			data: `
+version: v1
+hooks:
+  concurrency: -1
+`,
+			expErr: true,
+		},
+
+		"Run hook without command should fail.": {
+			data: `
+version: v1
+hooks:
+  onFailure:
+    timeout: 10s
+`,
+			expErr: true,
+		},
+
+		"Invalid delete policy on a group should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    deletePolicy: wrong
+`,
+			expErr: true,
+		},
+
+		"Invalid conflict policy on a group should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    conflictPolicy: wrong
+`,
+			expErr: true,
+		},
+
+		"Invalid timeout on a group should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    timeout: wrong
+`,
+			expErr: true,
+		},
+
+		"Negative timeout on a group should fail.": {
+			data: `
+version: v1
+groups:
+  - id: "test"
+    timeout: -5m
+`,
+			expErr: true,
+		},
+
 		"Empty group IDs can't be mapped to model.": {
 			data: `
 version: v1
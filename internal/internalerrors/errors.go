@@ -7,4 +7,11 @@ var (
 	ErrNotValid = errors.New("not valid")
 	// ErrMissing is used when a resource is missing.
 	ErrMissing = errors.New("is missing")
+	// ErrImmutable is used when a resource change is rejected because it changes immutable fields.
+	ErrImmutable = errors.New("immutable field change")
+	// ErrConflict is used when a resource change has field ownership conflicts with other field managers.
+	ErrConflict = errors.New("field ownership conflict")
+	// ErrTransient is used when a resource change fails due to a temporary error (e.g: apiserver
+	// throttling, timeouts or connection errors) and can be retried.
+	ErrTransient = errors.New("transient error")
 )
@@ -1,8 +1,13 @@
 package kubernetes
 
 import (
+	"bufio"
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -11,6 +16,7 @@ import (
 
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/manage/hook"
 	storagekubernetes "github.com/slok/kahoy/internal/storage/kubernetes"
 )
 
@@ -22,7 +28,9 @@ type Client struct {
 }
 
 var _ storagekubernetes.K8sClient = Client{}
+var _ storagekubernetes.EventClient = Client{}
 var _ model.KubernetesDiscoveryClient = Client{}
+var _ hook.JobRunner = Client{}
 
 // NewClient returns a new Kubernetes client.
 func NewClient(coreCli kubernetes.Interface, logger log.Logger) Client {
@@ -118,6 +126,154 @@ func (c Client) EnsureMissingSecret(ctx context.Context, ns, name string) error
 	return nil
 }
 
+// CreateEvent creates a Kubernetes event.
+func (c Client) CreateEvent(ctx context.Context, event *corev1.Event) error {
+	logger := c.logger.WithValues(log.Kv{"obj-ns": event.Namespace, "obj-name": event.Name})
+
+	_, err := c.coreCli.CoreV1().Events(event.Namespace).Create(ctx, event, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("event has been created")
+	return nil
+}
+
+// jobPollInterval is the interval used to check the state of the Jobs.
+var jobPollInterval = 2 * time.Second
+
+// RunJob creates the Job and waits until it has finished, the logs of the Job pods
+// are streamed to the received logger.
+func (c Client) RunJob(ctx context.Context, job *batchv1.Job, jobLogger log.Logger) error {
+	logger := c.logger.WithValues(log.Kv{"obj-ns": job.Namespace, "obj-name": job.Name})
+
+	_, err := c.coreCli.BatchV1().Jobs(job.Namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	logger.Debugf("job has been created")
+
+	// Wait until all the logs have been streamed.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// Stream the logs of the Job pods that have started and have not been streamed yet.
+	streamedPods := map[string]struct{}{}
+	podOpts := metav1.ListOptions{
+		LabelSelector: labels.Set{"job-name": job.Name}.String(),
+	}
+	streamPods := func() error {
+		pods, err := c.coreCli.CoreV1().Pods(job.Namespace).List(ctx, podOpts)
+		if err != nil {
+			return fmt.Errorf("could not list job pods: %w", err)
+		}
+		for _, pod := range pods.Items {
+			if _, ok := streamedPods[pod.Name]; ok || pod.Status.Phase == corev1.PodPending {
+				continue
+			}
+			streamedPods[pod.Name] = struct{}{}
+
+			for _, container := range pod.Spec.Containers {
+				wg.Add(1)
+				go func(pod, container string) {
+					defer wg.Done()
+					c.streamPodLogs(ctx, job.Namespace, pod, container, jobLogger)
+				}(pod.Name, container.Name)
+			}
+		}
+		return nil
+	}
+
+	for {
+		err := streamPods()
+		if err != nil {
+			return err
+		}
+
+		// Check if the Job has finished, the pods that have started and finished since the
+		// last check are streamed before returning, so their logs are not lost.
+		storedJob, err := c.coreCli.BatchV1().Jobs(job.Namespace).Get(ctx, job.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("could not get job: %w", err)
+		}
+		for _, cond := range storedJob.Status.Conditions {
+			if cond.Status != corev1.ConditionTrue {
+				continue
+			}
+
+			switch cond.Type {
+			case batchv1.JobComplete:
+				logger.Debugf("job has completed")
+				return streamPods()
+			case batchv1.JobFailed:
+				err := streamPods()
+				if err != nil {
+					logger.Warningf("%s", err)
+				}
+				return fmt.Errorf("job failed: %s: %s", cond.Reason, cond.Message)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+// streamPodLogs sends the logs of a pod container to the logger until the container finishes.
+func (c Client) streamPodLogs(ctx context.Context, ns, pod, container string, logger log.Logger) {
+	logger = logger.WithValues(log.Kv{"pod": pod, "container": container})
+
+	stream, err := c.coreCli.CoreV1().Pods(ns).GetLogs(pod, &corev1.PodLogOptions{Container: container, Follow: true}).Stream(ctx)
+	if err != nil {
+		logger.Warningf("could not stream pod logs: %s", err)
+		return
+	}
+	defer stream.Close()
+
+	outStream := bufio.NewScanner(stream)
+	for outStream.Scan() {
+		logger.Infof("%s", outStream.Text())
+	}
+}
+
+// DeleteJob deletes the Job and its pods and waits until the Job is gone, it's a noop if the
+// Job doesn't exist.
+func (c Client) DeleteJob(ctx context.Context, ns, name string) error {
+	logger := c.logger.WithValues(log.Kv{"obj-ns": ns, "obj-name": name})
+
+	propagation := metav1.DeletePropagationBackground
+	err := c.coreCli.BatchV1().Jobs(ns).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil {
+		if kubeerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// Wait until the Job is gone so it can be created again.
+	for {
+		_, err := c.coreCli.BatchV1().Jobs(ns).Get(ctx, name, metav1.GetOptions{})
+		if kubeerrors.IsNotFound(err) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jobPollInterval):
+		}
+	}
+
+	logger.Debugf("job has been deleted")
+	return nil
+}
+
 // GetServerGroupsAndResources returns the group and resource types from the API server.
 func (c Client) GetServerGroupsAndResources(ctx context.Context) ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
 	grs, res, err := c.coreCli.Discovery().ServerGroupsAndResources()
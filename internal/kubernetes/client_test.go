@@ -0,0 +1,129 @@
+package kubernetes_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/slok/kahoy/internal/kubernetes"
+	"github.com/slok/kahoy/internal/log"
+)
+
+func TestClientRunJob(t *testing.T) {
+	tests := map[string]struct {
+		condition   batchv1.JobConditionType
+		podOnFinish bool
+		expErr      bool
+	}{
+		"A completed job should finish without error.": {
+			condition: batchv1.JobComplete,
+		},
+
+		"A failed job should finish with error.": {
+			condition: batchv1.JobFailed,
+			expErr:    true,
+		},
+
+		"A completed job with pods started after the last pods check should stream their logs.": {
+			condition:   batchv1.JobComplete,
+			podOnFinish: true,
+		},
+
+		"A failed job with pods started after the last pods check should stream their logs.": {
+			condition:   batchv1.JobFailed,
+			podOnFinish: true,
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Set the Job finished condition when created.
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "test-pod", Labels: map[string]string{"job-name": "test-job"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "test"}}},
+			}
+			cli := fake.NewSimpleClientset()
+			if !test.podOnFinish {
+				require.NoError(cli.Tracker().Add(pod))
+			}
+			cli.PrependReactor("create", "jobs", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				job := action.(kubetesting.CreateAction).GetObject().(*batchv1.Job)
+				job.Status.Conditions = []batchv1.JobCondition{{Type: test.condition, Status: corev1.ConditionTrue}}
+				return false, nil, nil
+			})
+
+			// Start the pod after listing the pods for the first time, when checking the Job state.
+			cli.PrependReactor("get", "jobs", func(action kubetesting.Action) (bool, runtime.Object, error) {
+				if test.podOnFinish {
+					_ = cli.Tracker().Add(pod)
+				}
+				return false, nil, nil
+			})
+
+			c := kubernetes.NewClient(cli, log.Noop)
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "test-job"}}
+			err := c.RunJob(context.TODO(), job, log.Noop)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			// The Job pod logs should have been streamed.
+			streamed := false
+			for _, action := range cli.Actions() {
+				if action.Matches("get", "pods") && action.GetSubresource() == "log" {
+					streamed = true
+				}
+			}
+			assert.True(streamed)
+
+			// The Job should have been created.
+			_, err = cli.BatchV1().Jobs("test-ns").Get(context.TODO(), "test-job", metav1.GetOptions{})
+			require.NoError(err)
+		})
+	}
+}
+
+func TestClientDeleteJob(t *testing.T) {
+	tests := map[string]struct {
+		objs []runtime.Object
+	}{
+		"Deleting an existing job should delete it.": {
+			objs: []runtime.Object{
+				&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "test-job"}},
+			},
+		},
+
+		"Deleting a missing job should not fail.": {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cli := fake.NewSimpleClientset(test.objs...)
+			c := kubernetes.NewClient(cli, log.Noop)
+			err := c.DeleteJob(context.TODO(), "test-ns", "test-job")
+			require.NoError(err)
+
+			_, err = cli.BatchV1().Jobs("test-ns").Get(context.TODO(), "test-job", metav1.GetOptions{})
+			assert.True(kubeerrors.IsNotFound(err))
+		})
+	}
+}
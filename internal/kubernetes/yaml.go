@@ -15,6 +15,7 @@ import (
 
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/manage/hook"
 	"github.com/slok/kahoy/internal/resource/manage/kubectl"
 	storagefs "github.com/slok/kahoy/internal/storage/fs"
 	storagekubernetes "github.com/slok/kahoy/internal/storage/kubernetes"
@@ -32,6 +33,7 @@ type YAMLObjectSerializer struct {
 var (
 	_ storagefs.K8sObjectDecoder            = YAMLObjectSerializer{}
 	_ kubectl.K8sObjectEncoder              = YAMLObjectSerializer{}
+	_ hook.K8sObjectEncoder                 = YAMLObjectSerializer{}
 	_ storagekubernetes.K8sObjectSerializer = YAMLObjectSerializer{}
 )
 
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/slok/kahoy/internal/model"
+)
+
+// Recorder knows how to record the metrics of Kahoy executions.
+type Recorder interface {
+	// ObserveRun measures the duration of a full execution.
+	ObserveRun(ctx context.Context, status model.ExecutionStatus, duration time.Duration)
+	// ObservePlan measures the duration of the planning and the planned resources by state.
+	ObservePlan(ctx context.Context, success bool, duration time.Duration, resourcesByState map[string]int)
+	// AddResourceOperations counts the resources executed of a group.
+	AddResourceOperations(ctx context.Context, op model.Operation, groupID string, success bool, quantity int)
+	// AddOutcomes counts the executed resources of a group by outcome (e.g: created, configured, unchanged).
+	AddOutcomes(ctx context.Context, op model.Operation, groupID string, outcome model.ResourceOutcome, quantity int)
+	// ObserveBatch measures the duration of a batch execution.
+	ObserveBatch(ctx context.Context, op model.Operation, priority string, success bool, duration time.Duration)
+	// ObserveHook measures the duration of a group hook execution.
+	ObserveHook(ctx context.Context, groupID, hookType string, success bool, duration time.Duration)
+}
+
+//go:generate mockery --case underscore --output metricsmock --outpkg metricsmock --name Recorder
+
+// Noop recorder doesn't record anything.
+const Noop = noop(0)
+
+type noop int
+
+func (noop) ObserveRun(context.Context, model.ExecutionStatus, time.Duration)                 {}
+func (noop) ObservePlan(context.Context, bool, time.Duration, map[string]int)                 {}
+func (noop) AddResourceOperations(context.Context, model.Operation, string, bool, int)        {}
+func (noop) AddOutcomes(context.Context, model.Operation, string, model.ResourceOutcome, int) {}
+func (noop) ObserveBatch(context.Context, model.Operation, string, bool, time.Duration)       {}
+func (noop) ObserveHook(context.Context, string, string, bool, time.Duration)                 {}
@@ -0,0 +1,92 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"github.com/slok/kahoy/internal/log"
+)
+
+// Exporter knows how to export the gathered metrics.
+type Exporter interface {
+	Export(ctx context.Context) error
+}
+
+// ExporterConfig is the configuration of the exporter.
+type ExporterConfig struct {
+	// Gatherer is where the metrics will be gathered from.
+	Gatherer prometheus.Gatherer
+	// TextfilePath is the path to the file where the metrics will be written
+	// in text format, e.g: for the node-exporter textfile collector.
+	TextfilePath string
+	// PushgatewayURL is the Prometheus pushgateway URL where the metrics will be pushed.
+	PushgatewayURL string
+	// Job is the job name used when pushing to the pushgateway.
+	Job    string
+	Logger log.Logger
+}
+
+func (c *ExporterConfig) defaults() error {
+	if c.Gatherer == nil {
+		return fmt.Errorf("gatherer is required")
+	}
+
+	if c.Job == "" {
+		c.Job = "kahoy"
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "prometheus.Exporter"})
+
+	return nil
+}
+
+type exporter struct {
+	gatherer       prometheus.Gatherer
+	textfilePath   string
+	pushgatewayURL string
+	job            string
+	logger         log.Logger
+}
+
+// NewExporter returns a new exporter that will export the metrics to the configured
+// textfile and pushgateway. If none of them are configured, it will not export anything.
+func NewExporter(config ExporterConfig) (Exporter, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return exporter{
+		gatherer:       config.Gatherer,
+		textfilePath:   config.TextfilePath,
+		pushgatewayURL: config.PushgatewayURL,
+		job:            config.Job,
+		logger:         config.Logger,
+	}, nil
+}
+
+func (e exporter) Export(ctx context.Context) error {
+	if e.textfilePath != "" {
+		err := prometheus.WriteToTextfile(e.textfilePath, e.gatherer)
+		if err != nil {
+			return fmt.Errorf("could not write metrics textfile: %w", err)
+		}
+		e.logger.Debugf("metrics written to %q", e.textfilePath)
+	}
+
+	if e.pushgatewayURL != "" {
+		err := push.New(e.pushgatewayURL, e.job).Gatherer(e.gatherer).Push()
+		if err != nil {
+			return fmt.Errorf("could not push metrics: %w", err)
+		}
+		e.logger.Debugf("metrics pushed to %q", e.pushgatewayURL)
+	}
+
+	return nil
+}
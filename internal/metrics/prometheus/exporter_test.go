@@ -0,0 +1,96 @@
+package prometheus_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metricsprometheus "github.com/slok/kahoy/internal/metrics/prometheus"
+	"github.com/slok/kahoy/internal/model"
+)
+
+const expRunMetric = `kahoy_run_duration_seconds{status="success"} 1`
+
+func TestExporterTextfile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	reg := prometheus.NewRegistry()
+	metricsprometheus.NewRecorder(reg).ObserveRun(context.TODO(), model.ExecutionStatusSuccess, time.Second)
+
+	path := filepath.Join(t.TempDir(), "kahoy.prom")
+	exporter, err := metricsprometheus.NewExporter(metricsprometheus.ExporterConfig{
+		Gatherer:     reg,
+		TextfilePath: path,
+	})
+	require.NoError(err)
+
+	err = exporter.Export(context.TODO())
+	require.NoError(err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(err)
+	assert.Contains(string(data), expRunMetric)
+}
+
+func TestExporterPushgateway(t *testing.T) {
+	tests := map[string]struct {
+		statusCode int
+		expErr     bool
+	}{
+		"Pushing the metrics correctly should push the metrics to the pushgateway job.": {
+			statusCode: http.StatusOK,
+		},
+
+		"Having an error on the pushgateway should fail.": {
+			statusCode: http.StatusInternalServerError,
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Pushgateway stand-in.
+			var gotMethod, gotPath, gotBody string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				gotMethod, gotPath, gotBody = r.Method, r.URL.Path, string(body)
+				w.WriteHeader(test.statusCode)
+			}))
+			defer srv.Close()
+
+			reg := prometheus.NewRegistry()
+			metricsprometheus.NewRecorder(reg).ObserveRun(context.TODO(), model.ExecutionStatusSuccess, time.Second)
+
+			exporter, err := metricsprometheus.NewExporter(metricsprometheus.ExporterConfig{
+				Gatherer:       reg,
+				PushgatewayURL: srv.URL,
+			})
+			require.NoError(err)
+
+			err = exporter.Export(context.TODO())
+
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			if assert.NoError(err) {
+				assert.Equal(http.MethodPut, gotMethod)
+				assert.Equal("/metrics/job/kahoy", gotPath)
+				assert.NotEmpty(gotBody)
+			}
+		})
+	}
+}
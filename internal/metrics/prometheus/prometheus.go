@@ -0,0 +1,130 @@
+package prometheus
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/slok/kahoy/internal/metrics"
+	"github.com/slok/kahoy/internal/model"
+)
+
+const prefix = "kahoy"
+
+type recorder struct {
+	runDuration        *prometheus.GaugeVec
+	runLastTimestamp   *prometheus.GaugeVec
+	planDuration       *prometheus.GaugeVec
+	plannedResources   *prometheus.GaugeVec
+	resourceOperations *prometheus.CounterVec
+	resourceResults    *prometheus.CounterVec
+	batchDuration      *prometheus.HistogramVec
+	hookDuration       *prometheus.HistogramVec
+}
+
+// NewRecorder returns a new metrics recorder that registers the metrics
+// on the received Prometheus registerer.
+func NewRecorder(reg prometheus.Registerer) metrics.Recorder {
+	r := &recorder{
+		runDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Subsystem: "run",
+			Name:      "duration_seconds",
+			Help:      "The duration of the last Kahoy execution.",
+		}, []string{"status"}),
+
+		runLastTimestamp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Subsystem: "run",
+			Name:      "last_timestamp_seconds",
+			Help:      "The timestamp when the last Kahoy execution ended.",
+		}, []string{"status"}),
+
+		planDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Subsystem: "plan",
+			Name:      "duration_seconds",
+			Help:      "The duration of the resources planning.",
+		}, []string{"success"}),
+
+		plannedResources: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Subsystem: "plan",
+			Name:      "resources",
+			Help:      "The quantity of planned resources by state.",
+		}, []string{"state"}),
+
+		resourceOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Subsystem: "resource",
+			Name:      "operations_total",
+			Help:      "The quantity of resources executed by operation and group.",
+		}, []string{"operation", "group", "success"}),
+
+		resourceResults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Subsystem: "resource",
+			Name:      "results_total",
+			Help:      "The quantity of resources executed by operation, group and outcome.",
+		}, []string{"operation", "group", "outcome"}),
+
+		batchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prefix,
+			Subsystem: "batch",
+			Name:      "duration_seconds",
+			Help:      "The duration of the resource batch executions.",
+			Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600},
+		}, []string{"operation", "priority", "success"}),
+
+		hookDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prefix,
+			Subsystem: "hook",
+			Name:      "duration_seconds",
+			Help:      "The duration of the group hook executions.",
+			Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600},
+		}, []string{"group", "type", "success"}),
+	}
+
+	reg.MustRegister(
+		r.runDuration,
+		r.runLastTimestamp,
+		r.planDuration,
+		r.plannedResources,
+		r.resourceOperations,
+		r.resourceResults,
+		r.batchDuration,
+		r.hookDuration,
+	)
+
+	return r
+}
+
+func (r recorder) ObserveRun(ctx context.Context, status model.ExecutionStatus, duration time.Duration) {
+	r.runDuration.WithLabelValues(string(status)).Set(duration.Seconds())
+	r.runLastTimestamp.WithLabelValues(string(status)).SetToCurrentTime()
+}
+
+func (r recorder) ObservePlan(ctx context.Context, success bool, duration time.Duration, resourcesByState map[string]int) {
+	r.planDuration.WithLabelValues(strconv.FormatBool(success)).Set(duration.Seconds())
+	for state, q := range resourcesByState {
+		r.plannedResources.WithLabelValues(state).Set(float64(q))
+	}
+}
+
+func (r recorder) AddResourceOperations(ctx context.Context, op model.Operation, groupID string, success bool, quantity int) {
+	r.resourceOperations.WithLabelValues(string(op), groupID, strconv.FormatBool(success)).Add(float64(quantity))
+}
+
+func (r recorder) AddOutcomes(ctx context.Context, op model.Operation, groupID string, outcome model.ResourceOutcome, quantity int) {
+	r.resourceResults.WithLabelValues(string(op), groupID, string(outcome)).Add(float64(quantity))
+}
+
+func (r recorder) ObserveBatch(ctx context.Context, op model.Operation, priority string, success bool, duration time.Duration) {
+	r.batchDuration.WithLabelValues(string(op), priority, strconv.FormatBool(success)).Observe(duration.Seconds())
+}
+
+func (r recorder) ObserveHook(ctx context.Context, groupID, hookType string, success bool, duration time.Duration) {
+	r.hookDuration.WithLabelValues(groupID, hookType, strconv.FormatBool(success)).Observe(duration.Seconds())
+}
@@ -0,0 +1,118 @@
+package prometheus_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/kahoy/internal/metrics"
+	metricsprometheus "github.com/slok/kahoy/internal/metrics/prometheus"
+	"github.com/slok/kahoy/internal/model"
+)
+
+func TestRecorder(t *testing.T) {
+	tests := map[string]struct {
+		measure    func(r metrics.Recorder)
+		expMetrics string
+		expNames   []string
+	}{
+		"Measuring runs should record the run duration by status.": {
+			measure: func(r metrics.Recorder) {
+				r.ObserveRun(context.TODO(), model.ExecutionStatusFailed, 42*time.Second)
+			},
+			expMetrics: `
+# HELP kahoy_run_duration_seconds The duration of the last Kahoy execution.
+# TYPE kahoy_run_duration_seconds gauge
+kahoy_run_duration_seconds{status="failed"} 42
+`,
+			expNames: []string{"kahoy_run_duration_seconds"},
+		},
+
+		"Measuring plans should record the plan duration and the planned resources.": {
+			measure: func(r metrics.Recorder) {
+				r.ObservePlan(context.TODO(), true, 2*time.Second, map[string]int{"exists": 5, "missing": 2})
+			},
+			expMetrics: `
+# HELP kahoy_plan_duration_seconds The duration of the resources planning.
+# TYPE kahoy_plan_duration_seconds gauge
+kahoy_plan_duration_seconds{success="true"} 2
+# HELP kahoy_plan_resources The quantity of planned resources by state.
+# TYPE kahoy_plan_resources gauge
+kahoy_plan_resources{state="exists"} 5
+kahoy_plan_resources{state="missing"} 2
+`,
+			expNames: []string{"kahoy_plan_duration_seconds", "kahoy_plan_resources"},
+		},
+
+		"Measuring resource operations should count the resources by operation, group and success.": {
+			measure: func(r metrics.Recorder) {
+				r.AddResourceOperations(context.TODO(), model.OperationApply, "g1", true, 3)
+				r.AddResourceOperations(context.TODO(), model.OperationApply, "g1", true, 2)
+				r.AddResourceOperations(context.TODO(), model.OperationDelete, "g2", false, 1)
+			},
+			expMetrics: `
+# HELP kahoy_resource_operations_total The quantity of resources executed by operation and group.
+# TYPE kahoy_resource_operations_total counter
+kahoy_resource_operations_total{group="g1",operation="apply",success="true"} 5
+kahoy_resource_operations_total{group="g2",operation="delete",success="false"} 1
+`,
+			expNames: []string{"kahoy_resource_operations_total"},
+		},
+
+		"Measuring resource results should count the resources by operation, group and outcome.": {
+			measure: func(r metrics.Recorder) {
+				r.AddOutcomes(context.TODO(), model.OperationApply, "g1", model.ResourceOutcomeCreated, 3)
+				r.AddOutcomes(context.TODO(), model.OperationApply, "g1", model.ResourceOutcomeUnchanged, 2)
+				r.AddOutcomes(context.TODO(), model.OperationDelete, "g2", model.ResourceOutcomeNotFound, 1)
+			},
+			expMetrics: `
+# HELP kahoy_resource_results_total The quantity of resources executed by operation, group and outcome.
+# TYPE kahoy_resource_results_total counter
+kahoy_resource_results_total{group="g1",operation="apply",outcome="created"} 3
+kahoy_resource_results_total{group="g1",operation="apply",outcome="unchanged"} 2
+kahoy_resource_results_total{group="g2",operation="delete",outcome="not-found"} 1
+`,
+			expNames: []string{"kahoy_resource_results_total"},
+		},
+
+		"Measuring hooks should record the hook durations by group, type and success.": {
+			measure: func(r metrics.Recorder) {
+				r.ObserveHook(context.TODO(), "g1", "pre", false, 7*time.Second)
+			},
+			expMetrics: `
+# HELP kahoy_hook_duration_seconds The duration of the group hook executions.
+# TYPE kahoy_hook_duration_seconds histogram
+kahoy_hook_duration_seconds_bucket{group="g1",success="false",type="pre",le="1"} 0
+kahoy_hook_duration_seconds_bucket{group="g1",success="false",type="pre",le="5"} 0
+kahoy_hook_duration_seconds_bucket{group="g1",success="false",type="pre",le="10"} 1
+kahoy_hook_duration_seconds_bucket{group="g1",success="false",type="pre",le="30"} 1
+kahoy_hook_duration_seconds_bucket{group="g1",success="false",type="pre",le="60"} 1
+kahoy_hook_duration_seconds_bucket{group="g1",success="false",type="pre",le="120"} 1
+kahoy_hook_duration_seconds_bucket{group="g1",success="false",type="pre",le="300"} 1
+kahoy_hook_duration_seconds_bucket{group="g1",success="false",type="pre",le="600"} 1
+kahoy_hook_duration_seconds_bucket{group="g1",success="false",type="pre",le="+Inf"} 1
+kahoy_hook_duration_seconds_sum{group="g1",success="false",type="pre"} 7
+kahoy_hook_duration_seconds_count{group="g1",success="false",type="pre"} 1
+`,
+			expNames: []string{"kahoy_hook_duration_seconds"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			reg := prometheus.NewRegistry()
+			rec := metricsprometheus.NewRecorder(reg)
+			test.measure(rec)
+
+			err := testutil.GatherAndCompare(reg, strings.NewReader(test.expMetrics), test.expNames...)
+			assert.NoError(err)
+		})
+	}
+}
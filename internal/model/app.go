@@ -11,6 +11,35 @@ type AppConfig struct {
 	Fs FsConfig
 	// Group configuration by ID
 	Groups map[string]GroupConfig
+	// IgnoreFields are the rules of the resources fields that will be ignored.
+	IgnoreFields []IgnoreFieldsRule
+	// Hooks are the hooks executed at run level.
+	Hooks RunHooksConfig
+}
+
+// RunHooksConfig has the run level hooks options.
+type RunHooksConfig struct {
+	// BeforeRun is executed after planning and before executing anything.
+	BeforeRun *GroupHookConfigSpec
+	// AfterRun is executed after a correct execution.
+	AfterRun *GroupHookConfigSpec
+	// OnFailure is executed when the execution fails.
+	OnFailure *GroupHookConfigSpec
+	// Concurrency is the maximum number of group hooks executed at the same time,
+	// if 0 there is no limit.
+	Concurrency int
+}
+
+// IgnoreFieldsRule has the fields that will be ignored (not applied nor compared) on
+// the resources that match the rule.
+type IgnoreFieldsRule struct {
+	// KubeTypeRegex matches the Kubernetes type of the resource (e.g: `apps/v1/Deployment`),
+	// if empty it will match all.
+	KubeTypeRegex string
+	// GroupRegex matches the group ID of the resource, if empty it will match all.
+	GroupRegex string
+	// Paths are the field paths that will be ignored.
+	Paths []string
 }
 
 // FsConfig is the Fs configuration.
@@ -23,18 +52,68 @@ type FsConfig struct {
 type GroupConfig struct {
 	Priority    *int
 	HooksConfig GroupHooksConfig
+	// DeletePolicy is the delete policy of the group resources, if empty
+	// it will use the default delete policy.
+	DeletePolicy DeletePolicy
+	// ReplaceOnImmutable enables replacing the group resources when a change is
+	// rejected due to immutable fields changes.
+	ReplaceOnImmutable bool
+	// ConflictPolicy is the field ownership conflict policy of the group resources,
+	// if empty it will use the default conflict policy.
+	ConflictPolicy ConflictPolicy
+	// Timeout is the maximum duration of the group resources apply or delete executions,
+	// including the group hooks. 0 means no group timeout.
+	Timeout time.Duration
 }
 
 // GroupHooksConfig has a group hooks options.
 type GroupHooksConfig struct {
-	Pre  *GroupHookConfigSpec
-	Post *GroupHookConfigSpec
+	Pre        *GroupHookConfigSpec
+	Post       *GroupHookConfigSpec
+	PreDelete  *GroupHookConfigSpec
+	PostDelete *GroupHookConfigSpec
+	// Order is the weight used to execute the group hooks, the hooks with
+	// lower order are executed first.
+	Order int
 }
 
 // GroupHookConfigSpec is the spec of hook configuration.
 type GroupHookConfigSpec struct {
 	Cmd     string
 	Timeout time.Duration
+	// Args is the command and its arguments, alternative to Cmd.
+	Args []string
+	// Shell executes Cmd using a shell.
+	Shell bool
+	// Env are the env vars passed to the command in addition to the default ones.
+	Env map[string]string
+	// Workdir is the command working directory, if empty it will use the Kahoy execution
+	// directory, if relative it will be resolved against the group directory on group hooks.
+	Workdir string
+	// Retries is the number of retries when the command fails.
+	Retries int
+	// RetryBackoff is the initial wait between retries, it doubles on each retry.
+	RetryBackoff time.Duration
+	// HTTP makes the hook execute an HTTP request instead of a command.
+	HTTP *HTTPHook
+}
+
+// HTTPHook is the spec of a hook that executes an HTTP request.
+type HTTPHook struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	// Body is a Go template rendered with the hook group and resources information.
+	Body string
+	// ExpectedStatus are the response status codes that make the hook succeed, if
+	// empty any 2xx status code will succeed.
+	ExpectedStatus []int
+	// JSONPath is the field path of the JSON response body that is checked, the field
+	// needs to exist and have the ExpectedValue if set.
+	JSONPath      string
+	ExpectedValue string
+	// PollInterval enables polling the request until it succeeds or the hook times out.
+	PollInterval time.Duration
 }
 
 // Validate will validate the app configuration.
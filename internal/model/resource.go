@@ -3,12 +3,15 @@ package model
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/slok/kahoy/internal/internalerrors"
 	"github.com/slok/kahoy/internal/log"
 )
 
@@ -26,24 +29,284 @@ type Resource struct {
 	K8sObject    K8sObject
 }
 
+// Kahoy Kubernetes annotations that can be set on the resources to customize
+// how Kahoy manages them.
+const (
+	// AnnotationOnDelete sets the resource delete policy, has preference over the group delete policy.
+	AnnotationOnDelete = "kahoy.slok.dev/on-delete"
+	// AnnotationReplaceOnImmutable enables replacing (delete and create) the resource when a change
+	// is rejected due to immutable fields changes, has preference over the group configuration.
+	AnnotationReplaceOnImmutable = "kahoy.slok.dev/replace-on-immutable"
+	// AnnotationApplyStrategy sets the strategy used to apply the resource on the cluster.
+	AnnotationApplyStrategy = "kahoy.slok.dev/apply-strategy"
+	// AnnotationHook marks the resource as a hook Job that will be executed in the cluster
+	// on a group stage, instead of being managed as a regular resource.
+	AnnotationHook = "kahoy.slok.dev/hook"
+	// AnnotationHookDeletePolicy sets when the hook Job is deleted, multiple policies can be
+	// set separated by commas.
+	AnnotationHookDeletePolicy = "kahoy.slok.dev/hook-delete-policy"
+	// AnnotationHookTimeout sets the maximum duration of the hook Job execution.
+	AnnotationHookTimeout = "kahoy.slok.dev/hook-timeout"
+)
+
+// DefaultHookJobTimeout is the timeout of the hook Jobs that don't set one.
+const DefaultHookJobTimeout = 15 * time.Minute
+
+// HookType is the stage of the group execution where a hook Job is executed.
+type HookType string
+
+const (
+	// HookTypePreApply executes the hook Job before applying the group resources.
+	HookTypePreApply HookType = "pre-apply"
+	// HookTypePostApply executes the hook Job after applying the group resources.
+	HookTypePostApply HookType = "post-apply"
+	// HookTypePreDelete executes the hook Job before deleting the group resources.
+	HookTypePreDelete HookType = "pre-delete"
+)
+
+// Valid returns true if the hook type is a known one.
+func (h HookType) Valid() bool {
+	switch h {
+	case HookTypePreApply, HookTypePostApply, HookTypePreDelete:
+		return true
+	}
+
+	return false
+}
+
+// HookDeletePolicy is the policy used to know when a hook Job needs to be deleted.
+type HookDeletePolicy string
+
+const (
+	// HookDeletePolicyBeforeHookCreation will delete the previous hook Job before creating
+	// the new one. This is the default policy.
+	HookDeletePolicyBeforeHookCreation HookDeletePolicy = "before-hook-creation"
+	// HookDeletePolicyHookSucceeded will delete the hook Job after it succeeds.
+	HookDeletePolicyHookSucceeded HookDeletePolicy = "hook-succeeded"
+	// HookDeletePolicyHookFailed will delete the hook Job after it fails.
+	HookDeletePolicyHookFailed HookDeletePolicy = "hook-failed"
+)
+
+// Valid returns true if the hook delete policy is a known one.
+func (h HookDeletePolicy) Valid() bool {
+	switch h {
+	case HookDeletePolicyBeforeHookCreation, HookDeletePolicyHookSucceeded, HookDeletePolicyHookFailed:
+		return true
+	}
+
+	return false
+}
+
+// hookJobTypeID is the only Kubernetes type that can be used as a hook.
+const hookJobTypeID = "batch/v1/Job"
+
+// ApplyStrategy is the strategy used to apply a resource on the cluster.
+type ApplyStrategy string
+
+const (
+	// ApplyStrategyServerSide will apply the resource using server-side apply. This is the default strategy.
+	ApplyStrategyServerSide ApplyStrategy = "server-side"
+	// ApplyStrategyCreateOnly will only create the resource if missing, it will never be overwritten.
+	ApplyStrategyCreateOnly ApplyStrategy = "create-only"
+	// ApplyStrategyReplace will replace the full resource, creating it if missing.
+	ApplyStrategyReplace ApplyStrategy = "replace"
+)
+
+// Valid returns true if the apply strategy is a known one.
+func (a ApplyStrategy) Valid() bool {
+	switch a {
+	case ApplyStrategyServerSide, ApplyStrategyCreateOnly, ApplyStrategyReplace:
+		return true
+	}
+
+	return false
+}
+
+// ConflictPolicy is the policy used when a resource apply has field ownership conflicts
+// with other field managers (e.g: HPA controller, operators, humans...).
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyForce will force the ownership of the conflicting fields. This is the default policy.
+	ConflictPolicyForce ConflictPolicy = "force"
+	// ConflictPolicyFail will fail the apply of the resource.
+	ConflictPolicyFail ConflictPolicy = "fail"
+	// ConflictPolicySkipField will apply the resource without the conflicting fields, leaving
+	// their ownership to the other field managers.
+	ConflictPolicySkipField ConflictPolicy = "skip-field"
+)
+
+// Valid returns true if the conflict policy is a known one.
+func (c ConflictPolicy) Valid() bool {
+	switch c {
+	case ConflictPolicyForce, ConflictPolicyFail, ConflictPolicySkipField:
+		return true
+	}
+
+	return false
+}
+
+// FieldConflict represents the fields of a resource that are owned by other field manager.
+type FieldConflict struct {
+	Manager string
+	Fields  []string
+}
+
+// DeletePolicy is the policy used when a resource needs to be deleted.
+type DeletePolicy string
+
+const (
+	// DeletePolicyDelete will delete the resource from the cluster. This is the default policy.
+	DeletePolicyDelete DeletePolicy = "delete"
+	// DeletePolicyOrphan will stop managing the resource without deleting it from the cluster.
+	DeletePolicyOrphan DeletePolicy = "orphan"
+)
+
+// Valid returns true if the delete policy is a known one.
+func (d DeletePolicy) Valid() bool {
+	switch d {
+	case DeletePolicyDelete, DeletePolicyOrphan:
+		return true
+	}
+
+	return false
+}
+
+// DeletePolicy returns the delete policy of the resource. The policy set on the resource
+// annotation has preference over the received group policy. If none of them are set,
+// it will fallback to the default delete policy.
+func (r Resource) DeletePolicy(groupPolicy DeletePolicy) DeletePolicy {
+	if r.K8sObject != nil {
+		if p := DeletePolicy(r.K8sObject.GetAnnotations()[AnnotationOnDelete]); p != "" {
+			return p
+		}
+	}
+
+	if groupPolicy != "" {
+		return groupPolicy
+	}
+
+	return DeletePolicyDelete
+}
+
+// ReplaceOnImmutable returns true if the resource should be replaced when a change is rejected
+// due to immutable field changes. The resource annotation has preference over the received
+// group option.
+func (r Resource) ReplaceOnImmutable(groupReplace bool) bool {
+	if r.K8sObject != nil {
+		v, ok := r.K8sObject.GetAnnotations()[AnnotationReplaceOnImmutable]
+		if ok {
+			replace, _ := strconv.ParseBool(v)
+			return replace
+		}
+	}
+
+	return groupReplace
+}
+
+// ApplyStrategy returns the apply strategy of the resource set on the resource annotation,
+// if not set, it will fallback to the default apply strategy.
+func (r Resource) ApplyStrategy() ApplyStrategy {
+	if r.K8sObject != nil {
+		if s := ApplyStrategy(r.K8sObject.GetAnnotations()[AnnotationApplyStrategy]); s != "" {
+			return s
+		}
+	}
+
+	return ApplyStrategyServerSide
+}
+
+// HookType returns the hook type of the resource set on the resource annotation, if the
+// resource is not a hook, it will return an empty hook type.
+func (r Resource) HookType() HookType {
+	if r.K8sObject == nil {
+		return ""
+	}
+
+	return HookType(r.K8sObject.GetAnnotations()[AnnotationHook])
+}
+
+// HookDeletePolicies returns the hook delete policies of the resource set on the resource
+// annotation, if not set, it will fallback to the default hook delete policy.
+func (r Resource) HookDeletePolicies() []HookDeletePolicy {
+	policies := []HookDeletePolicy{}
+	if r.K8sObject != nil {
+		for _, p := range strings.Split(r.K8sObject.GetAnnotations()[AnnotationHookDeletePolicy], ",") {
+			p = strings.TrimSpace(p)
+			if p != "" {
+				policies = append(policies, HookDeletePolicy(p))
+			}
+		}
+	}
+
+	if len(policies) == 0 {
+		return []HookDeletePolicy{HookDeletePolicyBeforeHookCreation}
+	}
+
+	return policies
+}
+
+// HookTimeout returns the hook Job timeout set on the resource annotation, if not set (or
+// invalid), it will fallback to the default hook Job timeout.
+func (r Resource) HookTimeout() time.Duration {
+	if r.K8sObject == nil {
+		return DefaultHookJobTimeout
+	}
+
+	t, err := time.ParseDuration(r.K8sObject.GetAnnotations()[AnnotationHookTimeout])
+	if err != nil || t <= 0 {
+		return DefaultHookJobTimeout
+	}
+
+	return t
+}
+
 // Group represents a group of resources.
 type Group struct {
-	ID       string
-	Path     string
-	Priority int
-	Hooks    GroupHooks
+	ID                 string
+	Path               string
+	Priority           int
+	Hooks              GroupHooks
+	ReplaceOnImmutable bool
+	// ConflictPolicy is the field ownership conflict policy of the group resources,
+	// if empty it should use the default one.
+	ConflictPolicy ConflictPolicy
+	// Timeout is the maximum duration of the group executions, 0 means no timeout.
+	Timeout time.Duration
 }
 
 // GroupHooks tells what are the hooks.
 type GroupHooks struct {
 	Pre  *GroupHookSpec
 	Post *GroupHookSpec
+	// PreDelete and PostDelete are executed around the deletion of the
+	// group resources.
+	PreDelete  *GroupHookSpec
+	PostDelete *GroupHookSpec
+	// Order is the weight used to execute the group hooks, the hooks with
+	// lower order are executed first, and the ones with the same order concurrently.
+	Order int
 }
 
 // GroupHookSpec are the hook options.
 type GroupHookSpec struct {
 	Cmd     string
 	Timeout time.Duration
+	// Args is the command and its arguments, alternative to Cmd.
+	Args []string
+	// Shell executes Cmd using a shell.
+	Shell bool
+	// Env are the env vars passed to the command in addition to the default ones.
+	Env map[string]string
+	// Workdir is the command working directory, relative command paths are resolved
+	// against it. Empty means the Kahoy execution directory.
+	Workdir string
+	// Retries is the number of retries when the command fails.
+	Retries int
+	// RetryBackoff is the initial wait between retries, it doubles on each retry.
+	RetryBackoff time.Duration
+	// HTTP makes the hook execute an HTTP request instead of a command.
+	HTTP *HTTPHook
 }
 
 // KubernetesDiscoveryClient is the client used to discover resource types on
@@ -97,6 +360,44 @@ func (r ResourceAndGroupFactory) NewResource(k8sObject K8sObject, groupID string
 	}
 
 	modelID := r.genResourceID(k8sObject)
+
+	// Validate Kahoy annotations.
+	onDelete, ok := k8sObject.GetAnnotations()[AnnotationOnDelete]
+	if ok && !DeletePolicy(onDelete).Valid() {
+		return nil, fmt.Errorf("%w: invalid %q annotation value on %s: %q", internalerrors.ErrNotValid, AnnotationOnDelete, modelID, onDelete)
+	}
+	replace, ok := k8sObject.GetAnnotations()[AnnotationReplaceOnImmutable]
+	if _, err := strconv.ParseBool(replace); ok && err != nil {
+		return nil, fmt.Errorf("%w: invalid %q annotation value on %s: %q", internalerrors.ErrNotValid, AnnotationReplaceOnImmutable, modelID, replace)
+	}
+	strategy, ok := k8sObject.GetAnnotations()[AnnotationApplyStrategy]
+	if ok && !ApplyStrategy(strategy).Valid() {
+		return nil, fmt.Errorf("%w: invalid %q annotation value on %s: %q", internalerrors.ErrNotValid, AnnotationApplyStrategy, modelID, strategy)
+	}
+	hookType, ok := k8sObject.GetAnnotations()[AnnotationHook]
+	if ok {
+		if !HookType(hookType).Valid() {
+			return nil, fmt.Errorf("%w: invalid %q annotation value on %s: %q", internalerrors.ErrNotValid, AnnotationHook, modelID, hookType)
+		}
+		if id != hookJobTypeID {
+			return nil, fmt.Errorf("%w: %q annotation is only supported on %s resources, got %s", internalerrors.ErrNotValid, AnnotationHook, hookJobTypeID, modelID)
+		}
+	}
+	hookDeletePolicy, ok := k8sObject.GetAnnotations()[AnnotationHookDeletePolicy]
+	if ok {
+		for _, p := range strings.Split(hookDeletePolicy, ",") {
+			if !HookDeletePolicy(strings.TrimSpace(p)).Valid() {
+				return nil, fmt.Errorf("%w: invalid %q annotation value on %s: %q", internalerrors.ErrNotValid, AnnotationHookDeletePolicy, modelID, hookDeletePolicy)
+			}
+		}
+	}
+	hookTimeout, ok := k8sObject.GetAnnotations()[AnnotationHookTimeout]
+	if ok {
+		t, err := time.ParseDuration(hookTimeout)
+		if err != nil || t <= 0 {
+			return nil, fmt.Errorf("%w: invalid %q annotation value on %s: %q", internalerrors.ErrNotValid, AnnotationHookTimeout, modelID, hookTimeout)
+		}
+	}
 	// If k8s object is cluster scoped and has a namespace set we need to create the ID
 	// again because the cluster scoped resources should have always `default` as the namespace
 	// in the ID part (tl;dr: cluster scoped ignore namespace field and use always `default` ns).
@@ -140,8 +441,11 @@ func (r ResourceAndGroupFactory) NewGroup(id, path string, config GroupConfig) G
 	const defaultPriority = 1000
 
 	g := Group{
-		ID:   id,
-		Path: path,
+		ID:                 id,
+		Path:               path,
+		ReplaceOnImmutable: config.ReplaceOnImmutable,
+		ConflictPolicy:     config.ConflictPolicy,
+		Timeout:            config.Timeout,
 	}
 
 	// Set priority.
@@ -151,19 +455,43 @@ func (r ResourceAndGroupFactory) NewGroup(id, path string, config GroupConfig) G
 	}
 
 	// Set wait options.
+	g.Hooks.Order = config.HooksConfig.Order
 	if config.HooksConfig.Pre != nil {
-		g.Hooks.Pre = waitConfigToGroupModel(*config.HooksConfig.Pre)
+		g.Hooks.Pre = NewGroupHookSpec(*config.HooksConfig.Pre, path)
 	}
 	if config.HooksConfig.Post != nil {
-		g.Hooks.Post = waitConfigToGroupModel(*config.HooksConfig.Post)
+		g.Hooks.Post = NewGroupHookSpec(*config.HooksConfig.Post, path)
+	}
+	if config.HooksConfig.PreDelete != nil {
+		g.Hooks.PreDelete = NewGroupHookSpec(*config.HooksConfig.PreDelete, path)
+	}
+	if config.HooksConfig.PostDelete != nil {
+		g.Hooks.PostDelete = NewGroupHookSpec(*config.HooksConfig.PostDelete, path)
 	}
 
 	return g
 }
 
-func waitConfigToGroupModel(cfg GroupHookConfigSpec) *GroupHookSpec {
+// NewGroupHookSpec maps the hook configuration using the hook base directory, the
+// group directory for the group hooks and the Kahoy execution directory (empty)
+// for the run level hooks that don't belong to any group. The hooks without a
+// working directory are executed where Kahoy is executed, and the relative working
+// directories are resolved against the base directory.
+func NewGroupHookSpec(cfg GroupHookConfigSpec, baseDir string) *GroupHookSpec {
+	workdir := cfg.Workdir
+	if workdir != "" && !filepath.IsAbs(workdir) {
+		workdir = filepath.Join(baseDir, workdir)
+	}
+
 	return &GroupHookSpec{
-		Cmd:     cfg.Cmd,
-		Timeout: cfg.Timeout,
+		Cmd:          cfg.Cmd,
+		Timeout:      cfg.Timeout,
+		Args:         cfg.Args,
+		Shell:        cfg.Shell,
+		Env:          cfg.Env,
+		Workdir:      workdir,
+		Retries:      cfg.Retries,
+		RetryBackoff: cfg.RetryBackoff,
+		HTTP:         cfg.HTTP,
 	}
 }
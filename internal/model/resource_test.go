@@ -38,6 +38,12 @@ func TestResourceAndGroupFactoryNewResource(t *testing.T) {
 				{Kind: "ClusterRoleBinding", Namespaced: false},
 			},
 		},
+		{
+			GroupVersion: "batch/v1",
+			APIResources: []metav1.APIResource{
+				{Kind: "Job", Namespaced: true},
+			},
+		},
 	}
 
 	tests := map[string]struct {
@@ -196,6 +202,203 @@ func TestResourceAndGroupFactoryNewResource(t *testing.T) {
 				},
 			},
 		},
+
+		"A resource with an invalid delete policy annotation should fail.": {
+			obj: &unstructured.Unstructured{
+				Object: tm{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": tm{
+						"name":      "test-name",
+						"namespace": "test-ns",
+						"annotations": tm{
+							"kahoy.slok.dev/on-delete": "wrong",
+						},
+					},
+				},
+			},
+			groupID:      "test-group",
+			manifestPath: "/test",
+			mock: func(m *modelmock.KubernetesDiscoveryClient) {
+				m.On("GetServerGroupsAndResources", mock.Anything).Once().Return(nil, testAPIResourceList, nil)
+			},
+			expErr: true,
+		},
+
+		"A resource with an invalid replace on immutable annotation should fail.": {
+			obj: &unstructured.Unstructured{
+				Object: tm{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": tm{
+						"name":      "test-name",
+						"namespace": "test-ns",
+						"annotations": tm{
+							"kahoy.slok.dev/replace-on-immutable": "wrong",
+						},
+					},
+				},
+			},
+			groupID:      "test-group",
+			manifestPath: "/test",
+			mock: func(m *modelmock.KubernetesDiscoveryClient) {
+				m.On("GetServerGroupsAndResources", mock.Anything).Once().Return(nil, testAPIResourceList, nil)
+			},
+			expErr: true,
+		},
+
+		"A Job with hook annotations should return correctly the resource.": {
+			obj: &unstructured.Unstructured{
+				Object: tm{
+					"apiVersion": "batch/v1",
+					"kind":       "Job",
+					"metadata": tm{
+						"name":      "test-name",
+						"namespace": "test-ns",
+						"annotations": tm{
+							"kahoy.slok.dev/hook":               "pre-apply",
+							"kahoy.slok.dev/hook-delete-policy": "before-hook-creation, hook-succeeded",
+						},
+					},
+				},
+			},
+			groupID:      "test-group",
+			manifestPath: "/test",
+			mock: func(m *modelmock.KubernetesDiscoveryClient) {
+				m.On("GetServerGroupsAndResources", mock.Anything).Once().Return(nil, testAPIResourceList, nil)
+			},
+			expResource: model.Resource{
+				ID:           "batch/v1/Job/test-ns/test-name",
+				GroupID:      "test-group",
+				ManifestPath: "/test",
+				K8sObject: &unstructured.Unstructured{
+					Object: tm{
+						"apiVersion": "batch/v1",
+						"kind":       "Job",
+						"metadata": tm{
+							"name":      "test-name",
+							"namespace": "test-ns",
+							"annotations": tm{
+								"kahoy.slok.dev/hook":               "pre-apply",
+								"kahoy.slok.dev/hook-delete-policy": "before-hook-creation, hook-succeeded",
+							},
+						},
+					},
+				},
+			},
+		},
+
+		"A resource with an invalid hook annotation should fail.": {
+			obj: &unstructured.Unstructured{
+				Object: tm{
+					"apiVersion": "batch/v1",
+					"kind":       "Job",
+					"metadata": tm{
+						"name":      "test-name",
+						"namespace": "test-ns",
+						"annotations": tm{
+							"kahoy.slok.dev/hook": "wrong",
+						},
+					},
+				},
+			},
+			groupID:      "test-group",
+			manifestPath: "/test",
+			mock: func(m *modelmock.KubernetesDiscoveryClient) {
+				m.On("GetServerGroupsAndResources", mock.Anything).Once().Return(nil, testAPIResourceList, nil)
+			},
+			expErr: true,
+		},
+
+		"A resource that is not a Job with a hook annotation should fail.": {
+			obj: &unstructured.Unstructured{
+				Object: tm{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": tm{
+						"name":      "test-name",
+						"namespace": "test-ns",
+						"annotations": tm{
+							"kahoy.slok.dev/hook": "pre-apply",
+						},
+					},
+				},
+			},
+			groupID:      "test-group",
+			manifestPath: "/test",
+			mock: func(m *modelmock.KubernetesDiscoveryClient) {
+				m.On("GetServerGroupsAndResources", mock.Anything).Once().Return(nil, testAPIResourceList, nil)
+			},
+			expErr: true,
+		},
+
+		"A resource with an invalid hook delete policy annotation should fail.": {
+			obj: &unstructured.Unstructured{
+				Object: tm{
+					"apiVersion": "batch/v1",
+					"kind":       "Job",
+					"metadata": tm{
+						"name":      "test-name",
+						"namespace": "test-ns",
+						"annotations": tm{
+							"kahoy.slok.dev/hook":               "pre-apply",
+							"kahoy.slok.dev/hook-delete-policy": "hook-succeeded,wrong",
+						},
+					},
+				},
+			},
+			groupID:      "test-group",
+			manifestPath: "/test",
+			mock: func(m *modelmock.KubernetesDiscoveryClient) {
+				m.On("GetServerGroupsAndResources", mock.Anything).Once().Return(nil, testAPIResourceList, nil)
+			},
+			expErr: true,
+		},
+
+		"A resource with an invalid hook timeout annotation should fail.": {
+			obj: &unstructured.Unstructured{
+				Object: tm{
+					"apiVersion": "batch/v1",
+					"kind":       "Job",
+					"metadata": tm{
+						"name":      "test-name",
+						"namespace": "test-ns",
+						"annotations": tm{
+							"kahoy.slok.dev/hook":         "pre-apply",
+							"kahoy.slok.dev/hook-timeout": "-5m",
+						},
+					},
+				},
+			},
+			groupID:      "test-group",
+			manifestPath: "/test",
+			mock: func(m *modelmock.KubernetesDiscoveryClient) {
+				m.On("GetServerGroupsAndResources", mock.Anything).Once().Return(nil, testAPIResourceList, nil)
+			},
+			expErr: true,
+		},
+
+		"A resource with an invalid apply strategy annotation should fail.": {
+			obj: &unstructured.Unstructured{
+				Object: tm{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": tm{
+						"name":      "test-name",
+						"namespace": "test-ns",
+						"annotations": tm{
+							"kahoy.slok.dev/apply-strategy": "wrong",
+						},
+					},
+				},
+			},
+			groupID:      "test-group",
+			manifestPath: "/test",
+			mock: func(m *modelmock.KubernetesDiscoveryClient) {
+				m.On("GetServerGroupsAndResources", mock.Anything).Once().Return(nil, testAPIResourceList, nil)
+			},
+			expErr: true,
+		},
 	}
 
 	for name, test := range tests {
@@ -237,8 +440,11 @@ func TestTestResourceAndGroupFactoryNewGroup(t *testing.T) {
 			config: model.GroupConfig{
 				Priority: &fourtyTwo,
 				HooksConfig: model.GroupHooksConfig{
-					Pre:  &model.GroupHookConfigSpec{Cmd: "cmd1", Timeout: 555 * time.Millisecond},
-					Post: &model.GroupHookConfigSpec{Cmd: "cmd2", Timeout: 444 * time.Millisecond},
+					Pre:        &model.GroupHookConfigSpec{Cmd: "cmd1", Timeout: 555 * time.Millisecond},
+					Post:       &model.GroupHookConfigSpec{Cmd: "cmd2", Timeout: 444 * time.Millisecond},
+					PreDelete:  &model.GroupHookConfigSpec{Cmd: "cmd3", Timeout: 333 * time.Millisecond},
+					PostDelete: &model.GroupHookConfigSpec{Cmd: "cmd4", Timeout: 222 * time.Millisecond},
+					Order:      3,
 				},
 			},
 			expGroup: model.Group{
@@ -246,8 +452,110 @@ func TestTestResourceAndGroupFactoryNewGroup(t *testing.T) {
 				Path:     "tests/test1",
 				Priority: 42,
 				Hooks: model.GroupHooks{
-					Pre:  &model.GroupHookSpec{Cmd: "cmd1", Timeout: 555 * time.Millisecond},
-					Post: &model.GroupHookSpec{Cmd: "cmd2", Timeout: 444 * time.Millisecond},
+					Pre:        &model.GroupHookSpec{Cmd: "cmd1", Timeout: 555 * time.Millisecond},
+					Post:       &model.GroupHookSpec{Cmd: "cmd2", Timeout: 444 * time.Millisecond},
+					PreDelete:  &model.GroupHookSpec{Cmd: "cmd3", Timeout: 333 * time.Millisecond},
+					PostDelete: &model.GroupHookSpec{Cmd: "cmd4", Timeout: 222 * time.Millisecond},
+					Order:      3,
+				},
+			},
+		},
+
+		"A group with replace on immutable should be mapped.": {
+			id:   "test1",
+			path: "tests/test1",
+			config: model.GroupConfig{
+				Priority:           &fourtyTwo,
+				ReplaceOnImmutable: true,
+			},
+			expGroup: model.Group{
+				ID:                 "test1",
+				Path:               "tests/test1",
+				Priority:           42,
+				ReplaceOnImmutable: true,
+			},
+		},
+
+		"A group with conflict policy should be mapped.": {
+			id:   "test1",
+			path: "tests/test1",
+			config: model.GroupConfig{
+				Priority:       &fourtyTwo,
+				ConflictPolicy: model.ConflictPolicyFail,
+			},
+			expGroup: model.Group{
+				ID:             "test1",
+				Path:           "tests/test1",
+				Priority:       42,
+				ConflictPolicy: model.ConflictPolicyFail,
+			},
+		},
+
+		"A group with timeout should be mapped.": {
+			id:   "test1",
+			path: "tests/test1",
+			config: model.GroupConfig{
+				Priority: &fourtyTwo,
+				Timeout:  20 * time.Minute,
+			},
+			expGroup: model.Group{
+				ID:       "test1",
+				Path:     "tests/test1",
+				Priority: 42,
+				Timeout:  20 * time.Minute,
+			},
+		},
+
+		"A group with structured hooks should be mapped keeping the configured working directory.": {
+			id:   "test1",
+			path: "tests/test1",
+			config: model.GroupConfig{
+				HooksConfig: model.GroupHooksConfig{
+					Pre: &model.GroupHookConfigSpec{
+						Args:         []string{"cmd1", "arg 1"},
+						Env:          map[string]string{"K1": "V1"},
+						Workdir:      "/tmp",
+						Retries:      3,
+						RetryBackoff: time.Second,
+					},
+					Post:      &model.GroupHookConfigSpec{Cmd: "cmd2 | cmd3", Shell: true},
+					PreDelete: &model.GroupHookConfigSpec{HTTP: &model.HTTPHook{Method: "GET", URL: "https://test"}},
+				},
+			},
+			expGroup: model.Group{
+				ID:       "test1",
+				Path:     "tests/test1",
+				Priority: 1000,
+				Hooks: model.GroupHooks{
+					Pre: &model.GroupHookSpec{
+						Args:         []string{"cmd1", "arg 1"},
+						Env:          map[string]string{"K1": "V1"},
+						Workdir:      "/tmp",
+						Retries:      3,
+						RetryBackoff: time.Second,
+					},
+					Post:      &model.GroupHookSpec{Cmd: "cmd2 | cmd3", Shell: true},
+					PreDelete: &model.GroupHookSpec{HTTP: &model.HTTPHook{Method: "GET", URL: "https://test"}},
+				},
+			},
+		},
+
+		"A group with hooks with relative working directory should resolve them against the group directory.": {
+			id:   "test1",
+			path: "tests/test1",
+			config: model.GroupConfig{
+				HooksConfig: model.GroupHooksConfig{
+					Pre:  &model.GroupHookConfigSpec{Cmd: "./scripts/check.sh", Workdir: "scripts"},
+					Post: &model.GroupHookConfigSpec{Cmd: "./check.sh", Workdir: "../common"},
+				},
+			},
+			expGroup: model.Group{
+				ID:       "test1",
+				Path:     "tests/test1",
+				Priority: 1000,
+				Hooks: model.GroupHooks{
+					Pre:  &model.GroupHookSpec{Cmd: "./scripts/check.sh", Workdir: "tests/test1/scripts"},
+					Post: &model.GroupHookSpec{Cmd: "./check.sh", Workdir: "tests/common"},
 				},
 			},
 		},
@@ -290,3 +598,174 @@ func TestTestResourceAndGroupFactoryNewGroup(t *testing.T) {
 		})
 	}
 }
+
+func TestResourceDeletePolicy(t *testing.T) {
+	newResource := func(annotations map[string]string) model.Resource {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetAnnotations(annotations)
+		return model.Resource{ID: "test", K8sObject: obj}
+	}
+
+	tests := map[string]struct {
+		resource    model.Resource
+		groupPolicy model.DeletePolicy
+		expPolicy   model.DeletePolicy
+	}{
+		"Without policies, it should return the default policy.": {
+			resource:  newResource(nil),
+			expPolicy: model.DeletePolicyDelete,
+		},
+
+		"Without resource policy, it should return the group policy.": {
+			resource:    newResource(nil),
+			groupPolicy: model.DeletePolicyOrphan,
+			expPolicy:   model.DeletePolicyOrphan,
+		},
+
+		"Having a resource policy, it should have preference over the group policy.": {
+			resource:    newResource(map[string]string{"kahoy.slok.dev/on-delete": "delete"}),
+			groupPolicy: model.DeletePolicyOrphan,
+			expPolicy:   model.DeletePolicyDelete,
+		},
+
+		"Having a resource policy without group policy, it should return the resource policy.": {
+			resource:  newResource(map[string]string{"kahoy.slok.dev/on-delete": "orphan"}),
+			expPolicy: model.DeletePolicyOrphan,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotPolicy := test.resource.DeletePolicy(test.groupPolicy)
+			assert.Equal(t, test.expPolicy, gotPolicy)
+		})
+	}
+}
+
+func TestResourceReplaceOnImmutable(t *testing.T) {
+	newResource := func(annotations map[string]string) model.Resource {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetAnnotations(annotations)
+		return model.Resource{ID: "test", K8sObject: obj}
+	}
+
+	tests := map[string]struct {
+		resource     model.Resource
+		groupReplace bool
+		expReplace   bool
+	}{
+		"Without options, it should not replace.": {
+			resource:   newResource(nil),
+			expReplace: false,
+		},
+
+		"Without resource annotation, it should use the group option.": {
+			resource:     newResource(nil),
+			groupReplace: true,
+			expReplace:   true,
+		},
+
+		"Having a resource annotation, it should have preference over the group option.": {
+			resource:     newResource(map[string]string{"kahoy.slok.dev/replace-on-immutable": "false"}),
+			groupReplace: true,
+			expReplace:   false,
+		},
+
+		"Having a resource annotation without group option, it should use the resource annotation.": {
+			resource:   newResource(map[string]string{"kahoy.slok.dev/replace-on-immutable": "true"}),
+			expReplace: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotReplace := test.resource.ReplaceOnImmutable(test.groupReplace)
+			assert.Equal(t, test.expReplace, gotReplace)
+		})
+	}
+}
+
+func TestResourceApplyStrategy(t *testing.T) {
+	newResource := func(annotations map[string]string) model.Resource {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetAnnotations(annotations)
+		return model.Resource{ID: "test", K8sObject: obj}
+	}
+
+	tests := map[string]struct {
+		resource    model.Resource
+		expStrategy model.ApplyStrategy
+	}{
+		"Without resource annotation, it should use the default strategy.": {
+			resource:    newResource(nil),
+			expStrategy: model.ApplyStrategyServerSide,
+		},
+
+		"Having a create only resource annotation, it should use the create only strategy.": {
+			resource:    newResource(map[string]string{"kahoy.slok.dev/apply-strategy": "create-only"}),
+			expStrategy: model.ApplyStrategyCreateOnly,
+		},
+
+		"Having a replace resource annotation, it should use the replace strategy.": {
+			resource:    newResource(map[string]string{"kahoy.slok.dev/apply-strategy": "replace"}),
+			expStrategy: model.ApplyStrategyReplace,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotStrategy := test.resource.ApplyStrategy()
+			assert.Equal(t, test.expStrategy, gotStrategy)
+		})
+	}
+}
+
+func TestResourceHookDeletePolicies(t *testing.T) {
+	newResource := func(annotations map[string]string) model.Resource {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetAnnotations(annotations)
+		return model.Resource{ID: "test", K8sObject: obj}
+	}
+
+	tests := map[string]struct {
+		resource    model.Resource
+		expHookType model.HookType
+		expPolicies []model.HookDeletePolicy
+		expTimeout  time.Duration
+	}{
+		"Without resource annotations, it should not be a hook and use the default delete policy and timeout.": {
+			resource:    newResource(nil),
+			expHookType: "",
+			expPolicies: []model.HookDeletePolicy{model.HookDeletePolicyBeforeHookCreation},
+			expTimeout:  model.DefaultHookJobTimeout,
+		},
+
+		"Having a hook resource annotation, it should return the hook type.": {
+			resource:    newResource(map[string]string{"kahoy.slok.dev/hook": "post-apply"}),
+			expHookType: model.HookTypePostApply,
+			expPolicies: []model.HookDeletePolicy{model.HookDeletePolicyBeforeHookCreation},
+			expTimeout:  model.DefaultHookJobTimeout,
+		},
+
+		"Having multiple hook delete policies and a timeout, it should return all of them.": {
+			resource: newResource(map[string]string{
+				"kahoy.slok.dev/hook":               "pre-delete",
+				"kahoy.slok.dev/hook-delete-policy": "hook-succeeded, hook-failed",
+				"kahoy.slok.dev/hook-timeout":       "30m",
+			}),
+			expHookType: model.HookTypePreDelete,
+			expPolicies: []model.HookDeletePolicy{model.HookDeletePolicyHookSucceeded, model.HookDeletePolicyHookFailed},
+			expTimeout:  30 * time.Minute,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			assert.Equal(test.expHookType, test.resource.HookType())
+			assert.Equal(test.expPolicies, test.resource.HookDeletePolicies())
+			assert.Equal(test.expTimeout, test.resource.HookTimeout())
+		})
+	}
+}
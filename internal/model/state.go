@@ -14,6 +14,138 @@ type State struct {
 	EndedAt          time.Time
 	AppliedResources []Resource
 	DeletedResources []Resource
+	// ReleasedResources are the resources that Kahoy stopped managing
+	// without deleting them from the cluster.
+	ReleasedResources []Resource
+	// ReplacedResources are the resources that have been deleted and created
+	// again because their changes couldn't be applied.
+	ReplacedResources []Resource
+	// Conflicts are the field ownership conflicts found while applying the resources.
+	Conflicts []ResourceConflict
+	// Retries are the retries of the resources that failed due to transient errors.
+	Retries []ResourceRetry
+	// MovedResources are the resources that have been moved between groups, these
+	// could have been applied or not.
+	MovedResources []MovedResource
+	// DiffChanges tells if changes have been found while diffing the resources.
+	DiffChanges bool
+	// Status is the final status of the execution.
+	Status ExecutionStatus
+	// Error is the error of the execution, if failed.
+	Error string
+	// GitBeforeCommit and GitCommit are the Git commits used as old and new
+	// states, only set when using Git.
+	GitBeforeCommit string
+	GitCommit       string
+	// Batches are the executed batches of resources.
+	Batches []BatchResult
+	// ResourceResults are the outcomes of the executed resources.
+	ResourceResults []ResourceResult
+	// HookResults are the results of the executed hooks.
+	HookResults []HookResult
+}
+
+// ExecutionStatus is the status of an execution.
+type ExecutionStatus string
+
+const (
+	// ExecutionStatusSuccess is used when the execution finished correctly.
+	ExecutionStatusSuccess ExecutionStatus = "success"
+	// ExecutionStatusFailed is used when the execution failed.
+	ExecutionStatusFailed ExecutionStatus = "failed"
+	// ExecutionStatusCancelled is used when the execution has been cancelled.
+	ExecutionStatusCancelled ExecutionStatus = "cancelled"
+)
+
+// Operation is the operation executed on the resources.
+type Operation string
+
+const (
+	// OperationApply is the apply operation.
+	OperationApply Operation = "apply"
+	// OperationDelete is the delete operation.
+	OperationDelete Operation = "delete"
+)
+
+// BatchResult is the result of a batch of resources execution.
+type BatchResult struct {
+	Operation Operation
+	// Priority is the priority of the batch, nil when the batch is not
+	// batched by priority.
+	Priority  *int
+	Resources []Resource
+	StartedAt time.Time
+	Duration  time.Duration
+	// Error is the error of the batch, if failed.
+	Error string
+}
+
+// ResourceOutcome is the outcome of a resource execution.
+type ResourceOutcome string
+
+const (
+	// ResourceOutcomeCreated is used when the resource didn't exist before.
+	ResourceOutcomeCreated ResourceOutcome = "created"
+	// ResourceOutcomeConfigured is used when the resource existed and has changed.
+	ResourceOutcomeConfigured ResourceOutcome = "configured"
+	// ResourceOutcomeUnchanged is used when the resource existed without changes.
+	ResourceOutcomeUnchanged ResourceOutcome = "unchanged"
+	// ResourceOutcomeDeleted is used when the resource has been deleted.
+	ResourceOutcomeDeleted ResourceOutcome = "deleted"
+	// ResourceOutcomeNotFound is used when the resource to delete was missing.
+	ResourceOutcomeNotFound ResourceOutcome = "not-found"
+	// ResourceOutcomeFailed is used when the resource execution failed.
+	ResourceOutcomeFailed ResourceOutcome = "failed"
+	// ResourceOutcomeApplied is used when the resource has been applied without knowing
+	// if it has been created, configured or unchanged (e.g: server-side apply).
+	ResourceOutcomeApplied ResourceOutcome = "applied"
+)
+
+// ResourceResult is the outcome of a resource execution.
+type ResourceResult struct {
+	Resource  Resource
+	Operation Operation
+	Outcome   ResourceOutcome
+	// Error is the error of the execution, if failed.
+	Error string
+}
+
+// HookResult is the result of a hook execution.
+type HookResult struct {
+	GroupID   string
+	Type      string
+	Cmd       string
+	StartedAt time.Time
+	Duration  time.Duration
+	// ExitCode is the exit code of the hook command, -1 if it didn't exit.
+	ExitCode int
+	// Error is the error of the hook, if failed.
+	Error string
+}
+
+// MovedResource represents a resource that has been moved from one group to another.
+type MovedResource struct {
+	// Resource is the resource with the new group.
+	Resource   Resource
+	OldGroupID string
+}
+
+// ResourceConflict represents the field ownership conflicts of a resource with other
+// field managers and the policy used to resolve them.
+type ResourceConflict struct {
+	Resource  Resource
+	Policy    ConflictPolicy
+	Conflicts []FieldConflict
+}
+
+// ResourceRetry represents a retry of a resource operation that failed due to a transient error.
+type ResourceRetry struct {
+	Resource  Resource
+	Operation Operation
+	// Attempt is the number of the retry, starting at 1.
+	Attempt int
+	// Error is the error of the retry, empty if the retry succeeded.
+	Error string
 }
 
 // NewState returns a new state.
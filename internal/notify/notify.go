@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EventType is the type of a Kahoy execution lifecycle event.
+type EventType string
+
+const (
+	// EventTypeRunStarted is sent when the execution starts.
+	EventTypeRunStarted EventType = "run_started"
+	// EventTypePlanned is sent when the execution plan is ready, before executing anything.
+	EventTypePlanned EventType = "planned"
+	// EventTypeBatchFinished is sent when a batch of resources has been executed.
+	EventTypeBatchFinished EventType = "batch_finished"
+	// EventTypeRunSucceeded is sent when the execution ends correctly.
+	EventTypeRunSucceeded EventType = "run_succeeded"
+	// EventTypeRunFailed is sent when the execution ends with an error or is cancelled.
+	EventTypeRunFailed EventType = "run_failed"
+)
+
+// ExecutionMode is the mode of the Kahoy execution that sends the events.
+type ExecutionMode string
+
+const (
+	// ExecutionModeApply is a real execution that changes the cluster.
+	ExecutionModeApply ExecutionMode = "apply"
+	// ExecutionModeDryRun is a dry-run execution, nothing is changed.
+	ExecutionModeDryRun ExecutionMode = "dry-run"
+	// ExecutionModeDiff is a diff execution, nothing is changed.
+	ExecutionModeDiff ExecutionMode = "diff"
+)
+
+// Event is a Kahoy execution lifecycle event.
+type Event struct {
+	Type      EventType     `json:"type"`
+	Mode      ExecutionMode `json:"mode,omitempty"`
+	RunID     string        `json:"run_id"`
+	Time      time.Time     `json:"time"`
+	GitCommit string        `json:"git_commit,omitempty"`
+	Plan      *PlanSummary  `json:"plan,omitempty"`
+	Batch     *BatchSummary `json:"batch,omitempty"`
+	Status    string        `json:"status,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// PlanSummary are the resource counts of the execution plan.
+type PlanSummary struct {
+	Apply   int `json:"apply"`
+	Delete  int `json:"delete"`
+	Release int `json:"release"`
+	Moved   int `json:"moved"`
+}
+
+// BatchSummary is the result of a batch execution.
+type BatchSummary struct {
+	Operation       string  `json:"operation"`
+	Priority        *int    `json:"priority,omitempty"`
+	Resources       int     `json:"resources"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// Notifier knows how to notify Kahoy execution lifecycle events.
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+//go:generate mockery --case underscore --output notifymock --outpkg notifymock --name Notifier
+
+// Noop notifier doesn't notify anything.
+const Noop = noop(0)
+
+type noop int
+
+func (noop) Notify(context.Context, Event) error { return nil }
+
+type multiNotifier []Notifier
+
+// NewMultiNotifier returns a notifier that notifies the events to all the received
+// notifiers, a failing notifier doesn't stop the notification of the others.
+func NewMultiNotifier(ns ...Notifier) Notifier {
+	return multiNotifier(ns)
+}
+
+func (m multiNotifier) Notify(ctx context.Context, e Event) error {
+	errs := []string{}
+	for _, n := range m {
+		err := n.Notify(ctx, e)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d notifications failed: %s", len(errs), strings.Join(errs, "; "))
+	}
+
+	return nil
+}
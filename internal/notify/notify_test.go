@@ -0,0 +1,61 @@
+package notify_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/slok/kahoy/internal/notify"
+	"github.com/slok/kahoy/internal/notify/notifymock"
+)
+
+func TestMultiNotifier(t *testing.T) {
+	tests := map[string]struct {
+		mock   func(m1, m2 *notifymock.Notifier)
+		expErr bool
+	}{
+		"All the notifiers should receive the event.": {
+			mock: func(m1, m2 *notifymock.Notifier) {
+				exp := notify.Event{Type: notify.EventTypeRunStarted, RunID: "test"}
+				m1.On("Notify", mock.Anything, exp).Once().Return(nil)
+				m2.On("Notify", mock.Anything, exp).Once().Return(nil)
+			},
+		},
+
+		"A failing notifier should not stop notifying the rest and return an error.": {
+			mock: func(m1, m2 *notifymock.Notifier) {
+				exp := notify.Event{Type: notify.EventTypeRunStarted, RunID: "test"}
+				m1.On("Notify", mock.Anything, exp).Once().Return(fmt.Errorf("whatever"))
+				m2.On("Notify", mock.Anything, exp).Once().Return(nil)
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			m1 := &notifymock.Notifier{}
+			m2 := &notifymock.Notifier{}
+			test.mock(m1, m2)
+
+			// Execute.
+			n := notify.NewMultiNotifier(m1, m2)
+			err := n.Notify(context.TODO(), notify.Event{Type: notify.EventTypeRunStarted, RunID: "test"})
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			m1.AssertExpectations(t)
+			m2.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"text/template"
+	"time"
+
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/notify"
+)
+
+// SignatureHeader is the header where the HMAC SHA256 signature of the payload
+// is set, in `sha256=<hex>` format.
+const SignatureHeader = "X-Kahoy-Signature-256"
+
+// NotifierConfig is the configuration of the webhook notifier.
+type NotifierConfig struct {
+	// URL is where the events will be posted.
+	URL string
+	// Secret is used as the HMAC SHA256 key to sign the payloads, if empty
+	// the payloads will not be signed.
+	Secret string
+	// Template is a Go text template used to render the payload using the
+	// event as the data, if empty the event will be sent as JSON.
+	Template string
+	// ContentType is the content type of the payload.
+	ContentType string
+	// Retries is the number of retries on failed requests.
+	Retries int
+	// RetryWait is the base time waited between retries, it increases
+	// linearly on each retry.
+	RetryWait time.Duration
+	// Timeout is the timeout of each request.
+	Timeout time.Duration
+	// EventTimeout is the maximum time spent notifying an event, including the retries. The
+	// notifications are synchronous, so this bounds the time an execution can be blocked.
+	EventTimeout time.Duration
+	HTTPClient   *http.Client
+	Logger       log.Logger
+}
+
+func (c *NotifierConfig) defaults() error {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid URL scheme %q, only http and https are supported", u.Scheme)
+	}
+
+	if c.ContentType == "" {
+		c.ContentType = "application/json"
+	}
+
+	if c.Retries < 0 {
+		return fmt.Errorf("retries can't be negative")
+	}
+
+	if c.RetryWait == 0 {
+		c.RetryWait = time.Second
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+
+	if c.EventTimeout == 0 {
+		c.EventTimeout = 30 * time.Second
+	}
+
+	if c.HTTPClient == nil {
+		c.HTTPClient = http.DefaultClient
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "webhook.Notifier", "url": u.Redacted()})
+
+	return nil
+}
+
+type notifier struct {
+	url          string
+	secret       []byte
+	tpl          *template.Template
+	contentType  string
+	retries      int
+	retryWait    time.Duration
+	timeout      time.Duration
+	eventTimeout time.Duration
+	cli          *http.Client
+	logger       log.Logger
+}
+
+// NewNotifier returns a new notifier that will POST the events to a webhook.
+func NewNotifier(config NotifierConfig) (notify.Notifier, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	var tpl *template.Template
+	if config.Template != "" {
+		tpl, err = template.New("webhook").Funcs(templateFuncs).Option("missingkey=error").Parse(config.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+	}
+
+	return notifier{
+		url:          config.URL,
+		secret:       []byte(config.Secret),
+		tpl:          tpl,
+		contentType:  config.ContentType,
+		retries:      config.Retries,
+		retryWait:    config.RetryWait,
+		timeout:      config.Timeout,
+		eventTimeout: config.EventTimeout,
+		cli:          config.HTTPClient,
+		logger:       config.Logger,
+	}, nil
+}
+
+var templateFuncs = template.FuncMap{
+	// json marshals any value, useful to escape strings inside JSON templates.
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func (n notifier) Notify(ctx context.Context, e notify.Event) error {
+	body, err := n.render(e)
+	if err != nil {
+		return fmt.Errorf("could not render %q event payload: %w", e.Type, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.eventTimeout)
+	defer cancel()
+
+	logger := n.logger.WithValues(log.Kv{"event": e.Type})
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(ctx, body)
+		if err == nil {
+			logger.Debugf("event notified")
+			return nil
+		}
+
+		if !retry || attempt >= n.retries {
+			return fmt.Errorf("could not notify %q event: %w", e.Type, err)
+		}
+
+		logger.Warningf("could not notify event, retrying: %s", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("could not notify %q event: %w", e.Type, ctx.Err())
+		case <-time.After(time.Duration(attempt+1) * n.retryWait):
+		}
+	}
+}
+
+func (n notifier) render(e notify.Event) ([]byte, error) {
+	if n.tpl == nil {
+		return json.Marshal(e)
+	}
+
+	var b bytes.Buffer
+	err := n.tpl.Execute(&b, e)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// post sends the payload and returns if the failed request can be retried.
+func (n notifier) post(ctx context.Context, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", n.contentType)
+	req.Header.Set("User-Agent", "kahoy")
+	if len(n.secret) > 0 {
+		mac := hmac.New(sha256.New, n.secret)
+		_, _ = mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.cli.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	// Only server errors and throttling are retried.
+	retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+}
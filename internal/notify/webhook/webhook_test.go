@@ -0,0 +1,173 @@
+package webhook_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/kahoy/internal/notify"
+	"github.com/slok/kahoy/internal/notify/webhook"
+)
+
+type request struct {
+	header http.Header
+	body   string
+}
+
+type server struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []request
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	s.requests = append(s.requests, request{header: r.Header, body: string(body)})
+
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestNotifierNotify(t *testing.T) {
+	t0 := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	event := notify.Event{
+		Type:      notify.EventTypePlanned,
+		Mode:      notify.ExecutionModeApply,
+		RunID:     "test",
+		Time:      t0,
+		GitCommit: "1234",
+		Plan:      &notify.PlanSummary{Apply: 3, Delete: 1},
+	}
+	eventJSON := `{"type":"planned","mode":"apply","run_id":"test","time":"2021-01-02T03:04:05Z","git_commit":"1234","plan":{"apply":3,"delete":1,"release":0,"moved":0}}`
+
+	tests := map[string]struct {
+		config     webhook.NotifierConfig
+		statuses   []int
+		expBodies  []string
+		expSignHdr string
+		expErr     bool
+	}{
+		"By default the event should be sent as JSON.": {
+			config:    webhook.NotifierConfig{},
+			expBodies: []string{eventJSON},
+		},
+
+		"Having a secret, the payload should be signed.": {
+			config:     webhook.NotifierConfig{Secret: "s3cr3t"},
+			expBodies:  []string{eventJSON},
+			expSignHdr: sign("s3cr3t", eventJSON),
+		},
+
+		"Having a template, the payload should be rendered with the template.": {
+			config: webhook.NotifierConfig{
+				Template: `{"text": {{ printf "Kahoy %s %s: %d applied" .RunID .Type .Plan.Apply | json }}}`,
+			},
+			expBodies: []string{`{"text": "Kahoy test planned: 3 applied"}`},
+		},
+
+		"Server errors should be retried.": {
+			config:    webhook.NotifierConfig{Retries: 2, RetryWait: time.Millisecond},
+			statuses:  []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK},
+			expBodies: []string{eventJSON, eventJSON, eventJSON},
+		},
+
+		"Server errors after all the retries should fail.": {
+			config:    webhook.NotifierConfig{Retries: 1, RetryWait: time.Millisecond},
+			statuses:  []int{http.StatusBadGateway, http.StatusBadGateway},
+			expBodies: []string{eventJSON, eventJSON},
+			expErr:    true,
+		},
+
+		"Server errors exceeding the event timeout should fail without more retries.": {
+			config:    webhook.NotifierConfig{Retries: 5, RetryWait: time.Minute, EventTimeout: 10 * time.Millisecond},
+			statuses:  []int{http.StatusBadGateway, http.StatusBadGateway},
+			expBodies: []string{eventJSON},
+			expErr:    true,
+		},
+
+		"Client errors should not be retried.": {
+			config:    webhook.NotifierConfig{Retries: 2, RetryWait: time.Millisecond},
+			statuses:  []int{http.StatusBadRequest},
+			expBodies: []string{eventJSON},
+			expErr:    true,
+		},
+
+		"Failing rendering the template should fail without sending anything.": {
+			config: webhook.NotifierConfig{Template: `{{ .Batch.Operation }}`},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			srv := &server{statuses: test.statuses}
+			httpSrv := httptest.NewServer(srv)
+			defer httpSrv.Close()
+
+			test.config.URL = httpSrv.URL
+			n, err := webhook.NewNotifier(test.config)
+			require.NoError(err)
+
+			err = n.Notify(context.TODO(), event)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			gotBodies := []string{}
+			for _, r := range srv.requests {
+				gotBodies = append(gotBodies, r.body)
+				assert.Equal("application/json", r.header.Get("Content-Type"))
+				assert.Equal(test.expSignHdr, r.header.Get(webhook.SignatureHeader))
+			}
+			if test.expBodies == nil {
+				test.expBodies = []string{}
+			}
+			assert.Equal(test.expBodies, gotBodies)
+		})
+	}
+}
+
+func TestNewNotifierInvalidConfig(t *testing.T) {
+	tests := map[string]webhook.NotifierConfig{
+		"Missing URL should fail.":        {},
+		"Invalid URL scheme should fail.": {URL: "ftp://127.0.0.1"},
+		"Negative retries should fail.":   {URL: "http://127.0.0.1", Retries: -1},
+		"Invalid template should fail.":   {URL: "http://127.0.0.1", Template: "{{ .Type "},
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := webhook.NewNotifier(config)
+			assert.Error(t, err)
+		})
+	}
+}
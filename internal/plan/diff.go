@@ -0,0 +1,93 @@
+package plan
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"github.com/slok/kahoy/internal/model"
+)
+
+// FieldChangeType is the type of change of a field.
+type FieldChangeType string
+
+const (
+	// FieldChangeTypeAdded is used when the field is only on the new object.
+	FieldChangeTypeAdded FieldChangeType = "added"
+	// FieldChangeTypeRemoved is used when the field is only on the old object.
+	FieldChangeTypeRemoved FieldChangeType = "removed"
+	// FieldChangeTypeModified is used when the field is on both objects with different values.
+	FieldChangeTypeModified FieldChangeType = "modified"
+)
+
+// FieldChange is a change of a field between two objects.
+type FieldChange struct {
+	// Path is the field path (e.g: `.spec.replicas`, `.spec.template.spec.containers[0].image`).
+	Path string
+	Type FieldChangeType
+}
+
+// DiffFields returns the changed fields between two Kubernetes objects, sorted by path.
+// The objects are normalized before comparing them, so only real changes are returned.
+// Lists with different lengths are returned as a single change of the list field.
+func DiffFields(old, new model.K8sObject) ([]FieldChange, error) {
+	oldObj, err := normalize(old)
+	if err != nil {
+		return nil, fmt.Errorf("could not normalize old object: %w", err)
+	}
+
+	newObj, err := normalize(new)
+	if err != nil {
+		return nil, fmt.Errorf("could not normalize new object: %w", err)
+	}
+
+	changes := diffValues("", oldObj, newObj)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	return changes, nil
+}
+
+func diffValues(path string, old, new interface{}) []FieldChange {
+	switch o := old.(type) {
+	case map[string]interface{}:
+		n, ok := new.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		changes := []FieldChange{}
+		for k, ov := range o {
+			nv, ok := n[k]
+			if !ok {
+				changes = append(changes, FieldChange{Path: path + "." + k, Type: FieldChangeTypeRemoved})
+				continue
+			}
+			changes = append(changes, diffValues(path+"."+k, ov, nv)...)
+		}
+		for k := range n {
+			if _, ok := o[k]; !ok {
+				changes = append(changes, FieldChange{Path: path + "." + k, Type: FieldChangeTypeAdded})
+			}
+		}
+		return changes
+
+	case []interface{}:
+		n, ok := new.([]interface{})
+		if !ok || len(o) != len(n) {
+			break
+		}
+
+		changes := []FieldChange{}
+		for i := range o {
+			changes = append(changes, diffValues(fmt.Sprintf("%s[%d]", path, i), o[i], n[i])...)
+		}
+		return changes
+	}
+
+	if equality.Semantic.DeepEqual(old, new) {
+		return nil
+	}
+
+	return []FieldChange{{Path: path, Type: FieldChangeTypeModified}}
+}
@@ -0,0 +1,92 @@
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/plan"
+)
+
+func TestDiffFields(t *testing.T) {
+	type tm = map[string]interface{}
+	type ts = []interface{}
+
+	newDeployment := func(replicas int64, image string, args ts, annotations tm) model.K8sObject {
+		return &unstructured.Unstructured{Object: tm{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": tm{
+				"name":        "test",
+				"annotations": annotations,
+			},
+			"spec": tm{
+				"replicas": replicas,
+				"template": tm{
+					"spec": tm{
+						"containers": ts{
+							tm{"name": "app", "image": image, "args": args},
+						},
+					},
+				},
+			},
+		}}
+	}
+
+	tests := map[string]struct {
+		old        model.K8sObject
+		new        model.K8sObject
+		expChanges []plan.FieldChange
+	}{
+		"Same objects should not have changes.": {
+			old:        newDeployment(1, "app:v1", ts{"--a"}, tm{"a": "a"}),
+			new:        newDeployment(1, "app:v1", ts{"--a"}, tm{"a": "a"}),
+			expChanges: []plan.FieldChange{},
+		},
+
+		"Modified fields should be returned as modified.": {
+			old: newDeployment(1, "app:v1", ts{"--a"}, tm{"a": "a"}),
+			new: newDeployment(3, "app:v2", ts{"--a"}, tm{"a": "a"}),
+			expChanges: []plan.FieldChange{
+				{Path: ".spec.replicas", Type: plan.FieldChangeTypeModified},
+				{Path: ".spec.template.spec.containers[0].image", Type: plan.FieldChangeTypeModified},
+			},
+		},
+
+		"Added and removed fields should be returned as added and removed.": {
+			old: newDeployment(1, "app:v1", ts{"--a"}, tm{"a": "a"}),
+			new: newDeployment(1, "app:v1", ts{"--a"}, tm{"b": "b"}),
+			expChanges: []plan.FieldChange{
+				{Path: ".metadata.annotations.a", Type: plan.FieldChangeTypeRemoved},
+				{Path: ".metadata.annotations.b", Type: plan.FieldChangeTypeAdded},
+			},
+		},
+
+		"Lists with different length should be returned as modified lists.": {
+			old: newDeployment(1, "app:v1", ts{"--a"}, tm{"a": "a"}),
+			new: newDeployment(1, "app:v1", ts{"--a", "--b"}, tm{"a": "a"}),
+			expChanges: []plan.FieldChange{
+				{Path: ".spec.template.spec.containers[0].args", Type: plan.FieldChangeTypeModified},
+			},
+		},
+
+		"Changes that are not real changes should be ignored.": {
+			old:        newDeployment(1, "app:v1", ts{"--a"}, tm{}),
+			new:        newDeployment(1, "app:v1", ts{"--a"}, nil),
+			expChanges: []plan.FieldChange{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			gotChanges, err := plan.DiffFields(test.old, test.new)
+			if assert.NoError(err) {
+				assert.Equal(test.expChanges, gotChanges)
+			}
+		})
+	}
+}
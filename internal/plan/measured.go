@@ -0,0 +1,46 @@
+package plan
+
+import (
+	"context"
+	"time"
+
+	"github.com/slok/kahoy/internal/metrics"
+	"github.com/slok/kahoy/internal/model"
+)
+
+type measuredPlanner struct {
+	rec  metrics.Recorder
+	next Planner
+}
+
+// NewMeasuredPlanner wraps a planner and measures the planning.
+func NewMeasuredPlanner(rec metrics.Recorder, next Planner) Planner {
+	return measuredPlanner{rec: rec, next: next}
+}
+
+func (m measuredPlanner) Plan(ctx context.Context, old []model.Resource, new []model.Resource) (states []State, err error) {
+	defer func(t0 time.Time) {
+		byState := map[string]int{}
+		for _, s := range states {
+			byState[stateName(s.State)]++
+		}
+		m.rec.ObservePlan(ctx, err == nil, time.Since(t0), byState)
+	}(time.Now())
+
+	return m.next.Plan(ctx, old, new)
+}
+
+func stateName(s ResourceState) string {
+	switch s {
+	case ResourceStateExists:
+		return "exists"
+	case ResourceStateMissing:
+		return "missing"
+	case ResourceStateMoved:
+		return "moved"
+	case ResourceStateMovedUnchanged:
+		return "moved-unchanged"
+	default:
+		return "unknown"
+	}
+}
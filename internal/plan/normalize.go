@@ -0,0 +1,259 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/slok/kahoy/internal/model"
+)
+
+// normalizer normalizes in place the content of an unstructured Kubernetes object.
+type normalizer func(obj map[string]interface{})
+
+// normalizers is the normalization pipeline that is applied to the objects before
+// comparing them, this way changes that are not real changes (e.g: `cpu: 1000m` vs `cpu: 1`)
+// are not detected as changes.
+var normalizers = []normalizer{
+	canonicalizeQuantities,
+	sortSetLists,
+	dropEmptyFields,
+}
+
+// normalize returns the normalized content of the Kubernetes object.
+func normalize(obj model.K8sObject) (map[string]interface{}, error) {
+	// Use JSON to get a copy of the object content, this way we have the
+	// same types on all the objects.
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal object: %w", err)
+	}
+
+	content := map[string]interface{}{}
+	err = json.Unmarshal(data, &content)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal object: %w", err)
+	}
+
+	for _, n := range normalizers {
+		n(content)
+	}
+
+	return content, nil
+}
+
+// podSpecPaths are the paths of the pod specs by the kind of the workloads.
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"PodTemplate":           {"template", "spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// canonicalizeQuantities sets the resource quantities of the known quantity fields in
+// their canonical form (e.g: `1000m` and `"1"` will be `1`). Only the known paths are
+// canonicalized, so the same fields on other objects (e.g: ConfigMap data or CRDs)
+// are compared as they are.
+func canonicalizeQuantities(obj map[string]interface{}) {
+	kind, _ := obj["kind"].(string)
+
+	if path, ok := podSpecPaths[kind]; ok {
+		canonicalizePodSpecQuantities(getMap(obj, path...))
+	}
+
+	switch kind {
+	case "StatefulSet":
+		for _, pvc := range getMapList(getMap(obj, "spec"), "volumeClaimTemplates") {
+			canonicalizeQuantityMaps(getMap(pvc, "spec", "resources"), "limits", "requests")
+		}
+	case "PersistentVolumeClaim":
+		canonicalizeQuantityMaps(getMap(obj, "spec", "resources"), "limits", "requests")
+	case "PersistentVolume":
+		canonicalizeQuantityMaps(getMap(obj, "spec"), "capacity")
+	case "ResourceQuota":
+		canonicalizeQuantityMaps(getMap(obj, "spec"), "hard")
+	case "LimitRange":
+		for _, l := range getMapList(getMap(obj, "spec"), "limits") {
+			canonicalizeQuantityMaps(l, "max", "min", "default", "defaultRequest", "maxLimitRequestRatio")
+		}
+	}
+}
+
+// canonicalizePodSpecQuantities canonicalizes the containers resources and the
+// `emptyDir` volumes size limit of a pod spec.
+func canonicalizePodSpecQuantities(podSpec map[string]interface{}) {
+	for _, field := range []string{"containers", "initContainers"} {
+		for _, c := range getMapList(podSpec, field) {
+			canonicalizeQuantityMaps(getMap(c, "resources"), "limits", "requests")
+		}
+	}
+
+	for _, v := range getMapList(podSpec, "volumes") {
+		emptyDir := getMap(v, "emptyDir")
+		if sl, ok := emptyDir["sizeLimit"]; ok {
+			emptyDir["sizeLimit"] = canonicalQuantity(sl)
+		}
+	}
+}
+
+// canonicalizeQuantityMaps canonicalizes the quantities of the received fields that
+// are maps of resource quantities.
+func canonicalizeQuantityMaps(m map[string]interface{}, fields ...string) {
+	for _, f := range fields {
+		qm, ok := m[f].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for qk, qv := range qm {
+			qm[qk] = canonicalQuantity(qv)
+		}
+	}
+}
+
+// getMap returns the map on the path, nil if missing.
+func getMap(m map[string]interface{}, path ...string) map[string]interface{} {
+	for _, p := range path {
+		child, ok := m[p].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		m = child
+	}
+
+	return m
+}
+
+// getMapList returns the map items of the list field.
+func getMapList(m map[string]interface{}, field string) []map[string]interface{} {
+	l, _ := m[field].([]interface{})
+	res := make([]map[string]interface{}, 0, len(l))
+	for _, item := range l {
+		if im, ok := item.(map[string]interface{}); ok {
+			res = append(res, im)
+		}
+	}
+
+	return res
+}
+
+func canonicalQuantity(v interface{}) interface{} {
+	var s string
+	switch tv := v.(type) {
+	case string:
+		s = tv
+	case float64:
+		s = strconv.FormatFloat(tv, 'f', -1, 64)
+	default:
+		return v
+	}
+
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return v
+	}
+
+	return q.String()
+}
+
+// Set-like lists are the known lists whose order doesn't matter, with the fields
+// of their items that will be used to sort them.
+var (
+	podSpecSetLists = map[string][]string{
+		"volumes":          {"name"},
+		"imagePullSecrets": {"name"},
+	}
+	containerSetLists = map[string][]string{
+		"ports":        {"name", "containerPort", "protocol"},
+		"volumeMounts": {"mountPath"},
+	}
+	serviceSpecSetLists = map[string][]string{
+		"ports": {"name", "port", "protocol"},
+	}
+)
+
+// sortSetLists sorts the known set-like lists. Only the known paths are sorted, so the
+// lists with the same name on other objects (e.g: CRDs) are compared as they are.
+func sortSetLists(obj map[string]interface{}) {
+	kind, _ := obj["kind"].(string)
+
+	if path, ok := podSpecPaths[kind]; ok {
+		podSpec := getMap(obj, path...)
+		sortMapSetLists(podSpec, podSpecSetLists)
+		for _, field := range []string{"containers", "initContainers"} {
+			for _, c := range getMapList(podSpec, field) {
+				sortMapSetLists(c, containerSetLists)
+			}
+		}
+	}
+
+	if kind == "Service" {
+		sortMapSetLists(getMap(obj, "spec"), serviceSpecSetLists)
+	}
+}
+
+// sortMapSetLists sorts the set-like lists of the map.
+func sortMapSetLists(m map[string]interface{}, setLists map[string][]string) {
+	for field, keys := range setLists {
+		l, ok := m[field].([]interface{})
+		if !ok {
+			continue
+		}
+
+		sort.SliceStable(l, func(i, j int) bool {
+			return setListItemKey(l[i], keys) < setListItemKey(l[j], keys)
+		})
+	}
+}
+
+func setListItemKey(item interface{}, keys []string) string {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return fmt.Sprint(item)
+	}
+
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, fmt.Sprint(m[k]))
+	}
+
+	return strings.Join(values, "\x00")
+}
+
+// dropEmptyFields removes the fields that are null, empty maps or empty lists.
+func dropEmptyFields(obj map[string]interface{}) {
+	dropEmpty(obj)
+}
+
+func dropEmpty(v interface{}) (empty bool) {
+	switch tv := v.(type) {
+	case nil:
+		return true
+
+	case map[string]interface{}:
+		for k, child := range tv {
+			if dropEmpty(child) {
+				delete(tv, k)
+			}
+		}
+		return len(tv) == 0
+
+	case []interface{}:
+		for _, child := range tv {
+			// We don't remove items from the lists, position could matter,
+			// we only clean them.
+			dropEmpty(child)
+		}
+		return len(tv) == 0
+	}
+
+	return false
+}
@@ -0,0 +1,364 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/slok/kahoy/internal/internalerrors"
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/plan"
+	"github.com/slok/kahoy/internal/storage"
+)
+
+// Format is the format of the plan output.
+type Format string
+
+const (
+	// FormatJSON prints the plan in JSON.
+	FormatJSON Format = "json"
+	// FormatYAML prints the plan in YAML.
+	FormatYAML Format = "yaml"
+	// FormatMarkdown prints the plan in markdown, useful to post it as a comment.
+	FormatMarkdown Format = "markdown"
+)
+
+// ChangeType is the type of change of a planned resource.
+type ChangeType string
+
+const (
+	// ChangeTypeCreate is used when the resource is new.
+	ChangeTypeCreate ChangeType = "create"
+	// ChangeTypeUpdate is used when the resource already existed.
+	ChangeTypeUpdate ChangeType = "update"
+	// ChangeTypeDelete is used when the resource will be deleted.
+	ChangeTypeDelete ChangeType = "delete"
+	// ChangeTypeMove is used when the resource has been moved from another group.
+	ChangeTypeMove ChangeType = "move"
+	// ChangeTypeRelease is used when the resource will stop being managed without deleting it.
+	ChangeTypeRelease ChangeType = "release"
+)
+
+// Plan is the plan that will be printed.
+type Plan struct {
+	// OldResources are the current resources, used to know the type of change and
+	// the changed fields of the applied resources.
+	OldResources     []model.Resource
+	ApplyResources   []model.Resource
+	DeleteResources  []model.Resource
+	ReleaseResources []model.Resource
+	MovedResources   []model.MovedResource
+}
+
+// Printer knows how to print a plan.
+type Printer interface {
+	Print(ctx context.Context, plan Plan) error
+}
+
+// PrinterConfig is the configuration of the printer.
+type PrinterConfig struct {
+	Format Format
+	Out    io.Writer
+	// GroupRepository is used to get the priority of the resources.
+	GroupRepository storage.GroupRepository
+	Logger          log.Logger
+}
+
+func (c *PrinterConfig) defaults() error {
+	switch c.Format {
+	case FormatJSON, FormatYAML, FormatMarkdown:
+	default:
+		return fmt.Errorf("unknown plan output format: %q", c.Format)
+	}
+
+	if c.Out == nil {
+		c.Out = os.Stdout
+	}
+
+	if c.GroupRepository == nil {
+		return fmt.Errorf("group repository is required")
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "output.Printer"})
+
+	return nil
+}
+
+type printer struct {
+	format    Format
+	out       io.Writer
+	groupRepo storage.GroupRepository
+	logger    log.Logger
+}
+
+// NewPrinter returns a new plan printer.
+func NewPrinter(config PrinterConfig) (Printer, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return printer{
+		format:    config.Format,
+		out:       config.Out,
+		groupRepo: config.GroupRepository,
+		logger:    config.Logger,
+	}, nil
+}
+
+func (p printer) Print(ctx context.Context, pl Plan) error {
+	op, err := p.newOutputPlan(ctx, pl)
+	if err != nil {
+		return fmt.Errorf("could not map plan: %w", err)
+	}
+
+	var data []byte
+	switch p.format {
+	case FormatJSON:
+		data, err = json.Marshal(op)
+		data = append(data, '\n')
+	case FormatYAML:
+		data, err = yaml.Marshal(op)
+	case FormatMarkdown:
+		data = []byte(renderMarkdown(op))
+	}
+	if err != nil {
+		return fmt.Errorf("could not format plan: %w", err)
+	}
+
+	_, err = p.out.Write(data)
+	if err != nil {
+		return fmt.Errorf("could not write plan: %w", err)
+	}
+
+	return nil
+}
+
+type outputPlan struct {
+	Version          string           `json:"version"`
+	ApplyResources   []outputResource `json:"apply_resources"`
+	DeleteResources  []outputResource `json:"delete_resources"`
+	ReleaseResources []outputResource `json:"release_resources"`
+	// MoveResources are the resources moved between groups that don't need to be applied.
+	MoveResources []outputResource `json:"move_resources"`
+}
+
+type outputResource struct {
+	ID           string        `json:"id"`
+	Group        string        `json:"group"`
+	OldGroup     string        `json:"old_group,omitempty"`
+	Priority     *int          `json:"priority,omitempty"`
+	ManifestPath string        `json:"manifest_path"`
+	Change       ChangeType    `json:"change"`
+	APIVersion   string        `json:"api_version"`
+	Kind         string        `json:"kind"`
+	Namespace    string        `json:"namespace"`
+	Name         string        `json:"name"`
+	Fields       []outputField `json:"fields,omitempty"`
+}
+
+type outputField struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+}
+
+func (p printer) newOutputPlan(ctx context.Context, pl Plan) (*outputPlan, error) {
+	oldIdx := map[string]model.Resource{}
+	for _, r := range pl.OldResources {
+		oldIdx[r.ID] = r
+	}
+
+	movedIdx := map[string]string{}
+	for _, m := range pl.MovedResources {
+		movedIdx[m.Resource.ID] = m.OldGroupID
+	}
+
+	op := &outputPlan{Version: "v1"}
+
+	// Applied resources.
+	applied := map[string]bool{}
+	for _, r := range pl.ApplyResources {
+		applied[r.ID] = true
+
+		change := ChangeTypeCreate
+		if _, ok := oldIdx[r.ID]; ok {
+			change = ChangeTypeUpdate
+		}
+		if _, ok := movedIdx[r.ID]; ok {
+			change = ChangeTypeMove
+		}
+
+		res, err := p.newOutputResource(ctx, r, change, movedIdx[r.ID], oldIdx)
+		if err != nil {
+			return nil, err
+		}
+		op.ApplyResources = append(op.ApplyResources, *res)
+	}
+
+	// Moved resources that will not be applied.
+	for _, m := range pl.MovedResources {
+		if applied[m.Resource.ID] {
+			continue
+		}
+
+		res, err := p.newOutputResource(ctx, m.Resource, ChangeTypeMove, m.OldGroupID, oldIdx)
+		if err != nil {
+			return nil, err
+		}
+		op.MoveResources = append(op.MoveResources, *res)
+	}
+
+	// Deleted and released resources.
+	for _, r := range pl.DeleteResources {
+		res, err := p.newOutputResource(ctx, r, ChangeTypeDelete, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		op.DeleteResources = append(op.DeleteResources, *res)
+	}
+
+	for _, r := range pl.ReleaseResources {
+		res, err := p.newOutputResource(ctx, r, ChangeTypeRelease, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		op.ReleaseResources = append(op.ReleaseResources, *res)
+	}
+
+	sortResources(op.ApplyResources)
+	sortResources(op.DeleteResources)
+	sortResources(op.ReleaseResources)
+	sortResources(op.MoveResources)
+
+	// Always return lists.
+	if op.ApplyResources == nil {
+		op.ApplyResources = []outputResource{}
+	}
+	if op.DeleteResources == nil {
+		op.DeleteResources = []outputResource{}
+	}
+	if op.ReleaseResources == nil {
+		op.ReleaseResources = []outputResource{}
+	}
+	if op.MoveResources == nil {
+		op.MoveResources = []outputResource{}
+	}
+
+	return op, nil
+}
+
+func (p printer) newOutputResource(ctx context.Context, r model.Resource, change ChangeType, oldGroupID string, oldIdx map[string]model.Resource) (*outputResource, error) {
+	gvk := r.K8sObject.GetObjectKind().GroupVersionKind()
+	res := &outputResource{
+		ID:           r.ID,
+		Group:        r.GroupID,
+		OldGroup:     oldGroupID,
+		ManifestPath: r.ManifestPath,
+		Change:       change,
+		APIVersion:   gvk.GroupVersion().String(),
+		Kind:         gvk.Kind,
+		Namespace:    r.K8sObject.GetNamespace(),
+		Name:         r.K8sObject.GetName(),
+	}
+
+	// Deleted resources groups could be missing.
+	group, err := p.groupRepo.GetGroup(ctx, r.GroupID)
+	if err != nil && !errors.Is(err, internalerrors.ErrMissing) {
+		return nil, fmt.Errorf("could not get group %q: %w", r.GroupID, err)
+	}
+	if group != nil {
+		priority := group.Priority
+		res.Priority = &priority
+	}
+
+	// Get the field changes.
+	if old, ok := oldIdx[r.ID]; ok && old.K8sObject != nil {
+		changes, err := plan.DiffFields(old.K8sObject, r.K8sObject)
+		if err != nil {
+			p.logger.WithValues(log.Kv{"resource-id": r.ID}).Warningf("could not get field changes: %s", err)
+		}
+		for _, c := range changes {
+			res.Fields = append(res.Fields, outputField{Path: c.Path, Change: string(c.Type)})
+		}
+	}
+
+	return res, nil
+}
+
+// sortResources sorts the resources in the same order they will be executed, by
+// priority, group and ID.
+func sortResources(rs []outputResource) {
+	sort.SliceStable(rs, func(i, j int) bool {
+		pi, pj := priorityOf(rs[i]), priorityOf(rs[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return rs[i].Group+rs[i].ID < rs[j].Group+rs[j].ID
+	})
+}
+
+func priorityOf(r outputResource) int {
+	if r.Priority == nil {
+		return 0
+	}
+	return *r.Priority
+}
+
+func renderMarkdown(op *outputPlan) string {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "## Kahoy plan\n\n")
+	if len(op.ApplyResources)+len(op.DeleteResources)+len(op.ReleaseResources)+len(op.MoveResources) == 0 {
+		fmt.Fprintf(b, "No changes.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(b, "**%d** to apply, **%d** to delete, **%d** to release, **%d** moved without apply.\n",
+		len(op.ApplyResources), len(op.DeleteResources), len(op.ReleaseResources), len(op.MoveResources))
+
+	renderMarkdownTable(b, "Apply", op.ApplyResources)
+	renderMarkdownTable(b, "Delete", op.DeleteResources)
+	renderMarkdownTable(b, "Release", op.ReleaseResources)
+	renderMarkdownTable(b, "Move", op.MoveResources)
+
+	return b.String()
+}
+
+func renderMarkdownTable(b *strings.Builder, title string, rs []outputResource) {
+	if len(rs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	fmt.Fprintf(b, "| Priority | Group | Resource | Change | Manifest | Fields |\n")
+	fmt.Fprintf(b, "|---|---|---|---|---|---|\n")
+	for _, r := range rs {
+		priority := "-"
+		if r.Priority != nil {
+			priority = fmt.Sprintf("%d", *r.Priority)
+		}
+
+		change := string(r.Change)
+		if r.OldGroup != "" {
+			change = fmt.Sprintf("%s (from `%s`)", change, r.OldGroup)
+		}
+
+		fields := make([]string, 0, len(r.Fields))
+		for _, f := range r.Fields {
+			fields = append(fields, fmt.Sprintf("`%s` (%s)", f.Path, f.Change))
+		}
+
+		fmt.Fprintf(b, "| %s | `%s` | `%s` | %s | `%s` | %s |\n", priority, r.Group, r.ID, change, r.ManifestPath, strings.Join(fields, "<br>"))
+	}
+}
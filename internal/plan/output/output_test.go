@@ -0,0 +1,197 @@
+package output_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/slok/kahoy/internal/internalerrors"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/plan/output"
+	"github.com/slok/kahoy/internal/storage/storagemock"
+)
+
+func newResource(name, group string, replicas int64) model.Resource {
+	type tm = map[string]interface{}
+
+	return model.Resource{
+		ID:           name,
+		GroupID:      group,
+		ManifestPath: group + "/" + name + ".yaml",
+		K8sObject: &unstructured.Unstructured{
+			Object: tm{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": tm{
+					"name":      name,
+					"namespace": "ns1",
+				},
+				"spec": tm{
+					"replicas": replicas,
+				},
+			},
+		},
+	}
+}
+
+func TestPrinterPrint(t *testing.T) {
+	tests := map[string]struct {
+		format output.Format
+		plan   output.Plan
+		mock   func(mgr *storagemock.GroupRepository)
+		expOut string
+		expErr bool
+	}{
+		"Having an empty plan in JSON should print an empty plan.": {
+			format: output.FormatJSON,
+			plan:   output.Plan{},
+			mock:   func(mgr *storagemock.GroupRepository) {},
+			expOut: `{"version":"v1","apply_resources":[],"delete_resources":[],"release_resources":[],"move_resources":[]}` + "\n",
+		},
+
+		"Having a plan in JSON should print the plan with the change types, priorities and changed fields.": {
+			format: output.FormatJSON,
+			plan: output.Plan{
+				OldResources: []model.Resource{
+					newResource("r2", "g1", 1),
+					newResource("r3", "g1", 1),
+					newResource("r4", "g1", 1),
+				},
+				ApplyResources: []model.Resource{
+					newResource("r1", "g2", 1),
+					newResource("r2", "g1", 3),
+					newResource("r3", "g2", 1),
+				},
+				DeleteResources: []model.Resource{
+					newResource("r5", "g3", 1),
+				},
+				MovedResources: []model.MovedResource{
+					{Resource: newResource("r3", "g2", 1), OldGroupID: "g1"},
+					{Resource: newResource("r4", "g2", 1), OldGroupID: "g1"},
+				},
+			},
+			mock: func(mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1", Priority: 200}, nil)
+				mgr.On("GetGroup", mock.Anything, "g2").Return(&model.Group{ID: "g2", Priority: 100}, nil)
+				mgr.On("GetGroup", mock.Anything, "g3").Return(nil, internalerrors.ErrMissing)
+			},
+			expOut: `{"version":"v1","apply_resources":[` +
+				`{"id":"r1","group":"g2","priority":100,"manifest_path":"g2/r1.yaml","change":"create","api_version":"apps/v1","kind":"Deployment","namespace":"ns1","name":"r1"},` +
+				`{"id":"r3","group":"g2","old_group":"g1","priority":100,"manifest_path":"g2/r3.yaml","change":"move","api_version":"apps/v1","kind":"Deployment","namespace":"ns1","name":"r3"},` +
+				`{"id":"r2","group":"g1","priority":200,"manifest_path":"g1/r2.yaml","change":"update","api_version":"apps/v1","kind":"Deployment","namespace":"ns1","name":"r2","fields":[{"path":".spec.replicas","change":"modified"}]}],` +
+				`"delete_resources":[{"id":"r5","group":"g3","manifest_path":"g3/r5.yaml","change":"delete","api_version":"apps/v1","kind":"Deployment","namespace":"ns1","name":"r5"}],` +
+				`"release_resources":[],` +
+				`"move_resources":[{"id":"r4","group":"g2","old_group":"g1","priority":100,"manifest_path":"g2/r4.yaml","change":"move","api_version":"apps/v1","kind":"Deployment","namespace":"ns1","name":"r4"}]}` + "\n",
+		},
+
+		"Having a plan in YAML should print the plan in YAML.": {
+			format: output.FormatYAML,
+			plan: output.Plan{
+				ReleaseResources: []model.Resource{
+					newResource("r1", "g1", 1),
+				},
+			},
+			mock: func(mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1", Priority: 200}, nil)
+			},
+			expOut: `apply_resources: []
+delete_resources: []
+move_resources: []
+release_resources:
+- api_version: apps/v1
+  change: release
+  group: g1
+  id: r1
+  kind: Deployment
+  manifest_path: g1/r1.yaml
+  name: r1
+  namespace: ns1
+  priority: 200
+version: v1
+`,
+		},
+
+		"Having an empty plan in markdown should print no changes.": {
+			format: output.FormatMarkdown,
+			plan:   output.Plan{},
+			mock:   func(mgr *storagemock.GroupRepository) {},
+			expOut: "## Kahoy plan\n\nNo changes.\n",
+		},
+
+		"Having a plan in markdown should print the plan tables.": {
+			format: output.FormatMarkdown,
+			plan: output.Plan{
+				OldResources: []model.Resource{
+					newResource("r2", "g1", 1),
+				},
+				ApplyResources: []model.Resource{
+					newResource("r1", "g1", 1),
+					newResource("r2", "g2", 3),
+				},
+				DeleteResources: []model.Resource{
+					newResource("r3", "g1", 1),
+				},
+				MovedResources: []model.MovedResource{
+					{Resource: newResource("r2", "g2", 3), OldGroupID: "g1"},
+				},
+			},
+			mock: func(mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1", Priority: 100}, nil)
+				mgr.On("GetGroup", mock.Anything, "g2").Return(&model.Group{ID: "g2", Priority: 100}, nil)
+			},
+			expOut: "## Kahoy plan\n\n" +
+				"**2** to apply, **1** to delete, **0** to release, **0** moved without apply.\n" +
+				"\n### Apply\n\n" +
+				"| Priority | Group | Resource | Change | Manifest | Fields |\n" +
+				"|---|---|---|---|---|---|\n" +
+				"| 100 | `g1` | `r1` | create | `g1/r1.yaml` |  |\n" +
+				"| 100 | `g2` | `r2` | move (from `g1`) | `g2/r2.yaml` | `.spec.replicas` (modified) |\n" +
+				"\n### Delete\n\n" +
+				"| Priority | Group | Resource | Change | Manifest | Fields |\n" +
+				"|---|---|---|---|---|---|\n" +
+				"| 100 | `g1` | `r3` | delete | `g1/r3.yaml` |  |\n",
+		},
+
+		"Having an unknown format should fail.": {
+			format: output.Format("whatever"),
+			mock:   func(mgr *storagemock.GroupRepository) {},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mgr := &storagemock.GroupRepository{}
+			test.mock(mgr)
+
+			// Execute.
+			var out bytes.Buffer
+			printer, err := output.NewPrinter(output.PrinterConfig{
+				Format:          test.format,
+				Out:             &out,
+				GroupRepository: mgr,
+			})
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			err = printer.Print(context.TODO(), test.plan)
+
+			// Check.
+			if assert.NoError(err) {
+				assert.Equal(test.expOut, out.String())
+			}
+		})
+	}
+}
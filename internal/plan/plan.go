@@ -2,11 +2,16 @@ package plan
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 
+	"github.com/slok/kahoy/internal/internalerrors"
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/storage"
 )
 
 // ResourceState represents the state of a resource.
@@ -19,12 +24,21 @@ const (
 	ResourceStateExists
 	// ResourceStateMissing represents a state where the resource should be missing.
 	ResourceStateMissing
+	// ResourceStateMoved represents a state where the resource should exists and has been moved
+	// from another group.
+	ResourceStateMoved
+	// ResourceStateMovedUnchanged represents a state where the resource has been moved from another
+	// group, but doesn't need to be applied again.
+	ResourceStateMovedUnchanged
 )
 
 // State is the state of a plan of states.
 type State struct {
 	State    ResourceState
 	Resource model.Resource
+	// OldGroupID is the group where the resource was before being moved, only
+	// set on moved states.
+	OldGroupID string
 }
 
 // Planner knows how to make an plan of resource state based on an old group
@@ -33,21 +47,53 @@ type Planner interface {
 	Plan(ctx context.Context, old []model.Resource, new []model.Resource) ([]State, error)
 }
 
+// PlannerConfig is the configuration of the planner.
+type PlannerConfig struct {
+	// OnlyOnDiff will only plan the resources that have changed.
+	OnlyOnDiff bool
+	// GroupRepository and OldGroupRepository are used to check if the moved resources need
+	// to be applied again when OnlyOnDiff is used, comparing the group of the resource on the
+	// new state with its group on the old state. If any is missing, moved resources will
+	// always be applied.
+	GroupRepository    storage.GroupRepository
+	OldGroupRepository storage.GroupRepository
+	Logger             log.Logger
+}
+
+func (c *PlannerConfig) defaults() error {
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "plan.Planner"})
+
+	return nil
+}
+
 type planner struct {
-	onlyOnDiff bool
-	logger     log.Logger
+	onlyOnDiff   bool
+	groupRepo    storage.GroupRepository
+	oldGroupRepo storage.GroupRepository
+	logger       log.Logger
 }
 
 // NewPlanner returns a new planner.
 // The planner will take all the resources that exists on the new one, and delete
 // the ones that are not on the new one and are on the old one.
-// If `onlyOnDiff` is used, it will only add the ones that have changed and ignore the
+// If `OnlyOnDiff` is used, it will only add the ones that have changed and ignore the
 // ones that are the same.
-func NewPlanner(onlyOnDiff bool, logger log.Logger) Planner {
-	return planner{
-		onlyOnDiff: onlyOnDiff,
-		logger:     logger.WithValues(log.Kv{"app-svc": "plan.Planner"}),
+// The resources that have been moved between groups will be planned with a moved state.
+func NewPlanner(config PlannerConfig) (Planner, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
+
+	return planner{
+		onlyOnDiff:   config.OnlyOnDiff,
+		groupRepo:    config.GroupRepository,
+		oldGroupRepo: config.OldGroupRepository,
+		logger:       config.Logger,
+	}, nil
 }
 
 // Plan plans the states by comparing an expected state and the current state.
@@ -57,19 +103,50 @@ func (p planner) Plan(ctx context.Context, old []model.Resource, new []model.Res
 
 	missingQ := 0
 	existsQ := 0
+	movedQ := 0
 	states := []State{}
 
 	// Add the ones that we know need to exist.
 	for _, newRes := range newIdx {
+		oldRes, ok := oldIdx[newRes.ID]
+		moved := ok && oldRes.GroupID != newRes.GroupID
+
 		// If we want to filter resources that didn't change, we need to check that if we had an old state,
 		// the resources have changed. In case they didn't change, we will ignore them.
 		// TODO(slok): If we start having more than one filter, move this to a filter processing chain style.
-		if p.onlyOnDiff {
-			oldRes, ok := oldIdx[newRes.ID]
-			if ok && !p.hasChanged(oldRes, newRes) {
+		if p.onlyOnDiff && ok && !p.hasChanged(oldRes, newRes) {
+			if !moved {
 				resourceLogger(p.logger, newRes).Debugf("no changes between old and new state, ignoring resource from plan")
 				continue
 			}
+
+			// Moved resources without changes only need to be applied if the new group
+			// executes them in a different way.
+			required, err := p.moveRequiresApply(ctx, oldRes.GroupID, newRes.GroupID)
+			if err != nil {
+				return nil, err
+			}
+
+			if !required {
+				resourceLogger(p.logger, newRes).Debugf("resource moved from %q group without changes, it will not be applied", oldRes.GroupID)
+				movedQ++
+				states = append(states, State{
+					State:      ResourceStateMovedUnchanged,
+					Resource:   newRes,
+					OldGroupID: oldRes.GroupID,
+				})
+				continue
+			}
+		}
+
+		if moved {
+			movedQ++
+			states = append(states, State{
+				State:      ResourceStateMoved,
+				Resource:   newRes,
+				OldGroupID: oldRes.GroupID,
+			})
+			continue
 		}
 
 		existsQ++
@@ -94,11 +171,41 @@ func (p planner) Plan(ctx context.Context, old []model.Resource, new []model.Res
 		})
 	}
 
-	p.logger.Infof("%d planned states, %d missing, %d exists", len(states), missingQ, existsQ)
+	p.logger.Infof("%d planned states, %d missing, %d exists, %d moved", len(states), missingQ, existsQ, movedQ)
 
 	return states, nil
 }
 
+// moveRequiresApply checks if a resource moved between groups needs to be applied again,
+// this will happen when the groups have different settings that affect how the resources
+// are applied (priority, hooks, timeout, conflict policy, replace on immutable...).
+func (p planner) moveRequiresApply(ctx context.Context, oldGroupID, newGroupID string) (bool, error) {
+	if p.groupRepo == nil || p.oldGroupRepo == nil {
+		return true, nil
+	}
+
+	newGroup, err := p.groupRepo.GetGroup(ctx, newGroupID)
+	if err != nil {
+		return false, fmt.Errorf("could not get %q group: %w", newGroupID, err)
+	}
+
+	// If the old group doesn't exist anymore we can't compare them.
+	oldGroup, err := p.oldGroupRepo.GetGroup(ctx, oldGroupID)
+	if err != nil {
+		if errors.Is(err, internalerrors.ErrMissing) {
+			return true, nil
+		}
+		return false, fmt.Errorf("could not get %q group: %w", oldGroupID, err)
+	}
+
+	// Compare all the group settings except the ones that identify the group.
+	oldSettings, newSettings := *oldGroup, *newGroup
+	oldSettings.ID, oldSettings.Path = "", ""
+	newSettings.ID, newSettings.Path = "", ""
+
+	return !reflect.DeepEqual(oldSettings, newSettings), nil
+}
+
 func indexResources(rs []model.Resource) map[string]model.Resource {
 	index := map[string]model.Resource{}
 	for _, r := range rs {
@@ -108,8 +215,26 @@ func indexResources(rs []model.Resource) map[string]model.Resource {
 	return index
 }
 
+// hasChanged compares the resources after normalizing them, so only real changes are detected.
+// If the resources can't be normalized, the raw resources will be compared.
 func (p planner) hasChanged(old, new model.Resource) bool {
-	return !equality.Semantic.Equalities.DeepEqual(old.K8sObject, new.K8sObject)
+	if old.K8sObject == nil || new.K8sObject == nil {
+		return !equality.Semantic.Equalities.DeepEqual(old.K8sObject, new.K8sObject)
+	}
+
+	oldObj, err := normalize(old.K8sObject)
+	if err != nil {
+		resourceLogger(p.logger, old).Warningf("could not normalize resource: %s", err)
+		return !equality.Semantic.Equalities.DeepEqual(old.K8sObject, new.K8sObject)
+	}
+
+	newObj, err := normalize(new.K8sObject)
+	if err != nil {
+		resourceLogger(p.logger, new).Warningf("could not normalize resource: %s", err)
+		return !equality.Semantic.Equalities.DeepEqual(old.K8sObject, new.K8sObject)
+	}
+
+	return !equality.Semantic.Equalities.DeepEqual(oldObj, newObj)
 }
 
 func resourceLogger(l log.Logger, r model.Resource) log.Logger {
@@ -5,13 +5,18 @@ import (
 	"errors"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"github.com/slok/kahoy/internal/internalerrors"
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
 	"github.com/slok/kahoy/internal/plan"
+	"github.com/slok/kahoy/internal/storage/storagemock"
 )
 
 func newPod(name string, containerNames []string) model.K8sObject {
@@ -214,7 +219,8 @@ func TestPlannerPlan(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			assert := assert.New(t)
 
-			p := plan.NewPlanner(test.onlyOnDiff, log.Noop)
+			p, err := plan.NewPlanner(plan.PlannerConfig{OnlyOnDiff: test.onlyOnDiff, Logger: log.Noop})
+			require.NoError(t, err)
 			gotState, err := p.Plan(context.TODO(), test.oldRes, test.newRes)
 
 			if test.expErr != nil {
@@ -233,3 +239,403 @@ func sortStateList(l []plan.State) {
 		return l[i].Resource.ID < l[j].Resource.ID
 	})
 }
+
+func TestPlannerPlanOnlyOnDiffNormalization(t *testing.T) {
+	type tm = map[string]interface{}
+	type ts = []interface{}
+
+	newContainerPod := func(container tm) model.K8sObject {
+		return &unstructured.Unstructured{
+			Object: tm{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": tm{
+					"name":      "test",
+					"namespace": "test",
+				},
+				"spec": tm{
+					"containers": ts{container},
+				},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		oldObj     model.K8sObject
+		newObj     model.K8sObject
+		expChanged bool
+	}{
+		"Same resources shouldn't be a change.": {
+			oldObj:     newContainerPod(tm{"name": "app", "image": "app:v1"}),
+			newObj:     newContainerPod(tm{"name": "app", "image": "app:v1"}),
+			expChanged: false,
+		},
+
+		"Different resources should be a change.": {
+			oldObj:     newContainerPod(tm{"name": "app", "image": "app:v1"}),
+			newObj:     newContainerPod(tm{"name": "app", "image": "app:v2"}),
+			expChanged: true,
+		},
+
+		"Equivalent quantities shouldn't be a change.": {
+			oldObj: newContainerPod(tm{"name": "app", "resources": tm{
+				"limits":   tm{"cpu": "1000m", "memory": "1Gi"},
+				"requests": tm{"cpu": int64(1), "memory": "1024Mi"},
+			}}),
+			newObj: newContainerPod(tm{"name": "app", "resources": tm{
+				"limits":   tm{"cpu": "1", "memory": "1Gi"},
+				"requests": tm{"cpu": "1", "memory": "1Gi"},
+			}}),
+			expChanged: false,
+		},
+
+		"Different quantities should be a change.": {
+			oldObj: newContainerPod(tm{"name": "app", "resources": tm{
+				"limits": tm{"cpu": "1000m"},
+			}}),
+			newObj: newContainerPod(tm{"name": "app", "resources": tm{
+				"limits": tm{"cpu": "1001m"},
+			}}),
+			expChanged: true,
+		},
+
+		"Equivalent quantities on known non container fields shouldn't be a change.": {
+			oldObj: &unstructured.Unstructured{Object: tm{
+				"apiVersion": "v1",
+				"kind":       "ResourceQuota",
+				"metadata":   tm{"name": "test", "namespace": "test"},
+				"spec":       tm{"hard": tm{"cpu": "1000m"}},
+			}},
+			newObj: &unstructured.Unstructured{Object: tm{
+				"apiVersion": "v1",
+				"kind":       "ResourceQuota",
+				"metadata":   tm{"name": "test", "namespace": "test"},
+				"spec":       tm{"hard": tm{"cpu": "1"}},
+			}},
+			expChanged: false,
+		},
+
+		"Quantity like fields on unknown paths should be compared as they are.": {
+			oldObj: &unstructured.Unstructured{Object: tm{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   tm{"name": "test", "namespace": "test"},
+				"data":       tm{"limits": tm{"cpu": "1000m"}},
+			}},
+			newObj: &unstructured.Unstructured{Object: tm{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   tm{"name": "test", "namespace": "test"},
+				"data":       tm{"limits": tm{"cpu": "1"}},
+			}},
+			expChanged: true,
+		},
+
+		"Null and empty fields shouldn't be a change.": {
+			oldObj:     newContainerPod(tm{"name": "app", "args": nil, "env": ts{}, "resources": tm{}}),
+			newObj:     newContainerPod(tm{"name": "app"}),
+			expChanged: false,
+		},
+
+		"Reordered set-like lists shouldn't be a change.": {
+			oldObj: newContainerPod(tm{
+				"name":         "app",
+				"ports":        ts{tm{"name": "http", "containerPort": int64(80)}, tm{"name": "metrics", "containerPort": int64(81)}},
+				"volumeMounts": ts{tm{"name": "a", "mountPath": "/a"}, tm{"name": "b", "mountPath": "/b"}},
+			}),
+			newObj: newContainerPod(tm{
+				"name":         "app",
+				"ports":        ts{tm{"name": "metrics", "containerPort": int64(81)}, tm{"name": "http", "containerPort": int64(80)}},
+				"volumeMounts": ts{tm{"name": "b", "mountPath": "/b"}, tm{"name": "a", "mountPath": "/a"}},
+			}),
+			expChanged: false,
+		},
+
+		"Reordered service ports shouldn't be a change.": {
+			oldObj: &unstructured.Unstructured{Object: tm{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   tm{"name": "test", "namespace": "test"},
+				"spec":       tm{"ports": ts{tm{"name": "http", "port": int64(80)}, tm{"name": "metrics", "port": int64(81)}}},
+			}},
+			newObj: &unstructured.Unstructured{Object: tm{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   tm{"name": "test", "namespace": "test"},
+				"spec":       tm{"ports": ts{tm{"name": "metrics", "port": int64(81)}, tm{"name": "http", "port": int64(80)}}},
+			}},
+			expChanged: false,
+		},
+
+		"Reordered env vars should be a change.": {
+			oldObj:     newContainerPod(tm{"name": "app", "env": ts{tm{"name": "A", "value": "a"}, tm{"name": "B", "value": "$(A)"}}}),
+			newObj:     newContainerPod(tm{"name": "app", "env": ts{tm{"name": "B", "value": "$(A)"}, tm{"name": "A", "value": "a"}}}),
+			expChanged: true,
+		},
+
+		"Reordered set-like lists on unknown paths should be a change.": {
+			oldObj: &unstructured.Unstructured{Object: tm{
+				"apiVersion": "test.dev/v1",
+				"kind":       "Test",
+				"metadata":   tm{"name": "test", "namespace": "test"},
+				"spec":       tm{"volumes": ts{tm{"name": "a"}, tm{"name": "b"}}},
+			}},
+			newObj: &unstructured.Unstructured{Object: tm{
+				"apiVersion": "test.dev/v1",
+				"kind":       "Test",
+				"metadata":   tm{"name": "test", "namespace": "test"},
+				"spec":       tm{"volumes": ts{tm{"name": "b"}, tm{"name": "a"}}},
+			}},
+			expChanged: true,
+		},
+
+		"Reordered lists that are not set-like should be a change.": {
+			oldObj:     newContainerPod(tm{"name": "app", "args": ts{"--a", "--b"}}),
+			newObj:     newContainerPod(tm{"name": "app", "args": ts{"--b", "--a"}}),
+			expChanged: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			oldRes := []model.Resource{{ID: "test", K8sObject: test.oldObj}}
+			newRes := []model.Resource{{ID: "test", K8sObject: test.newObj}}
+
+			p, err := plan.NewPlanner(plan.PlannerConfig{OnlyOnDiff: true, Logger: log.Noop})
+			require.NoError(t, err)
+			gotState, err := p.Plan(context.TODO(), oldRes, newRes)
+			if assert.NoError(err) {
+				assert.Equal(test.expChanged, len(gotState) == 1)
+			}
+		})
+	}
+}
+
+func TestPlannerPlanMovedResources(t *testing.T) {
+	tests := map[string]struct {
+		onlyOnDiff bool
+		oldRes     []model.Resource
+		newRes     []model.Resource
+		mock       func(oldMgr, newMgr *storagemock.GroupRepository)
+		expState   []plan.State
+		expErr     bool
+	}{
+		"Moved resources should be planned as moved.": {
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+				{ID: "test1", GroupID: "g1", K8sObject: newPod("test1", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+				{ID: "test1", GroupID: "g2", K8sObject: newPod("test1", []string{"c1"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {},
+			expState: []plan.State{
+				{Resource: model.Resource{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})}, State: plan.ResourceStateExists},
+				{Resource: model.Resource{ID: "test1", GroupID: "g2", K8sObject: newPod("test1", []string{"c1"})}, State: plan.ResourceStateMoved, OldGroupID: "g1"},
+			},
+		},
+
+		"Moved resources with changes, using only diff changes flag, should be planned as moved.": {
+			onlyOnDiff: true,
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1", "c2"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {},
+			expState: []plan.State{
+				{Resource: model.Resource{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1", "c2"})}, State: plan.ResourceStateMoved, OldGroupID: "g1"},
+			},
+		},
+
+		"Moved resources without changes to a group with the same settings, using only diff changes flag, should be planned as moved unchanged.": {
+			onlyOnDiff: true,
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {
+				oldMgr.On("GetGroup", mock.Anything, "g1").Once().Return(&model.Group{ID: "g1", Path: "manifests/g1", Priority: 1000}, nil)
+				newMgr.On("GetGroup", mock.Anything, "g2").Once().Return(&model.Group{ID: "g2", Path: "manifests/g2", Priority: 1000}, nil)
+			},
+			expState: []plan.State{
+				{Resource: model.Resource{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})}, State: plan.ResourceStateMovedUnchanged, OldGroupID: "g1"},
+			},
+		},
+
+		"Moved resources without changes to a group with the same settings as the old group, using only diff changes flag, should be compared with the old group state.": {
+			onlyOnDiff: true,
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {
+				// The old group is only available on the old state repository.
+				oldMgr.On("GetGroup", mock.Anything, "g1").Once().Return(&model.Group{ID: "g1", Priority: 10}, nil)
+				newMgr.On("GetGroup", mock.Anything, "g2").Once().Return(&model.Group{ID: "g2", Priority: 10}, nil)
+			},
+			expState: []plan.State{
+				{Resource: model.Resource{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})}, State: plan.ResourceStateMovedUnchanged, OldGroupID: "g1"},
+			},
+		},
+
+		"Moved resources without changes to a group with different priority, using only diff changes flag, should be planned as moved.": {
+			onlyOnDiff: true,
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {
+				oldMgr.On("GetGroup", mock.Anything, "g1").Once().Return(&model.Group{ID: "g1", Priority: 1000}, nil)
+				newMgr.On("GetGroup", mock.Anything, "g2").Once().Return(&model.Group{ID: "g2", Priority: 10}, nil)
+			},
+			expState: []plan.State{
+				{Resource: model.Resource{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})}, State: plan.ResourceStateMoved, OldGroupID: "g1"},
+			},
+		},
+
+		"Moved resources without changes to a group with different hooks, using only diff changes flag, should be planned as moved.": {
+			onlyOnDiff: true,
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {
+				oldMgr.On("GetGroup", mock.Anything, "g1").Once().Return(&model.Group{ID: "g1"}, nil)
+				newMgr.On("GetGroup", mock.Anything, "g2").Once().Return(&model.Group{ID: "g2", Hooks: model.GroupHooks{
+					Pre: &model.GroupHookSpec{Cmd: "echo"},
+				}}, nil)
+			},
+			expState: []plan.State{
+				{Resource: model.Resource{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})}, State: plan.ResourceStateMoved, OldGroupID: "g1"},
+			},
+		},
+
+		"Moved resources without changes to a group with different timeout, using only diff changes flag, should be planned as moved.": {
+			onlyOnDiff: true,
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {
+				oldMgr.On("GetGroup", mock.Anything, "g1").Once().Return(&model.Group{ID: "g1", Path: "manifests/g1"}, nil)
+				newMgr.On("GetGroup", mock.Anything, "g2").Once().Return(&model.Group{ID: "g2", Path: "manifests/g2", Timeout: time.Minute}, nil)
+			},
+			expState: []plan.State{
+				{Resource: model.Resource{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})}, State: plan.ResourceStateMoved, OldGroupID: "g1"},
+			},
+		},
+
+		"Moved resources without changes to a group with different conflict policy, using only diff changes flag, should be planned as moved.": {
+			onlyOnDiff: true,
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {
+				oldMgr.On("GetGroup", mock.Anything, "g1").Once().Return(&model.Group{ID: "g1", Path: "manifests/g1"}, nil)
+				newMgr.On("GetGroup", mock.Anything, "g2").Once().Return(&model.Group{ID: "g2", Path: "manifests/g2", ConflictPolicy: model.ConflictPolicyFail}, nil)
+			},
+			expState: []plan.State{
+				{Resource: model.Resource{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})}, State: plan.ResourceStateMoved, OldGroupID: "g1"},
+			},
+		},
+
+		"Moved resources without changes to a group with different replace on immutable, using only diff changes flag, should be planned as moved.": {
+			onlyOnDiff: true,
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {
+				oldMgr.On("GetGroup", mock.Anything, "g1").Once().Return(&model.Group{ID: "g1", Path: "manifests/g1"}, nil)
+				newMgr.On("GetGroup", mock.Anything, "g2").Once().Return(&model.Group{ID: "g2", Path: "manifests/g2", ReplaceOnImmutable: true}, nil)
+			},
+			expState: []plan.State{
+				{Resource: model.Resource{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})}, State: plan.ResourceStateMoved, OldGroupID: "g1"},
+			},
+		},
+
+		"Moved resources without changes from a group that doesn't exist anymore, using only diff changes flag, should be planned as moved.": {
+			onlyOnDiff: true,
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {
+				oldMgr.On("GetGroup", mock.Anything, "g1").Once().Return(nil, internalerrors.ErrMissing)
+				newMgr.On("GetGroup", mock.Anything, "g2").Once().Return(&model.Group{ID: "g2"}, nil)
+			},
+			expState: []plan.State{
+				{Resource: model.Resource{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})}, State: plan.ResourceStateMoved, OldGroupID: "g1"},
+			},
+		},
+
+		"Having an error getting the groups should fail.": {
+			onlyOnDiff: true,
+			oldRes: []model.Resource{
+				{ID: "test0", GroupID: "g1", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			newRes: []model.Resource{
+				{ID: "test0", GroupID: "g2", K8sObject: newPod("test0", []string{"c1"})},
+			},
+			mock: func(oldMgr, newMgr *storagemock.GroupRepository) {
+				newMgr.On("GetGroup", mock.Anything, "g2").Once().Return(nil, errors.New("whatever"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			oldMgr := &storagemock.GroupRepository{}
+			newMgr := &storagemock.GroupRepository{}
+			test.mock(oldMgr, newMgr)
+
+			// Execute.
+			p, err := plan.NewPlanner(plan.PlannerConfig{
+				OnlyOnDiff:         test.onlyOnDiff,
+				GroupRepository:    newMgr,
+				OldGroupRepository: oldMgr,
+				Logger:             log.Noop,
+			})
+			require.NoError(err)
+			gotState, err := p.Plan(context.TODO(), test.oldRes, test.newRes)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				sortStateList(test.expState)
+				sortStateList(gotState)
+				assert.Equal(test.expState, gotState)
+			}
+			oldMgr.AssertExpectations(t)
+			newMgr.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package plan
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/tracing"
+)
+
+type tracedPlanner struct {
+	tracer trace.Tracer
+	next   Planner
+}
+
+// NewTracedPlanner wraps a planner and traces the planning.
+func NewTracedPlanner(tracer trace.Tracer, next Planner) Planner {
+	return tracedPlanner{tracer: tracer, next: next}
+}
+
+func (t tracedPlanner) Plan(ctx context.Context, old []model.Resource, new []model.Resource) ([]State, error) {
+	ctx, span := t.tracer.Start(ctx, "plan", trace.WithAttributes(
+		attribute.Int("kahoy.old_resources", len(old)),
+		attribute.Int("kahoy.new_resources", len(new)),
+	))
+
+	states, err := t.next.Plan(ctx, old, new)
+	tracing.EndSpan(span, err, attribute.Int("kahoy.planned_resources", len(states)))
+
+	return states, err
+}
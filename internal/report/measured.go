@@ -0,0 +1,34 @@
+package report
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/slok/kahoy/internal/metrics"
+	"github.com/slok/kahoy/internal/model"
+)
+
+type measuredRecorder struct {
+	rec metrics.Recorder
+	Recorder
+}
+
+// NewMeasuredRecorder wraps a recorder and measures the recorded batch and
+// hook executions.
+func NewMeasuredRecorder(rec metrics.Recorder, next Recorder) Recorder {
+	return measuredRecorder{rec: rec, Recorder: next}
+}
+
+func (m measuredRecorder) RecordBatchResult(ctx context.Context, b model.BatchResult) {
+	priority := ""
+	if b.Priority != nil {
+		priority = strconv.Itoa(*b.Priority)
+	}
+	m.rec.ObserveBatch(ctx, b.Operation, priority, b.Error == "", b.Duration)
+	m.Recorder.RecordBatchResult(ctx, b)
+}
+
+func (m measuredRecorder) RecordHookResult(ctx context.Context, h model.HookResult) {
+	m.rec.ObserveHook(ctx, h.GroupID, h.Type, h.Error == "", h.Duration)
+	m.Recorder.RecordHookResult(ctx, h)
+}
@@ -0,0 +1,50 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/notify"
+)
+
+type notifiedRecorder struct {
+	notifier notify.Notifier
+	state    *model.State
+	logger   log.Logger
+	Recorder
+}
+
+// NewNotifiedRecorder wraps a recorder and notifies the recorded batch executions
+// of the execution state. Notification failures are logged, they don't affect
+// the execution.
+func NewNotifiedRecorder(notifier notify.Notifier, state *model.State, logger log.Logger, next Recorder) Recorder {
+	return notifiedRecorder{
+		notifier: notifier,
+		state:    state,
+		logger:   logger,
+		Recorder: next,
+	}
+}
+
+func (n notifiedRecorder) RecordBatchResult(ctx context.Context, b model.BatchResult) {
+	n.Recorder.RecordBatchResult(ctx, b)
+
+	err := n.notifier.Notify(ctx, notify.Event{
+		Type:      notify.EventTypeBatchFinished,
+		RunID:     n.state.ID,
+		Time:      time.Now().UTC(),
+		GitCommit: n.state.GitCommit,
+		Batch: &notify.BatchSummary{
+			Operation:       string(b.Operation),
+			Priority:        b.Priority,
+			Resources:       len(b.Resources),
+			DurationSeconds: b.Duration.Seconds(),
+			Error:           b.Error,
+		},
+	})
+	if err != nil {
+		n.logger.Warningf("could not notify batch result: %s", err)
+	}
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"context"
+	"sync"
+
+	"github.com/slok/kahoy/internal/model"
+)
+
+// Recorder knows how to record the events that happen while Kahoy is executing,
+// so they can be added to the execution report.
+type Recorder interface {
+	RecordResourceReplaced(ctx context.Context, r model.Resource)
+	RecordResourceConflict(ctx context.Context, c model.ResourceConflict)
+	RecordResourceRetry(ctx context.Context, r model.ResourceRetry)
+	RecordResourceResult(ctx context.Context, r model.ResourceResult)
+	RecordDiffChanges(ctx context.Context)
+	RecordBatchResult(ctx context.Context, b model.BatchResult)
+	RecordHookResult(ctx context.Context, h model.HookResult)
+}
+
+//go:generate mockery --case underscore --output reportmock --outpkg reportmock --name Recorder
+
+// Noop recorder doesn't record anything.
+const Noop = noop(0)
+
+type noop int
+
+func (noop) RecordResourceReplaced(ctx context.Context, r model.Resource)         {}
+func (noop) RecordResourceConflict(ctx context.Context, c model.ResourceConflict) {}
+func (noop) RecordResourceRetry(ctx context.Context, r model.ResourceRetry)       {}
+func (noop) RecordResourceResult(ctx context.Context, r model.ResourceResult)     {}
+func (noop) RecordDiffChanges(ctx context.Context)                                {}
+func (noop) RecordBatchResult(ctx context.Context, b model.BatchResult)           {}
+func (noop) RecordHookResult(ctx context.Context, h model.HookResult)             {}
+
+type stateRecorder struct {
+	mu    sync.Mutex
+	state *model.State
+}
+
+// NewStateRecorder returns a recorder that records the events on the received state.
+// The recorder is safe to be used concurrently.
+func NewStateRecorder(state *model.State) Recorder {
+	return &stateRecorder{state: state}
+}
+
+func (s *stateRecorder) RecordResourceReplaced(ctx context.Context, r model.Resource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.ReplacedResources = append(s.state.ReplacedResources, r)
+}
+
+func (s *stateRecorder) RecordResourceConflict(ctx context.Context, c model.ResourceConflict) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Conflicts = append(s.state.Conflicts, c)
+}
+
+func (s *stateRecorder) RecordResourceRetry(ctx context.Context, r model.ResourceRetry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Retries = append(s.state.Retries, r)
+}
+
+func (s *stateRecorder) RecordResourceResult(ctx context.Context, r model.ResourceResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.ResourceResults = append(s.state.ResourceResults, r)
+}
+
+func (s *stateRecorder) RecordDiffChanges(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.DiffChanges = true
+}
+
+func (s *stateRecorder) RecordBatchResult(ctx context.Context, b model.BatchResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Batches = append(s.state.Batches, b)
+}
+
+func (s *stateRecorder) RecordHookResult(ctx context.Context, h model.HookResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.HookResults = append(s.state.HookResults, h)
+}
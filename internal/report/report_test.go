@@ -0,0 +1,114 @@
+package report_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
+)
+
+func TestStateRecorder(t *testing.T) {
+	tests := map[string]struct {
+		record   func(r report.Recorder)
+		expState model.State
+	}{
+		"Not recording anything should not modify the state.": {
+			record:   func(r report.Recorder) {},
+			expState: model.State{ID: "test"},
+		},
+
+		"Recording replaced resources should set them on the state.": {
+			record: func(r report.Recorder) {
+				r.RecordResourceReplaced(context.TODO(), model.Resource{ID: "r1"})
+				r.RecordResourceReplaced(context.TODO(), model.Resource{ID: "r2"})
+			},
+			expState: model.State{
+				ID: "test",
+				ReplacedResources: []model.Resource{
+					{ID: "r1"},
+					{ID: "r2"},
+				},
+			},
+		},
+
+		"Recording conflicts should set them on the state.": {
+			record: func(r report.Recorder) {
+				r.RecordResourceConflict(context.TODO(), model.ResourceConflict{
+					Resource:  model.Resource{ID: "r1"},
+					Policy:    model.ConflictPolicySkipField,
+					Conflicts: []model.FieldConflict{{Manager: "m1", Fields: []string{".spec.replicas"}}},
+				})
+			},
+			expState: model.State{
+				ID: "test",
+				Conflicts: []model.ResourceConflict{
+					{
+						Resource:  model.Resource{ID: "r1"},
+						Policy:    model.ConflictPolicySkipField,
+						Conflicts: []model.FieldConflict{{Manager: "m1", Fields: []string{".spec.replicas"}}},
+					},
+				},
+			},
+		},
+
+		"Recording retries should set them on the state.": {
+			record: func(r report.Recorder) {
+				r.RecordResourceRetry(context.TODO(), model.ResourceRetry{Resource: model.Resource{ID: "r1"}, Operation: model.OperationApply, Attempt: 1, Error: "whatever"})
+				r.RecordResourceRetry(context.TODO(), model.ResourceRetry{Resource: model.Resource{ID: "r1"}, Operation: model.OperationApply, Attempt: 2, Error: "whatever"})
+			},
+			expState: model.State{
+				ID: "test",
+				Retries: []model.ResourceRetry{
+					{Resource: model.Resource{ID: "r1"}, Operation: model.OperationApply, Attempt: 1, Error: "whatever"},
+					{Resource: model.Resource{ID: "r1"}, Operation: model.OperationApply, Attempt: 2, Error: "whatever"},
+				},
+			},
+		},
+
+		"Recording resource results should set them on the state.": {
+			record: func(r report.Recorder) {
+				r.RecordResourceResult(context.TODO(), model.ResourceResult{Resource: model.Resource{ID: "r1"}, Operation: model.OperationApply, Outcome: model.ResourceOutcomeApplied})
+			},
+			expState: model.State{
+				ID: "test",
+				ResourceResults: []model.ResourceResult{
+					{Resource: model.Resource{ID: "r1"}, Operation: model.OperationApply, Outcome: model.ResourceOutcomeApplied},
+				},
+			},
+		},
+
+		"Recording diff changes should set them on the state.": {
+			record: func(r report.Recorder) {
+				r.RecordDiffChanges(context.TODO())
+			},
+			expState: model.State{
+				ID:          "test",
+				DiffChanges: true,
+			},
+		},
+
+		"Recording batch and hook results should set them on the state.": {
+			record: func(r report.Recorder) {
+				r.RecordBatchResult(context.TODO(), model.BatchResult{Operation: model.OperationApply, Resources: []model.Resource{{ID: "r1"}}})
+				r.RecordHookResult(context.TODO(), model.HookResult{GroupID: "g1", Type: "pre", Cmd: "echo", ExitCode: 1, Error: "whatever"})
+			},
+			expState: model.State{
+				ID:          "test",
+				Batches:     []model.BatchResult{{Operation: model.OperationApply, Resources: []model.Resource{{ID: "r1"}}}},
+				HookResults: []model.HookResult{{GroupID: "g1", Type: "pre", Cmd: "echo", ExitCode: 1, Error: "whatever"}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			state := &model.State{ID: "test"}
+			test.record(report.NewStateRecorder(state))
+
+			assert.Equal(t, test.expState, *state)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package report
+
+import (
+	"context"
+
+	"github.com/slok/kahoy/internal/metrics"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/plan"
+)
+
+// NewResourceResults returns the outcome of each executed resource based on the
+// executed batches, the results recorded while executing the resources (e.g: parsed
+// from kubectl output) and the state of the resources before the execution (old).
+//
+// The recorded results take precedence over the batch result, so the resources of a
+// failed batch that have been executed correctly are not marked as failed. If a resource
+// has multiple recorded results (e.g: retries), the last one is used.
+func NewResourceResults(batches []model.BatchResult, old []model.Resource, recorded []model.ResourceResult) []model.ResourceResult {
+	oldIdx := map[string]model.Resource{}
+	for _, r := range old {
+		oldIdx[r.ID] = r
+	}
+
+	recordedIdx := map[model.Operation]map[string]model.ResourceResult{}
+	for _, r := range recorded {
+		if recordedIdx[r.Operation] == nil {
+			recordedIdx[r.Operation] = map[string]model.ResourceResult{}
+		}
+		recordedIdx[r.Operation][r.Resource.ID] = r
+	}
+
+	results := []model.ResourceResult{}
+	for _, b := range batches {
+		for _, r := range b.Resources {
+			result := model.ResourceResult{
+				Resource:  r,
+				Operation: b.Operation,
+			}
+
+			rec, recOK := recordedIdx[b.Operation][r.ID]
+			switch {
+			case recOK && rec.Outcome == model.ResourceOutcomeFailed:
+				result.Outcome = model.ResourceOutcomeFailed
+				result.Error = rec.Error
+			case recOK && rec.Outcome == model.ResourceOutcomeApplied:
+				result.Outcome = applyOutcome(oldIdx, r)
+			case recOK:
+				result.Outcome = rec.Outcome
+			case b.Error != "":
+				result.Outcome = model.ResourceOutcomeFailed
+				result.Error = b.Error
+			case b.Operation == model.OperationDelete:
+				result.Outcome = model.ResourceOutcomeDeleted
+			default:
+				result.Outcome = applyOutcome(oldIdx, r)
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// MeasureResourceResults counts the resources results by operation, group and outcome.
+func MeasureResourceResults(ctx context.Context, rec metrics.Recorder, results []model.ResourceResult) {
+	type key struct {
+		op      model.Operation
+		groupID string
+		outcome model.ResourceOutcome
+	}
+
+	counts := map[key]int{}
+	keys := []key{}
+	for _, r := range results {
+		k := key{op: r.Operation, groupID: r.Resource.GroupID, outcome: r.Outcome}
+		if _, ok := counts[k]; !ok {
+			keys = append(keys, k)
+		}
+		counts[k]++
+	}
+
+	for _, k := range keys {
+		rec.AddOutcomes(ctx, k.op, k.groupID, k.outcome, counts[k])
+	}
+}
+
+func applyOutcome(oldIdx map[string]model.Resource, r model.Resource) model.ResourceOutcome {
+	old, ok := oldIdx[r.ID]
+	if !ok {
+		return model.ResourceOutcomeCreated
+	}
+
+	if old.K8sObject == nil || r.K8sObject == nil {
+		return model.ResourceOutcomeConfigured
+	}
+
+	changes, err := plan.DiffFields(old.K8sObject, r.K8sObject)
+	if err != nil || len(changes) > 0 {
+		return model.ResourceOutcomeConfigured
+	}
+
+	return model.ResourceOutcomeUnchanged
+}
+
+// SucceededResources returns the resources of the results that have been executed
+// correctly with the received operation.
+func SucceededResources(results []model.ResourceResult, op model.Operation) []model.Resource {
+	resources := []model.Resource{}
+	for _, r := range results {
+		if r.Operation == op && r.Outcome != model.ResourceOutcomeFailed {
+			resources = append(resources, r.Resource)
+		}
+	}
+
+	return resources
+}
@@ -0,0 +1,137 @@
+package report_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/slok/kahoy/internal/metrics/metricsmock"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
+)
+
+func newResource(id string, replicas int64) model.Resource {
+	return model.Resource{
+		ID: id,
+		K8sObject: &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"replicas": replicas},
+		}},
+	}
+}
+
+func TestNewResourceResults(t *testing.T) {
+	tests := map[string]struct {
+		batches    []model.BatchResult
+		old        []model.Resource
+		recorded   []model.ResourceResult
+		expResults []model.ResourceResult
+	}{
+		"Not having batches should not have results.": {
+			expResults: []model.ResourceResult{},
+		},
+
+		"Applied resources should have the outcome based on the old resources.": {
+			old: []model.Resource{
+				newResource("r2", 1),
+				newResource("r3", 1),
+			},
+			batches: []model.BatchResult{
+				{Operation: model.OperationApply, Resources: []model.Resource{newResource("r1", 1), newResource("r2", 3)}},
+				{Operation: model.OperationApply, Resources: []model.Resource{newResource("r3", 1)}},
+			},
+			expResults: []model.ResourceResult{
+				{Resource: newResource("r1", 1), Operation: model.OperationApply, Outcome: model.ResourceOutcomeCreated},
+				{Resource: newResource("r2", 3), Operation: model.OperationApply, Outcome: model.ResourceOutcomeConfigured},
+				{Resource: newResource("r3", 1), Operation: model.OperationApply, Outcome: model.ResourceOutcomeUnchanged},
+			},
+		},
+
+		"Deleted resources should have deleted outcome.": {
+			batches: []model.BatchResult{
+				{Operation: model.OperationDelete, Resources: []model.Resource{newResource("r1", 1)}},
+			},
+			expResults: []model.ResourceResult{
+				{Resource: newResource("r1", 1), Operation: model.OperationDelete, Outcome: model.ResourceOutcomeDeleted},
+			},
+		},
+
+		"Resources of failed batches should have failed outcome with the error.": {
+			batches: []model.BatchResult{
+				{Operation: model.OperationApply, Resources: []model.Resource{newResource("r1", 1)}, Error: "whatever"},
+				{Operation: model.OperationDelete, Resources: []model.Resource{newResource("r2", 1)}, Error: "whatever"},
+			},
+			expResults: []model.ResourceResult{
+				{Resource: newResource("r1", 1), Operation: model.OperationApply, Outcome: model.ResourceOutcomeFailed, Error: "whatever"},
+				{Resource: newResource("r2", 1), Operation: model.OperationDelete, Outcome: model.ResourceOutcomeFailed, Error: "whatever"},
+			},
+		},
+
+		"Recorded results should be used instead of the batch result.": {
+			old: []model.Resource{
+				newResource("r2", 1),
+			},
+			batches: []model.BatchResult{
+				{Operation: model.OperationApply, Resources: []model.Resource{newResource("r1", 1), newResource("r2", 3), newResource("r3", 1)}, Error: "whatever"},
+				{Operation: model.OperationDelete, Resources: []model.Resource{newResource("r4", 1), newResource("r5", 1)}},
+			},
+			recorded: []model.ResourceResult{
+				{Resource: newResource("r1", 1), Operation: model.OperationApply, Outcome: model.ResourceOutcomeCreated},
+				{Resource: newResource("r2", 3), Operation: model.OperationApply, Outcome: model.ResourceOutcomeApplied},
+				{Resource: newResource("r3", 1), Operation: model.OperationApply, Outcome: model.ResourceOutcomeFailed, Error: "r3 failed"},
+				{Resource: newResource("r4", 1), Operation: model.OperationDelete, Outcome: model.ResourceOutcomeNotFound},
+			},
+			expResults: []model.ResourceResult{
+				{Resource: newResource("r1", 1), Operation: model.OperationApply, Outcome: model.ResourceOutcomeCreated},
+				{Resource: newResource("r2", 3), Operation: model.OperationApply, Outcome: model.ResourceOutcomeConfigured},
+				{Resource: newResource("r3", 1), Operation: model.OperationApply, Outcome: model.ResourceOutcomeFailed, Error: "r3 failed"},
+				{Resource: newResource("r4", 1), Operation: model.OperationDelete, Outcome: model.ResourceOutcomeNotFound},
+				{Resource: newResource("r5", 1), Operation: model.OperationDelete, Outcome: model.ResourceOutcomeDeleted},
+			},
+		},
+
+		"The last recorded result of a resource should be used.": {
+			batches: []model.BatchResult{
+				{Operation: model.OperationApply, Resources: []model.Resource{newResource("r1", 1)}},
+			},
+			recorded: []model.ResourceResult{
+				{Resource: newResource("r1", 1), Operation: model.OperationApply, Outcome: model.ResourceOutcomeFailed, Error: "whatever"},
+				{Resource: newResource("r1", 1), Operation: model.OperationApply, Outcome: model.ResourceOutcomeCreated},
+			},
+			expResults: []model.ResourceResult{
+				{Resource: newResource("r1", 1), Operation: model.OperationApply, Outcome: model.ResourceOutcomeCreated},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotResults := report.NewResourceResults(test.batches, test.old, test.recorded)
+			assert.Equal(t, test.expResults, gotResults)
+		})
+	}
+}
+
+func TestMeasureResourceResults(t *testing.T) {
+	newGroupResource := func(id, group string) model.Resource { return model.Resource{ID: id, GroupID: group} }
+
+	results := []model.ResourceResult{
+		{Resource: newGroupResource("r1", "g1"), Operation: model.OperationApply, Outcome: model.ResourceOutcomeCreated},
+		{Resource: newGroupResource("r2", "g1"), Operation: model.OperationApply, Outcome: model.ResourceOutcomeCreated},
+		{Resource: newGroupResource("r3", "g1"), Operation: model.OperationApply, Outcome: model.ResourceOutcomeUnchanged},
+		{Resource: newGroupResource("r4", "g2"), Operation: model.OperationApply, Outcome: model.ResourceOutcomeConfigured},
+		{Resource: newGroupResource("r5", "g2"), Operation: model.OperationDelete, Outcome: model.ResourceOutcomeFailed},
+	}
+
+	mr := &metricsmock.Recorder{}
+	mr.On("AddOutcomes", mock.Anything, model.OperationApply, "g1", model.ResourceOutcomeCreated, 2).Once()
+	mr.On("AddOutcomes", mock.Anything, model.OperationApply, "g1", model.ResourceOutcomeUnchanged, 1).Once()
+	mr.On("AddOutcomes", mock.Anything, model.OperationApply, "g2", model.ResourceOutcomeConfigured, 1).Once()
+	mr.On("AddOutcomes", mock.Anything, model.OperationDelete, "g2", model.ResourceOutcomeFailed, 1).Once()
+
+	report.MeasureResourceResults(context.TODO(), mr, results)
+
+	mr.AssertExpectations(t)
+}
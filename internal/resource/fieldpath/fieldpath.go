@@ -0,0 +1,359 @@
+package fieldpath
+
+import (
+	"encoding/json"
+	"fmt"
+	gopath "path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/slok/kahoy/internal/model"
+)
+
+// Remove returns a copy of the Kubernetes object without the fields of the received paths.
+// The paths use the same format as Kubernetes server-side apply field paths, e.g:
+//
+// - `.spec.replicas`
+// - `.spec.template.spec.containers[name="app"].image`
+// - `.spec.ports[port=80,protocol="TCP"]`
+// - `.metadata.finalizers[="kahoy"]`
+// - `.spec.args[0]`
+//
+// Map keys can also be selected using brackets, these support glob patterns (e.g:
+// `.metadata.annotations[some-controller/*]` or `.metadata.labels["app.kubernetes.io/name"]`).
+//
+// The paths that don't exist on the object are ignored.
+func Remove(obj model.K8sObject, paths []string) (model.K8sObject, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj.DeepCopyObject())
+	if err != nil {
+		return nil, fmt.Errorf("could not convert object to unstructured: %w", err)
+	}
+
+	for _, path := range paths {
+		elements, err := Parse(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(elements) == 0 {
+			continue
+		}
+
+		removeElement(content, elements)
+	}
+
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+// Get returns the values of the received decoded JSON data (e.g: the result of `json.Unmarshal`
+// into an `interface{}`) that match the field path. The path can match multiple values when
+// using list selectors or map key glob patterns, the paths that don't exist return no values.
+func Get(data interface{}, path string) ([]interface{}, error) {
+	elements, err := Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return getElement(data, elements), nil
+}
+
+// PathElement is an element of a field path.
+type PathElement struct {
+	// FieldName is set when the element is a map field.
+	FieldName *string
+	// Key is set when the element is a list item identified by its keys.
+	Key map[string]interface{}
+	// Value is set when the element is a list item identified by its value (set).
+	Value *interface{}
+	// Index is set when the element is a list item identified by its position.
+	Index *int
+	// MapKey is set when the element is a map field identified by a glob pattern.
+	MapKey *string
+}
+
+// Parse parses a field path into path elements.
+func Parse(path string) ([]PathElement, error) {
+	path = strings.TrimSpace(path)
+	elements := []PathElement{}
+
+	// Allow paths without the leading dot (e.g: `spec.replicas`).
+	if path != "" && path[0] != '.' && path[0] != '[' {
+		path = "." + path
+	}
+
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			end := i + 1
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			name := path[i+1 : end]
+			if name == "" {
+				return nil, fmt.Errorf("invalid field path %q: empty field name at %d", path, i)
+			}
+			elements = append(elements, PathElement{FieldName: &name})
+			i = end
+
+		case '[':
+			end, err := closingBracket(path, i)
+			if err != nil {
+				return nil, err
+			}
+			element, err := parseBracketElement(path[i+1 : end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid field path %q: %w", path, err)
+			}
+			elements = append(elements, *element)
+			i = end + 1
+
+		default:
+			return nil, fmt.Errorf("invalid field path %q: unexpected character %q at %d", path, path[i], i)
+		}
+	}
+
+	return elements, nil
+}
+
+// closingBracket returns the position of the bracket that closes the one
+// at the received position, ignoring the ones inside quoted strings.
+func closingBracket(path string, start int) (int, error) {
+	quoted := false
+	for i := start + 1; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && quoted:
+			i++
+		case path[i] == '"':
+			quoted = !quoted
+		case path[i] == ']' && !quoted:
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid field path %q: missing closing bracket", path)
+}
+
+func parseBracketElement(s string) (*PathElement, error) {
+	// Value.
+	if strings.HasPrefix(s, "=") {
+		var v interface{}
+		err := json.Unmarshal([]byte(s[1:]), &v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", s[1:], err)
+		}
+		return &PathElement{Value: &v}, nil
+	}
+
+	// Index.
+	if idx, err := strconv.Atoi(s); err == nil {
+		return &PathElement{Index: &idx}, nil
+	}
+
+	// Map key pattern.
+	if !strings.Contains(s, "=") || strings.HasPrefix(s, `"`) {
+		pattern := s
+		if strings.HasPrefix(s, `"`) {
+			err := json.Unmarshal([]byte(s), &pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid map key %q: %w", s, err)
+			}
+		}
+		if _, err := gopath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid map key pattern %q: %w", pattern, err)
+		}
+		return &PathElement{MapKey: &pattern}, nil
+	}
+
+	// Keys.
+	keys := map[string]interface{}{}
+	for _, kv := range splitKeys(s) {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid key %q", kv)
+		}
+
+		var v interface{}
+		err := json.Unmarshal([]byte(parts[1]), &v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key value %q: %w", parts[1], err)
+		}
+		keys[parts[0]] = v
+	}
+
+	return &PathElement{Key: keys}, nil
+}
+
+// splitKeys splits the keys by commas ignoring the ones inside quoted strings.
+func splitKeys(s string) []string {
+	keys := []string{}
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == ',' && !quoted:
+			keys = append(keys, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(keys, s[start:])
+}
+
+// removeElement removes the path elements from the received node, returns the
+// node that should replace the received one (lists can't be mutated in place).
+func removeElement(node interface{}, elements []PathElement) interface{} {
+	el, last := elements[0], len(elements) == 1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if el.MapKey != nil {
+			for k, child := range n {
+				if ok, _ := gopath.Match(*el.MapKey, k); !ok {
+					continue
+				}
+
+				if last {
+					delete(n, k)
+					continue
+				}
+				n[k] = removeElement(child, elements[1:])
+			}
+			return n
+		}
+
+		if el.FieldName == nil {
+			return node
+		}
+
+		child, ok := n[*el.FieldName]
+		if !ok {
+			return node
+		}
+
+		if last {
+			delete(n, *el.FieldName)
+			return n
+		}
+
+		n[*el.FieldName] = removeElement(child, elements[1:])
+		return n
+
+	case []interface{}:
+		if el.FieldName != nil || el.MapKey != nil {
+			return node
+		}
+
+		res := make([]interface{}, 0, len(n))
+		for i, item := range n {
+			if !el.matches(i, item) {
+				res = append(res, item)
+				continue
+			}
+
+			if last {
+				continue
+			}
+
+			res = append(res, removeElement(item, elements[1:]))
+		}
+		return res
+	}
+
+	return node
+}
+
+// getElement returns the values of the node that match the path elements.
+func getElement(node interface{}, elements []PathElement) []interface{} {
+	if len(elements) == 0 {
+		return []interface{}{node}
+	}
+	el := elements[0]
+
+	res := []interface{}{}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if el.MapKey != nil {
+			keys := make([]string, 0, len(n))
+			for k := range n {
+				if ok, _ := gopath.Match(*el.MapKey, k); ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				res = append(res, getElement(n[k], elements[1:])...)
+			}
+			return res
+		}
+
+		if el.FieldName == nil {
+			return res
+		}
+
+		child, ok := n[*el.FieldName]
+		if !ok {
+			return res
+		}
+		return getElement(child, elements[1:])
+
+	case []interface{}:
+		if el.FieldName != nil || el.MapKey != nil {
+			return res
+		}
+
+		for i, item := range n {
+			if el.matches(i, item) {
+				res = append(res, getElement(item, elements[1:])...)
+			}
+		}
+	}
+
+	return res
+}
+
+func (p PathElement) matches(idx int, item interface{}) bool {
+	switch {
+	case p.Index != nil:
+		return *p.Index == idx
+
+	case p.Value != nil:
+		return equalJSON(*p.Value, item)
+
+	case p.Key != nil:
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range p.Key {
+			if !equalJSON(v, m[k]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// equalJSON compares values using their JSON representation, this way we don't
+// need to take care of the different numeric types.
+func equalJSON(a, b interface{}) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return string(ja) == string(jb)
+}
@@ -0,0 +1,259 @@
+package fieldpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/fieldpath"
+)
+
+type tm = map[string]interface{}
+type ts = []interface{}
+
+func newDeployment() model.K8sObject {
+	return &unstructured.Unstructured{
+		Object: tm{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": tm{
+				"name":       "test",
+				"namespace":  "test-ns",
+				"finalizers": ts{"f1", "f2"},
+				"annotations": tm{
+					"some-controller/a":    "a",
+					"some-controller/b":    "b",
+					"app.kubernetes.io/id": "c",
+				},
+			},
+			"spec": tm{
+				"replicas": int64(3),
+				"template": tm{
+					"spec": tm{
+						"containers": ts{
+							tm{
+								"name":  "app",
+								"image": "app:v1",
+								"args":  ts{"--a", "--b"},
+								"ports": ts{
+									tm{"containerPort": int64(80), "protocol": "TCP"},
+									tm{"containerPort": int64(81), "protocol": "TCP"},
+								},
+							},
+							tm{
+								"name":  "sidecar",
+								"image": "sidecar:v1",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tests := map[string]struct {
+		obj    func() model.K8sObject
+		paths  []string
+		expObj func() model.K8sObject
+		expErr bool
+	}{
+		"Not having paths should return the same object.": {
+			obj:    newDeployment,
+			paths:  []string{},
+			expObj: newDeployment,
+		},
+
+		"Removing a field should remove it.": {
+			obj:   newDeployment,
+			paths: []string{".spec.replicas"},
+			expObj: func() model.K8sObject {
+				obj := newDeployment().(*unstructured.Unstructured)
+				delete(obj.Object["spec"].(tm), "replicas")
+				return obj
+			},
+		},
+
+		"Removing a field without the leading dot should remove it.": {
+			obj:   newDeployment,
+			paths: []string{"spec.replicas"},
+			expObj: func() model.K8sObject {
+				obj := newDeployment().(*unstructured.Unstructured)
+				delete(obj.Object["spec"].(tm), "replicas")
+				return obj
+			},
+		},
+
+		"Removing a missing field should ignore it.": {
+			obj:    newDeployment,
+			paths:  []string{".spec.whatever.other", ".spec.replicas.other"},
+			expObj: newDeployment,
+		},
+
+		"Removing a field of a list item identified by key should remove it.": {
+			obj:   newDeployment,
+			paths: []string{`.spec.template.spec.containers[name="sidecar"].image`},
+			expObj: func() model.K8sObject {
+				obj := newDeployment().(*unstructured.Unstructured)
+				containers := obj.Object["spec"].(tm)["template"].(tm)["spec"].(tm)["containers"].(ts)
+				delete(containers[1].(tm), "image")
+				return obj
+			},
+		},
+
+		"Removing a list item identified by multiple keys should remove it.": {
+			obj:   newDeployment,
+			paths: []string{`.spec.template.spec.containers[name="app"].ports[containerPort=81,protocol="TCP"]`},
+			expObj: func() model.K8sObject {
+				obj := newDeployment().(*unstructured.Unstructured)
+				container := obj.Object["spec"].(tm)["template"].(tm)["spec"].(tm)["containers"].(ts)[0].(tm)
+				container["ports"] = ts{tm{"containerPort": int64(80), "protocol": "TCP"}}
+				return obj
+			},
+		},
+
+		"Removing a list item identified by value should remove it.": {
+			obj:   newDeployment,
+			paths: []string{`.metadata.finalizers[="f1"]`},
+			expObj: func() model.K8sObject {
+				obj := newDeployment().(*unstructured.Unstructured)
+				obj.Object["metadata"].(tm)["finalizers"] = ts{"f2"}
+				return obj
+			},
+		},
+
+		"Removing a list item identified by index should remove it.": {
+			obj:   newDeployment,
+			paths: []string{`.spec.template.spec.containers[0].args[1]`},
+			expObj: func() model.K8sObject {
+				obj := newDeployment().(*unstructured.Unstructured)
+				container := obj.Object["spec"].(tm)["template"].(tm)["spec"].(tm)["containers"].(ts)[0].(tm)
+				container["args"] = ts{"--a"}
+				return obj
+			},
+		},
+
+		"Removing multiple fields should remove all of them.": {
+			obj:   newDeployment,
+			paths: []string{".spec.replicas", ".metadata.finalizers"},
+			expObj: func() model.K8sObject {
+				obj := newDeployment().(*unstructured.Unstructured)
+				delete(obj.Object["spec"].(tm), "replicas")
+				delete(obj.Object["metadata"].(tm), "finalizers")
+				return obj
+			},
+		},
+
+		"Removing map keys using a glob pattern should remove the matching ones.": {
+			obj:   newDeployment,
+			paths: []string{`metadata.annotations[some-controller/*]`},
+			expObj: func() model.K8sObject {
+				obj := newDeployment().(*unstructured.Unstructured)
+				obj.Object["metadata"].(tm)["annotations"] = tm{"app.kubernetes.io/id": "c"}
+				return obj
+			},
+		},
+
+		"Removing a quoted map key should remove it.": {
+			obj:   newDeployment,
+			paths: []string{`.metadata.annotations["app.kubernetes.io/id"]`},
+			expObj: func() model.K8sObject {
+				obj := newDeployment().(*unstructured.Unstructured)
+				obj.Object["metadata"].(tm)["annotations"] = tm{"some-controller/a": "a", "some-controller/b": "b"}
+				return obj
+			},
+		},
+
+		"Invalid paths should fail.": {
+			obj:    newDeployment,
+			paths:  []string{`.spec.template.spec.containers[name="app"`},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			obj := test.obj()
+			gotObj, err := fieldpath.Remove(obj, test.paths)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expObj(), gotObj)
+				// Original object should not be mutated.
+				require.Equal(test.obj(), obj)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	data := tm{
+		"status": "ready",
+		"checks": ts{
+			tm{"name": "db", "ok": true},
+			tm{"name": "cache", "ok": false},
+		},
+		"labels": tm{
+			"app.kubernetes.io/name": "test",
+			"app.kubernetes.io/part": "web",
+		},
+	}
+
+	tests := map[string]struct {
+		path      string
+		expValues []interface{}
+		expErr    bool
+	}{
+		"Getting a field should return its value.": {
+			path:      ".status",
+			expValues: []interface{}{"ready"},
+		},
+
+		"Getting a missing field should not return values.": {
+			path:      ".missing.field",
+			expValues: []interface{}{},
+		},
+
+		"Getting a list item field using keys should return its value.": {
+			path:      `.checks[name="cache"].ok`,
+			expValues: []interface{}{false},
+		},
+
+		"Getting a list item field using an index should return its value.": {
+			path:      ".checks[0].name",
+			expValues: []interface{}{"db"},
+		},
+
+		"Getting map keys using glob patterns should return all the values sorted by key.": {
+			path:      ".labels[app.kubernetes.io/*]",
+			expValues: []interface{}{"test", "web"},
+		},
+
+		"Invalid paths should fail.": {
+			path:   ".checks[",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			gotValues, err := fieldpath.Get(data, test.path)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expValues, gotValues)
+			}
+		})
+	}
+}
@@ -3,10 +3,16 @@ package batch
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
 	"github.com/slok/kahoy/internal/resource/manage"
+	"github.com/slok/kahoy/internal/tracing"
 )
 
 type batch struct {
@@ -21,6 +27,8 @@ type batchFunc = func(ctx context.Context, resources []model.Resource) ([]batch,
 // Normally this batch manager is used internally to create different batching managers.
 type batchManager struct {
 	manager         manage.ResourceManager
+	recorder        report.Recorder
+	tracer          trace.Tracer
 	logger          log.Logger
 	applyBatchFunc  batchFunc
 	deleteBatchFunc batchFunc
@@ -28,7 +36,7 @@ type batchManager struct {
 
 func (b batchManager) Apply(ctx context.Context, resources []model.Resource) error {
 	if b.applyBatchFunc == nil {
-		return b.manager.Apply(ctx, resources)
+		return b.execute(ctx, model.OperationApply, batch{Resources: resources}, b.manager.Apply)
 	}
 
 	batches, err := b.applyBatchFunc(ctx, resources)
@@ -49,7 +57,7 @@ func (b batchManager) Apply(ctx context.Context, resources []model.Resource) err
 		}
 
 		logger.Infof("applying batch %d of %d", i+1, totalBatches)
-		err := b.manager.Apply(ctx, batch.Resources)
+		err := b.execute(ctx, model.OperationApply, batch, b.manager.Apply)
 		if err != nil {
 			return fmt.Errorf("could not apply batch correctly: %w", err)
 		}
@@ -60,7 +68,7 @@ func (b batchManager) Apply(ctx context.Context, resources []model.Resource) err
 
 func (b batchManager) Delete(ctx context.Context, resources []model.Resource) error {
 	if b.deleteBatchFunc == nil {
-		return b.manager.Delete(ctx, resources)
+		return b.execute(ctx, model.OperationDelete, batch{Resources: resources}, b.manager.Delete)
 	}
 
 	batches, err := b.deleteBatchFunc(ctx, resources)
@@ -71,7 +79,7 @@ func (b batchManager) Delete(ctx context.Context, resources []model.Resource) er
 	totalBatches := len(batches)
 	for i, batch := range batches {
 		b.logger.WithValues(log.Kv(batch.Metadata)).Infof("deleting batch %d of %d", i+1, totalBatches)
-		err := b.manager.Delete(ctx, batch.Resources)
+		err := b.execute(ctx, model.OperationDelete, batch, b.manager.Delete)
 		if err != nil {
 			return fmt.Errorf("could not delete batch correctly: %w", err)
 		}
@@ -79,3 +87,36 @@ func (b batchManager) Delete(ctx context.Context, resources []model.Resource) er
 
 	return nil
 }
+
+// execute executes the batch and records its result.
+func (b batchManager) execute(ctx context.Context, op model.Operation, bt batch, f func(ctx context.Context, resources []model.Resource) error) error {
+	if len(bt.Resources) == 0 {
+		return f(ctx, bt.Resources)
+	}
+
+	result := model.BatchResult{
+		Operation: op,
+		Resources: bt.Resources,
+		StartedAt: time.Now().UTC(),
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("kahoy.operation", string(op)),
+		attribute.Int("kahoy.resources", len(bt.Resources)),
+	}
+	if priority, ok := bt.Metadata["priority"].(int); ok {
+		result.Priority = &priority
+		attrs = append(attrs, attribute.Int("kahoy.priority", priority))
+	}
+
+	ctx, span := b.tracer.Start(ctx, "batch", trace.WithAttributes(attrs...))
+	err := f(ctx, bt.Resources)
+	tracing.EndSpan(span, err)
+
+	result.Duration = time.Since(result.StartedAt)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	b.recorder.RecordBatchResult(ctx, result)
+
+	return err
+}
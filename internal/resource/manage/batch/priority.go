@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"sort"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
 	"github.com/slok/kahoy/internal/resource/manage"
 	"github.com/slok/kahoy/internal/storage"
+	"github.com/slok/kahoy/internal/tracing"
 )
 
 // PriorityManagerConfig is the configuration of the priority batch manager.
@@ -18,7 +22,11 @@ type PriorityManagerConfig struct {
 	// Manager is the original manager used to apply and delete.
 	Manager         manage.ResourceManager
 	GroupRepository storage.GroupRepository
-	Logger          log.Logger
+	// Recorder will record the result of the executed batches.
+	Recorder report.Recorder
+	// Tracer is used to trace the batch executions.
+	Tracer trace.Tracer
+	Logger log.Logger
 }
 
 func (c *PriorityManagerConfig) defaults() error {
@@ -35,6 +43,14 @@ func (c *PriorityManagerConfig) defaults() error {
 		return fmt.Errorf("group repository is required")
 	}
 
+	if c.Recorder == nil {
+		c.Recorder = report.Noop
+	}
+
+	if c.Tracer == nil {
+		c.Tracer = tracing.Noop
+	}
+
 	return nil
 }
 
@@ -55,6 +71,8 @@ func NewPriorityManager(config PriorityManagerConfig) (manage.ResourceManager, e
 
 	return batchManager{
 		manager:         config.Manager,
+		recorder:        config.Recorder,
+		tracer:          config.Tracer,
 		logger:          config.Logger,
 		applyBatchFunc:  applyBatchFunc,
 		deleteBatchFunc: nil, // Priorities not enabled on deletes.
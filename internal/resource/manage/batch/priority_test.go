@@ -3,13 +3,19 @@ package batch_test
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report/reportmock"
 	"github.com/slok/kahoy/internal/resource/manage/batch"
 	"github.com/slok/kahoy/internal/resource/manage/managemock"
 	"github.com/slok/kahoy/internal/storage/storagemock"
@@ -228,3 +234,95 @@ func TestPriorityManagerDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestPriorityManagerRecordBatches(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Mocks.
+	mrm := &managemock.ResourceManager{}
+	mgr := &storagemock.GroupRepository{}
+	mr := &reportmock.Recorder{}
+
+	mgr.On("GetGroup", mock.Anything, "group1").Return(&model.Group{ID: "group1", Priority: 10}, nil)
+	mgr.On("GetGroup", mock.Anything, "group2").Return(&model.Group{ID: "group2", Priority: 20}, nil)
+	mrm.On("Apply", mock.Anything, []model.Resource{{ID: "resource1", GroupID: "group1"}}).Once().Return(nil)
+	mrm.On("Apply", mock.Anything, []model.Resource{{ID: "resource2", GroupID: "group2"}}).Once().Return(errors.New("whatever"))
+	mrm.On("Delete", mock.Anything, []model.Resource{{ID: "resource3", GroupID: "group1"}}).Once().Return(nil)
+
+	expBatch := func(op model.Operation, priority *int, resource model.Resource, expErr string) interface{} {
+		return mock.MatchedBy(func(b model.BatchResult) bool {
+			return b.Operation == op &&
+				reflect.DeepEqual(priority, b.Priority) &&
+				reflect.DeepEqual([]model.Resource{resource}, b.Resources) &&
+				!b.StartedAt.IsZero() &&
+				b.Error == expErr
+		})
+	}
+	p10, p20 := 10, 20
+	mr.On("RecordBatchResult", mock.Anything, expBatch(model.OperationApply, &p10, model.Resource{ID: "resource1", GroupID: "group1"}, "")).Once()
+	mr.On("RecordBatchResult", mock.Anything, expBatch(model.OperationApply, &p20, model.Resource{ID: "resource2", GroupID: "group2"}, "whatever")).Once()
+	mr.On("RecordBatchResult", mock.Anything, expBatch(model.OperationDelete, nil, model.Resource{ID: "resource3", GroupID: "group1"}, "")).Once()
+
+	// Execute.
+	manager, err := batch.NewPriorityManager(batch.PriorityManagerConfig{
+		Manager:         mrm,
+		GroupRepository: mgr,
+		Recorder:        mr,
+	})
+	require.NoError(err)
+
+	err = manager.Apply(context.TODO(), []model.Resource{
+		{ID: "resource1", GroupID: "group1"},
+		{ID: "resource2", GroupID: "group2"},
+	})
+	assert.Error(err)
+
+	err = manager.Delete(context.TODO(), []model.Resource{
+		{ID: "resource3", GroupID: "group1"},
+	})
+	assert.NoError(err)
+
+	// Check.
+	mrm.AssertExpectations(t)
+	mr.AssertExpectations(t)
+}
+
+func TestPriorityManagerTraceBatches(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Mocks.
+	mrm := &managemock.ResourceManager{}
+	mgr := &storagemock.GroupRepository{}
+
+	mgr.On("GetGroup", mock.Anything, "group1").Return(&model.Group{ID: "group1", Priority: 10}, nil)
+	mgr.On("GetGroup", mock.Anything, "group2").Return(&model.Group{ID: "group2", Priority: 20}, nil)
+	mrm.On("Apply", mock.Anything, []model.Resource{{ID: "resource1", GroupID: "group1"}}).Once().Return(nil)
+	mrm.On("Apply", mock.Anything, []model.Resource{{ID: "resource2", GroupID: "group2"}}).Once().Return(errors.New("whatever"))
+
+	// Execute.
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	manager, err := batch.NewPriorityManager(batch.PriorityManagerConfig{
+		Manager:         mrm,
+		GroupRepository: mgr,
+		Tracer:          tp.Tracer("test"),
+	})
+	require.NoError(err)
+
+	err = manager.Apply(context.TODO(), []model.Resource{
+		{ID: "resource1", GroupID: "group1"},
+		{ID: "resource2", GroupID: "group2"},
+	})
+	assert.Error(err)
+
+	// Check.
+	spans := sr.Ended()
+	require.Len(spans, 2)
+	assert.Equal("batch", spans[0].Name())
+	assert.Contains(spans[0].Attributes(), attribute.Int("kahoy.priority", 10))
+	assert.Equal(codes.Unset, spans[0].Status().Code)
+	assert.Contains(spans[1].Attributes(), attribute.Int("kahoy.priority", 20))
+	assert.Equal(codes.Error, spans[1].Status().Code)
+}
@@ -0,0 +1,244 @@
+package conflict
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/slok/kahoy/internal/internalerrors"
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
+	"github.com/slok/kahoy/internal/resource/fieldpath"
+	"github.com/slok/kahoy/internal/resource/manage"
+	"github.com/slok/kahoy/internal/storage"
+)
+
+// ManagerConfig is the configuration of the conflict resource manager.
+type ManagerConfig struct {
+	// Manager is the manager used to apply without forcing the field ownership conflicts,
+	// it's also used to delete.
+	Manager manage.ResourceManager
+	// ForceManager is the manager used to apply forcing the field ownership conflicts.
+	ForceManager manage.ResourceManager
+	// CheckManager is the manager used to detect the field ownership conflicts of the resources
+	// that will be forced without applying them (e.g: server dry-run without forcing conflicts),
+	// so these conflicts are also reported. If missing, the forced conflicts are not reported.
+	CheckManager    manage.ResourceManager
+	GroupRepository storage.GroupRepository
+	// DefaultPolicy is the conflict policy used when the group doesn't have one.
+	DefaultPolicy model.ConflictPolicy
+	Recorder      report.Recorder
+	Logger        log.Logger
+}
+
+func (c *ManagerConfig) defaults() error {
+	if c.Manager == nil {
+		return fmt.Errorf("manager is required")
+	}
+
+	if c.ForceManager == nil {
+		return fmt.Errorf("force manager is required")
+	}
+
+	if c.GroupRepository == nil {
+		return fmt.Errorf("group repository is required")
+	}
+
+	if c.DefaultPolicy == "" {
+		c.DefaultPolicy = model.ConflictPolicyForce
+	}
+
+	if !c.DefaultPolicy.Valid() {
+		return fmt.Errorf("invalid default conflict policy: %q", c.DefaultPolicy)
+	}
+
+	if c.Recorder == nil {
+		c.Recorder = report.Noop
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "manage.ConflictManager"})
+
+	return nil
+}
+
+type conflictManager struct {
+	manager       manage.ResourceManager
+	forceManager  manage.ResourceManager
+	checkManager  manage.ResourceManager
+	groupRepo     storage.GroupRepository
+	defaultPolicy model.ConflictPolicy
+	recorder      report.Recorder
+	logger        log.Logger
+}
+
+// NewManager returns a resource manager that applies the resources based on the field ownership
+// conflict policy of their groups:
+//
+// - force: Takes the ownership of the conflicting fields.
+// - fail: Fails if the resource has conflicting fields.
+// - skip-field: Applies the resource without the conflicting fields.
+//
+// The conflicts found are logged and recorded on the report, the conflicts of the forced
+// resources are detected using the check manager before forcing them.
+func NewManager(config ManagerConfig) (manage.ResourceManager, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return conflictManager{
+		manager:       config.Manager,
+		forceManager:  config.ForceManager,
+		checkManager:  config.CheckManager,
+		groupRepo:     config.GroupRepository,
+		defaultPolicy: config.DefaultPolicy,
+		recorder:      config.Recorder,
+		logger:        config.Logger,
+	}, nil
+}
+
+func (c conflictManager) Apply(ctx context.Context, resources []model.Resource) error {
+	// Split resources by policy.
+	forceRes := []model.Resource{}
+	noForceRes := []model.Resource{}
+	policies := map[string]model.ConflictPolicy{}
+	for _, res := range resources {
+		group, err := c.groupRepo.GetGroup(ctx, res.GroupID)
+		if err != nil {
+			return fmt.Errorf("could not get group %q: %w", res.GroupID, err)
+		}
+
+		policy := group.ConflictPolicy
+		if policy == "" {
+			policy = c.defaultPolicy
+		}
+
+		if policy == model.ConflictPolicyForce {
+			forceRes = append(forceRes, res)
+			continue
+		}
+		noForceRes = append(noForceRes, res)
+		policies[res.ID] = policy
+	}
+
+	if len(forceRes) > 0 {
+		c.checkForcedConflicts(ctx, forceRes)
+
+		err := c.forceManager.Apply(ctx, forceRes)
+		if err != nil {
+			return manage.WithNotExecutedResources(err, noForceRes)
+		}
+	}
+
+	if len(noForceRes) > 0 {
+		err := c.applyNoForce(ctx, noForceRes, policies)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkForcedConflicts reports the conflicts of the resources that will be forced, the
+// check errors are not fatal, the apply of the resources will fail if there is a problem.
+func (c conflictManager) checkForcedConflicts(ctx context.Context, resources []model.Resource) {
+	if c.checkManager == nil {
+		return
+	}
+
+	err := c.checkManager.Apply(ctx, resources)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, internalerrors.ErrConflict) {
+		c.logger.Warningf("could not check field ownership conflicts: %s", err)
+		return
+	}
+
+	// We don't know which resources have the conflicts, so we check one by one.
+	for _, res := range resources {
+		err := c.checkManager.Apply(ctx, []model.Resource{res})
+		var conflictErr manage.ConflictError
+		if err == nil || !errors.As(err, &conflictErr) {
+			continue
+		}
+		c.reportConflicts(ctx, res, model.ConflictPolicyForce, conflictErr.Conflicts)
+	}
+}
+
+func (c conflictManager) applyNoForce(ctx context.Context, resources []model.Resource, policies map[string]model.ConflictPolicy) error {
+	err := c.manager.Apply(ctx, resources)
+	if err == nil || !errors.Is(err, internalerrors.ErrConflict) {
+		return err
+	}
+
+	// We don't know which resources have the conflicts, so we apply one by one to get
+	// the conflicts of each resource.
+	c.logger.Warningf("field ownership conflicts detected, checking %d resources", len(resources))
+	failed := []string{}
+	for i, res := range resources {
+		err := c.manager.Apply(ctx, []model.Resource{res})
+		if err == nil {
+			continue
+		}
+
+		var conflictErr manage.ConflictError
+		if !errors.As(err, &conflictErr) {
+			return manage.WithNotExecutedResources(err, resources[i+1:])
+		}
+
+		policy := policies[res.ID]
+		c.reportConflicts(ctx, res, policy, conflictErr.Conflicts)
+
+		if policy != model.ConflictPolicySkipField {
+			failed = append(failed, res.ID)
+			continue
+		}
+
+		// Skip the conflicting fields and apply again.
+		fields := []string{}
+		for _, fc := range conflictErr.Conflicts {
+			fields = append(fields, fc.Fields...)
+		}
+		obj, err := fieldpath.Remove(res.K8sObject, fields)
+		if err != nil {
+			return fmt.Errorf("could not remove conflicting fields from %q resource: %w", res.ID, err)
+		}
+		res.K8sObject = obj
+
+		c.logger.WithValues(log.Kv{"resource-id": res.ID, "resource-group-id": res.GroupID}).Infof("applying resource without the conflicting fields")
+		err = c.manager.Apply(ctx, []model.Resource{res})
+		if err != nil {
+			return manage.WithNotExecutedResources(fmt.Errorf("could not apply %q resource without conflicting fields: %w", res.ID, err), resources[i+1:])
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: resources with field ownership conflicts: %s", internalerrors.ErrConflict, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// reportConflicts logs and records the field ownership conflicts of a resource.
+func (c conflictManager) reportConflicts(ctx context.Context, res model.Resource, policy model.ConflictPolicy, conflicts []model.FieldConflict) {
+	logger := c.logger.WithValues(log.Kv{"resource-id": res.ID, "resource-group-id": res.GroupID, "conflict-policy": policy})
+	for _, fc := range conflicts {
+		logger.WithValues(log.Kv{"field-manager": fc.Manager}).Warningf("conflicting fields owned by %q field manager: %s", fc.Manager, strings.Join(fc.Fields, ", "))
+	}
+	c.recorder.RecordResourceConflict(ctx, model.ResourceConflict{
+		Resource:  res,
+		Policy:    policy,
+		Conflicts: conflicts,
+	})
+}
+
+func (c conflictManager) Delete(ctx context.Context, resources []model.Resource) error {
+	return c.manager.Delete(ctx, resources)
+}
@@ -0,0 +1,227 @@
+package conflict_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report/reportmock"
+	"github.com/slok/kahoy/internal/resource/manage"
+	"github.com/slok/kahoy/internal/resource/manage/conflict"
+	"github.com/slok/kahoy/internal/resource/manage/managemock"
+	"github.com/slok/kahoy/internal/storage/storagemock"
+)
+
+func newResource(id, group string, replicas bool) model.Resource {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"image": "test",
+		},
+	}}
+	if replicas {
+		obj.Object["spec"].(map[string]interface{})["replicas"] = int64(3)
+	}
+
+	return model.Resource{ID: id, GroupID: group, K8sObject: obj}
+}
+
+func TestManagerApply(t *testing.T) {
+	errConflict := fmt.Errorf("whatever: %w", manage.ConflictError{
+		Conflicts: []model.FieldConflict{{Manager: "hpa", Fields: []string{".spec.replicas"}}},
+	})
+
+	tests := map[string]struct {
+		defaultPolicy model.ConflictPolicy
+		resources     []model.Resource
+		mock          func(mm, mfm, mcm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mr *reportmock.Recorder)
+		expErr        bool
+	}{
+		"Without policies, it should use the force manager.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", true),
+				newResource("r2", "g1", true),
+			},
+			mock: func(mm, mfm, mcm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1"}, nil)
+				exp := []model.Resource{newResource("r1", "g1", true), newResource("r2", "g1", true)}
+				mcm.On("Apply", mock.Anything, exp).Once().Return(nil)
+				mfm.On("Apply", mock.Anything, exp).Once().Return(nil)
+			},
+		},
+
+		"Having conflicts with force policy should record the conflicts and force them.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", true),
+				newResource("r2", "g1", true),
+			},
+			mock: func(mm, mfm, mcm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1"}, nil)
+
+				exp := []model.Resource{newResource("r1", "g1", true), newResource("r2", "g1", true)}
+				mcm.On("Apply", mock.Anything, exp).Once().Return(errConflict)
+				mcm.On("Apply", mock.Anything, []model.Resource{newResource("r1", "g1", true)}).Once().Return(nil)
+				mcm.On("Apply", mock.Anything, []model.Resource{newResource("r2", "g1", true)}).Once().Return(errConflict)
+				mfm.On("Apply", mock.Anything, exp).Once().Return(nil)
+
+				mr.On("RecordResourceConflict", mock.Anything, model.ResourceConflict{
+					Resource:  newResource("r2", "g1", true),
+					Policy:    model.ConflictPolicyForce,
+					Conflicts: []model.FieldConflict{{Manager: "hpa", Fields: []string{".spec.replicas"}}},
+				}).Once()
+			},
+		},
+
+		"Having an error checking the conflicts with force policy should force them anyway.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", true),
+			},
+			mock: func(mm, mfm, mcm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1"}, nil)
+
+				exp := []model.Resource{newResource("r1", "g1", true)}
+				mcm.On("Apply", mock.Anything, exp).Once().Return(errors.New("whatever"))
+				mfm.On("Apply", mock.Anything, exp).Once().Return(nil)
+			},
+		},
+
+		"Resources should be split by policy.": {
+			defaultPolicy: model.ConflictPolicyFail,
+			resources: []model.Resource{
+				newResource("r1", "g1", true),
+				newResource("r2", "g2", true),
+				newResource("r3", "g3", true),
+			},
+			mock: func(mm, mfm, mcm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1"}, nil)
+				mgr.On("GetGroup", mock.Anything, "g2").Return(&model.Group{ID: "g2", ConflictPolicy: model.ConflictPolicyForce}, nil)
+				mgr.On("GetGroup", mock.Anything, "g3").Return(&model.Group{ID: "g3", ConflictPolicy: model.ConflictPolicySkipField}, nil)
+
+				mcm.On("Apply", mock.Anything, []model.Resource{newResource("r2", "g2", true)}).Once().Return(nil)
+				mfm.On("Apply", mock.Anything, []model.Resource{newResource("r2", "g2", true)}).Once().Return(nil)
+				mm.On("Apply", mock.Anything, []model.Resource{newResource("r1", "g1", true), newResource("r3", "g3", true)}).Once().Return(nil)
+			},
+		},
+
+		"Having conflicts with fail policy should record the conflicts and fail.": {
+			defaultPolicy: model.ConflictPolicyFail,
+			resources: []model.Resource{
+				newResource("r1", "g1", true),
+				newResource("r2", "g1", true),
+			},
+			mock: func(mm, mfm, mcm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1"}, nil)
+
+				mm.On("Apply", mock.Anything, []model.Resource{newResource("r1", "g1", true), newResource("r2", "g1", true)}).Once().Return(errConflict)
+				mm.On("Apply", mock.Anything, []model.Resource{newResource("r1", "g1", true)}).Once().Return(nil)
+				mm.On("Apply", mock.Anything, []model.Resource{newResource("r2", "g1", true)}).Once().Return(errConflict)
+
+				mr.On("RecordResourceConflict", mock.Anything, model.ResourceConflict{
+					Resource:  newResource("r2", "g1", true),
+					Policy:    model.ConflictPolicyFail,
+					Conflicts: []model.FieldConflict{{Manager: "hpa", Fields: []string{".spec.replicas"}}},
+				}).Once()
+			},
+			expErr: true,
+		},
+
+		"Having conflicts with skip field policy should record the conflicts and apply without the conflicting fields.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", true),
+				newResource("r2", "g1", true),
+			},
+			mock: func(mm, mfm, mcm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1", ConflictPolicy: model.ConflictPolicySkipField}, nil)
+
+				mm.On("Apply", mock.Anything, []model.Resource{newResource("r1", "g1", true), newResource("r2", "g1", true)}).Once().Return(errConflict)
+				mm.On("Apply", mock.Anything, []model.Resource{newResource("r1", "g1", true)}).Once().Return(nil)
+				mm.On("Apply", mock.Anything, []model.Resource{newResource("r2", "g1", true)}).Once().Return(errConflict)
+				mm.On("Apply", mock.Anything, []model.Resource{newResource("r2", "g1", false)}).Once().Return(nil)
+
+				mr.On("RecordResourceConflict", mock.Anything, model.ResourceConflict{
+					Resource:  newResource("r2", "g1", true),
+					Policy:    model.ConflictPolicySkipField,
+					Conflicts: []model.FieldConflict{{Manager: "hpa", Fields: []string{".spec.replicas"}}},
+				}).Once()
+			},
+		},
+
+		"Having a regular error without force should fail.": {
+			defaultPolicy: model.ConflictPolicySkipField,
+			resources: []model.Resource{
+				newResource("r1", "g1", true),
+			},
+			mock: func(mm, mfm, mcm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1"}, nil)
+				mm.On("Apply", mock.Anything, mock.Anything).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+
+		"Having an error with force should fail.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", true),
+			},
+			mock: func(mm, mfm, mcm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1"}, nil)
+				mcm.On("Apply", mock.Anything, mock.Anything).Once().Return(nil)
+				mfm.On("Apply", mock.Anything, mock.Anything).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+
+		"Having an error getting the group should fail.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", true),
+			},
+			mock: func(mm, mfm, mcm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(nil, errors.New("whatever"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mm := &managemock.ResourceManager{}
+			mfm := &managemock.ResourceManager{}
+			mcm := &managemock.ResourceManager{}
+			mgr := &storagemock.GroupRepository{}
+			mr := &reportmock.Recorder{}
+			test.mock(mm, mfm, mcm, mgr, mr)
+
+			// Execute.
+			manager, err := conflict.NewManager(conflict.ManagerConfig{
+				Manager:         mm,
+				ForceManager:    mfm,
+				CheckManager:    mcm,
+				GroupRepository: mgr,
+				DefaultPolicy:   test.defaultPolicy,
+				Recorder:        mr,
+			})
+			require.NoError(err)
+			err = manager.Apply(context.TODO(), test.resources)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mm.AssertExpectations(t)
+			mfm.AssertExpectations(t)
+			mcm.AssertExpectations(t)
+			mr.AssertExpectations(t)
+		})
+	}
+}
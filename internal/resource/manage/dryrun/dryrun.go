@@ -16,8 +16,26 @@ import (
 // pfunc is a helper alias to be less vebose on func declarations.
 type pfunc = func(format string, a ...interface{}) string
 
+// ManagerConfig is the configuration of the dry-run manager.
+type ManagerConfig struct {
+	DisableColor bool
+	Out          io.Writer
+	// MovedResources are the resources that have been moved between groups, these
+	// will be shown on the output.
+	MovedResources []model.MovedResource
+}
+
+func (c *ManagerConfig) defaults() error {
+	if c.Out == nil {
+		c.Out = os.Stdout
+	}
+
+	return nil
+}
+
 type dryRunManager struct {
-	out io.Writer
+	out       io.Writer
+	movedFrom map[string]string
 
 	redSprintf        pfunc
 	yellowBoldSprintf pfunc
@@ -27,11 +45,39 @@ type dryRunManager struct {
 	blueSprintf       pfunc
 }
 
+// MovedPrinter prints the resources that have been moved between groups.
+type MovedPrinter interface {
+	PrintMoved(ctx context.Context, resources []model.MovedResource) error
+}
+
+// NoopMovedPrinter doesn't print anything.
+const NoopMovedPrinter = noopMovedPrinter(0)
+
+type noopMovedPrinter int
+
+func (noopMovedPrinter) PrintMoved(context.Context, []model.MovedResource) error { return nil }
+
 // NewManager returns a resource manager that dry runs the changes
 // without the need of an apiserver.
-func NewManager(disableColor bool, out io.Writer) manage.ResourceManager {
-	if out == nil {
-		out = os.Stdout
+func NewManager(config ManagerConfig) (manage.ResourceManager, error) {
+	return newDryRunManager(config)
+}
+
+// NewMovedPrinter returns a printer that prints the moved resources that are not applied
+// (e.g: moved without changes), with the same format as the dry-run manager.
+func NewMovedPrinter(config ManagerConfig) (MovedPrinter, error) {
+	return newDryRunManager(config)
+}
+
+func newDryRunManager(config ManagerConfig) (*dryRunManager, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	movedFrom := map[string]string{}
+	for _, m := range config.MovedResources {
+		movedFrom[m.Resource.ID] = m.OldGroupID
 	}
 
 	redSprintf := fmt.Sprintf
@@ -40,7 +86,7 @@ func NewManager(disableColor bool, out io.Writer) manage.ResourceManager {
 	cyanSprintf := fmt.Sprintf
 	greenSprintf := fmt.Sprintf
 	blueSprintf := fmt.Sprintf
-	if !disableColor {
+	if !config.DisableColor {
 		color.NoColor = false // This is required because Color infers and uses globals, in our case we manage with explicit flag and force this.
 		redSprintf = color.New(color.FgRed).Sprintf
 		yellowBoldSprintf = color.New(color.FgYellow, color.Bold).Sprintf
@@ -50,8 +96,9 @@ func NewManager(disableColor bool, out io.Writer) manage.ResourceManager {
 		blueSprintf = color.New(color.FgBlue).Sprintf
 	}
 
-	return dryRunManager{
-		out: out,
+	return &dryRunManager{
+		out:       config.Out,
+		movedFrom: movedFrom,
 
 		redSprintf:        redSprintf,
 		yellowBoldSprintf: yellowBoldSprintf,
@@ -59,22 +106,34 @@ func NewManager(disableColor bool, out io.Writer) manage.ResourceManager {
 		cyanSprintf:       cyanSprintf,
 		greenSprintf:      greenSprintf,
 		blueSprintf:       blueSprintf,
-	}
+	}, nil
 }
 
 func (d dryRunManager) Apply(ctx context.Context, resources []model.Resource) error {
 	d.sort(resources)
-	d.printTree("Apply", resources, d.greenSprintf)
+	d.printTree("Apply", resources, d.greenSprintf, true)
 	return nil
 }
 
 func (d dryRunManager) Delete(ctx context.Context, resources []model.Resource) error {
 	d.sort(resources)
-	d.printTree("Delete", resources, d.redSprintf)
+	d.printTree("Delete", resources, d.redSprintf, false)
+	return nil
+}
+
+func (d dryRunManager) PrintMoved(ctx context.Context, resources []model.MovedResource) error {
+	rs := make([]model.Resource, 0, len(resources))
+	for _, m := range resources {
+		d.movedFrom[m.Resource.ID] = m.OldGroupID
+		rs = append(rs, m.Resource)
+	}
+
+	d.sort(rs)
+	d.printTree("Move", rs, d.blueSprintf, false)
 	return nil
 }
 
-func (d dryRunManager) printTree(title string, resources []model.Resource, printColor pfunc) {
+func (d dryRunManager) printTree(title string, resources []model.Resource, printColor pfunc, showApplyInfo bool) {
 	if len(resources) == 0 {
 		return
 	}
@@ -112,12 +171,38 @@ func (d dryRunManager) printTree(title string, resources []model.Resource, print
 				joinSymbol = groupSymbol + `   └── `
 			}
 
-			d.printf(joinSymbol + printColor(res.ID) + d.cyanSprintf(" (%s)", res.ManifestPath) + "\n")
+			line := joinSymbol + printColor(res.ID) + d.cyanSprintf(" (%s)", res.ManifestPath)
+			if showApplyInfo {
+				line += d.strategyInfo(res)
+			}
+			line += d.movedInfo(res)
+			d.printf(line + "\n")
 		}
 
 		c++
 	}
-	fmt.Println()
+	d.printf("\n")
+}
+
+// strategyInfo returns the apply strategy information of the resource, only the
+// non default strategies are shown to not add noise to the output.
+func (d dryRunManager) strategyInfo(res model.Resource) string {
+	strategy := res.ApplyStrategy()
+	if strategy == model.ApplyStrategyServerSide {
+		return ""
+	}
+
+	return d.blueSprintf(" [%s]", strategy)
+}
+
+// movedInfo returns the group where the resource was before being moved, if moved.
+func (d dryRunManager) movedInfo(res model.Resource) string {
+	oldGroupID, ok := d.movedFrom[res.ID]
+	if !ok {
+		return ""
+	}
+
+	return d.blueSprintf(" [moved from %s]", oldGroupID)
 }
 
 func (d dryRunManager) printf(format string, a ...interface{}) {
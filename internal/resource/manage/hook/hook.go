@@ -3,19 +3,28 @@ package hook
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/slok/kahoy/internal/internalerrors"
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
 	"github.com/slok/kahoy/internal/resource/manage"
 	"github.com/slok/kahoy/internal/storage"
+	"github.com/slok/kahoy/internal/tracing"
 )
 
 // CmdRunner knows how to run exec.Cmd commands. This can be used to
@@ -51,11 +60,35 @@ func (stdCmdRunner) CombinedOutputPipe(cmd *exec.Cmd) (io.Reader, error) {
 type ManagerConfig struct {
 	Manager         manage.ResourceManager
 	GroupRepository storage.GroupRepository
-	CmdRunner       CmdRunner
-	KubectlCmd      string
-	KubeConfig      string
-	KubeContext     string
-	Logger          log.Logger
+	// OldGroupRepository is the repository of the current groups, used to get the delete
+	// hooks of the deleted resources (e.g: the groups that have been removed), if not
+	// set the GroupRepository will be used.
+	OldGroupRepository storage.GroupRepository
+	CmdRunner          CmdRunner
+	KubectlCmd         string
+	KubeConfig         string
+	KubeContext        string
+	// Recorder will record the result of the executed hooks.
+	Recorder report.Recorder
+	// Tracer is used to trace the hook executions.
+	Tracer trace.Tracer
+	// YAMLEncoder is used to write the group resources manifest that is passed
+	// to the hooks, if not set, the resources will not be passed to the hooks.
+	YAMLEncoder K8sObjectEncoder
+	// OldResources are the current resources, used to know the type of change
+	// of the applied resources that are passed to the hooks.
+	OldResources []model.Resource
+	// JobHooks are the hook Jobs that will be executed on the cluster on the
+	// stages of their groups.
+	JobHooks []model.Resource
+	// JobRunner is used to run the hook Jobs, required if there are hook Jobs.
+	JobRunner JobRunner
+	// Concurrency is the maximum number of hooks executed at the same time,
+	// if 0 there is no limit.
+	Concurrency int
+	// HTTPClient is the client used by the HTTP hooks.
+	HTTPClient *http.Client
+	Logger     log.Logger
 }
 
 func (c *ManagerConfig) defaults() error {
@@ -72,6 +105,10 @@ func (c *ManagerConfig) defaults() error {
 		return fmt.Errorf("group repository is required")
 	}
 
+	if c.OldGroupRepository == nil {
+		c.OldGroupRepository = c.GroupRepository
+	}
+
 	if c.CmdRunner == nil {
 		c.CmdRunner = defaultCmdRunner
 	}
@@ -80,18 +117,40 @@ func (c *ManagerConfig) defaults() error {
 		c.KubectlCmd = "kubectl"
 	}
 
+	if c.Recorder == nil {
+		c.Recorder = report.Noop
+	}
+
+	if c.Tracer == nil {
+		c.Tracer = tracing.Noop
+	}
+
+	if c.HTTPClient == nil {
+		c.HTTPClient = http.DefaultClient
+	}
+
+	if c.Concurrency < 0 {
+		return fmt.Errorf("concurrency can't be negative")
+	}
+
+	if len(c.JobHooks) > 0 && c.JobRunner == nil {
+		return fmt.Errorf("job runner is required when there are hook jobs")
+	}
+
 	return nil
 }
 
 // hookManager knows how to execute cmds before and after resources management.
 type hookManager struct {
-	groupRepo   storage.GroupRepository
-	manager     manage.ResourceManager
-	cmdRunner   CmdRunner
-	kubectlCmd  string
-	kubeConfig  string
-	kubeContext string
-	logger      log.Logger
+	groupRepo    storage.GroupRepository
+	oldGroupRepo storage.GroupRepository
+	manager      manage.ResourceManager
+	hooks        hookFactory
+	yamlEncoder  K8sObjectEncoder
+	oldResources map[string]struct{}
+	jobHooks     map[string][]model.Resource
+	concurrency  int
+	logger       log.Logger
 }
 
 // NewManager returns a manager that knows how to execute hooks `Apply`.
@@ -100,27 +159,49 @@ func NewManager(config ManagerConfig) (manage.ResourceManager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not create wait manager: %w", err)
 	}
+	oldResources := map[string]struct{}{}
+	for _, r := range config.OldResources {
+		oldResources[r.ID] = struct{}{}
+	}
+	jobHooks := map[string][]model.Resource{}
+	for _, r := range config.JobHooks {
+		jobHooks[r.GroupID] = append(jobHooks[r.GroupID], r)
+	}
+
 	return hookManager{
-		groupRepo:   config.GroupRepository,
-		manager:     config.Manager,
-		cmdRunner:   config.CmdRunner,
-		kubectlCmd:  config.KubectlCmd,
-		kubeConfig:  config.KubeConfig,
-		kubeContext: config.KubeContext,
-		logger:      config.Logger,
+		groupRepo:    config.GroupRepository,
+		oldGroupRepo: config.OldGroupRepository,
+		manager:      config.Manager,
+		yamlEncoder:  config.YAMLEncoder,
+		oldResources: oldResources,
+		jobHooks:     jobHooks,
+		concurrency:  config.Concurrency,
+		hooks: hookFactory{
+			jobRunner:   config.JobRunner,
+			cmdRunner:   config.CmdRunner,
+			httpClient:  config.HTTPClient,
+			kubectlCmd:  config.KubectlCmd,
+			kubeConfig:  config.KubeConfig,
+			kubeContext: config.KubeContext,
+			recorder:    config.Recorder,
+			tracer:      config.Tracer,
+			logger:      config.Logger,
+		},
+		logger: config.Logger,
 	}, err
 }
 
 // Apply will apply and then wait using the most important waiting policy of all
 // the applied resources.
 func (h hookManager) Apply(ctx context.Context, resources []model.Resource) error {
-	preHooks, postHooks, err := h.createHooks(ctx, resources)
+	preHooks, postHooks, cleanup, err := h.createHooks(ctx, h.groupRepo, resources, hookPreType, hookPostType, h.applyChangeType)
 	if err != nil {
 		return fmt.Errorf("could not create group hooks: %w", err)
 	}
+	defer cleanup()
 
 	// Pre hooks.
-	err = h.executeHooks(ctx, preHooks)
+	err = executeHooks(ctx, preHooks, h.concurrency)
 	if err != nil {
 		return fmt.Errorf("pre hooks error: %w", err)
 	}
@@ -132,7 +213,7 @@ func (h hookManager) Apply(ctx context.Context, resources []model.Resource) erro
 	}
 
 	// Post hooks.
-	err = h.executeHooks(ctx, postHooks)
+	err = executeHooks(ctx, postHooks, h.concurrency)
 	if err != nil {
 		return fmt.Errorf("post hooks error: %w", err)
 	}
@@ -140,87 +221,313 @@ func (h hookManager) Apply(ctx context.Context, resources []model.Resource) erro
 	return nil
 }
 
-// Delete is NOOP on hooks.
+// Delete will execute the pre delete hooks, delete and then execute the post delete hooks
+// of the deleted resources groups.
 func (h hookManager) Delete(ctx context.Context, resources []model.Resource) error {
-	return h.manager.Delete(ctx, resources)
+	// The deleted resources hooks are the ones of the groups they belong to (that could
+	// have been removed), not the new ones.
+	preHooks, postHooks, cleanup, err := h.createHooks(ctx, h.oldGroupRepo, resources, hookPreDeleteType, hookPostDeleteType, deleteChangeType)
+	if err != nil {
+		return fmt.Errorf("could not create group hooks: %w", err)
+	}
+	defer cleanup()
+
+	// Pre delete hooks.
+	err = executeHooks(ctx, preHooks, h.concurrency)
+	if err != nil {
+		return fmt.Errorf("pre delete hooks error: %w", err)
+	}
+
+	// Delete.
+	err = h.manager.Delete(ctx, resources)
+	if err != nil {
+		return err
+	}
+
+	// Post delete hooks.
+	err = executeHooks(ctx, postHooks, h.concurrency)
+	if err != nil {
+		return fmt.Errorf("post delete hooks error: %w", err)
+	}
+
+	return nil
 }
 
-// createHooks will create the hooks from the resource groups.
-func (h hookManager) createHooks(ctx context.Context, resources []model.Resource) (pre []hook, post []hook, err error) {
-	var preHooks, postHooks []hook
+func (h hookManager) applyChangeType(r model.Resource) string {
+	if _, ok := h.oldResources[r.ID]; ok {
+		return resourceChangeUpdate
+	}
+	return resourceChangeCreate
+}
+
+func deleteChangeType(model.Resource) string { return resourceChangeDelete }
+
+// createHooks will create the hooks of the received types from the resource groups of the
+// group repository. The returned cleanup function removes the group resources files passed
+// to the hooks.
+func (h hookManager) createHooks(ctx context.Context, groupRepo storage.GroupRepository, resources []model.Resource, preType, postType string, changeType func(model.Resource) string) (pre []orderedHook, post []orderedHook, cleanup func(), err error) {
+	var preHooks, postHooks []orderedHook
+	cleanups := []func(){}
+	cleanup = func() {
+		for _, c := range cleanups {
+			c()
+		}
+	}
 
 	groups := map[string]*model.Group{}
+	groupResources := map[string][]model.Resource{}
 	for _, r := range resources {
-		group, err := h.groupRepo.GetGroup(ctx, r.GroupID)
+		// Groups could be missing (e.g: the repository doesn't have groups), in that
+		// case the group doesn't have hooks.
+		group, err := groupRepo.GetGroup(ctx, r.GroupID)
 		if err != nil {
-			return nil, nil, fmt.Errorf("could not get group %q: %w", r.GroupID, err)
+			if !errors.Is(err, internalerrors.ErrMissing) {
+				return nil, nil, nil, fmt.Errorf("could not get group %q: %w", r.GroupID, err)
+			}
+			group = &model.Group{ID: r.GroupID}
 		}
 		groups[group.ID] = group
+		groupResources[group.ID] = append(groupResources[group.ID], r)
 	}
 
 	for _, group := range groups {
-		if group.Hooks.Pre != nil {
-			preHooks = append(preHooks, h.createHook(group, group.Hooks.Pre, hookPreType))
+		// Job hooks.
+		for _, r := range h.jobHooks[group.ID] {
+			switch r.HookType() {
+			case jobHookType(preType):
+				preHooks = append(preHooks, newOrderedHook(group, h.hooks.createJobHook(group.ID, r, preType)))
+			case jobHookType(postType):
+				postHooks = append(postHooks, newOrderedHook(group, h.hooks.createJobHook(group.ID, r, postType)))
+			}
+		}
+
+		// Command hooks.
+		preSpec := groupHookSpec(group, preType)
+		postSpec := groupHookSpec(group, postType)
+		if preSpec == nil && postSpec == nil {
+			continue
+		}
+
+		// Pass the group resources to the hooks.
+		hookResources := newHookResources(groupResources[group.ID], changeType)
+		var env []string
+		if h.yamlEncoder != nil {
+			var c func()
+			env, c, err = writeHookResources(ctx, h.yamlEncoder, groupResources[group.ID], changeType)
+			if err != nil {
+				cleanup()
+				return nil, nil, nil, fmt.Errorf("could not write group %q hook resources: %w", group.ID, err)
+			}
+			cleanups = append(cleanups, c)
+		}
+
+		if preSpec != nil {
+			preHooks = append(preHooks, newOrderedHook(group, h.hooks.createHook(group.ID, preSpec, preType, hookResources, env...)))
+		}
+		if postSpec != nil {
+			postHooks = append(postHooks, newOrderedHook(group, h.hooks.createHook(group.ID, postSpec, postType, hookResources, env...)))
+		}
+	}
+
+	return preHooks, postHooks, cleanup, nil
+}
+
+// orderedHook is a hook with the execution order of its group.
+type orderedHook struct {
+	order   int
+	groupID string
+	run     hook
+}
+
+func newOrderedHook(group *model.Group, h hook) orderedHook {
+	return orderedHook{
+		order:   group.Hooks.Order,
+		groupID: group.ID,
+		run:     h,
+	}
+}
+
+// executeHooks executes the hooks by order, the hooks with the same order are executed concurrently
+// up to the concurrency limit (0 means no limit). The execution stops on the first failure.
+func executeHooks(ctx context.Context, hooks []orderedHook, concurrency int) error {
+	// Sort by group ID also, so the execution is deterministic.
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].order != hooks[j].order {
+			return hooks[i].order < hooks[j].order
 		}
-		if group.Hooks.Post != nil {
-			postHooks = append(postHooks, h.createHook(group, group.Hooks.Post, hookPostType))
+		return hooks[i].groupID < hooks[j].groupID
+	})
+
+	for start := 0; start < len(hooks); {
+		end := start
+		for end < len(hooks) && hooks[end].order == hooks[start].order {
+			end++
+		}
+
+		err := executeConcurrentHooks(ctx, hooks[start:end], concurrency)
+		if err != nil {
+			return fmt.Errorf("hooks execution stopped due to failure: %w", err)
 		}
+		start = end
 	}
 
-	return preHooks, postHooks, nil
+	return nil
 }
 
-func (h hookManager) executeHooks(ctx context.Context, hooks []hook) error {
-	g, ctx := errgroup.WithContext(ctx)
+// executeConcurrentHooks executes the hooks concurrently, stopping all of them on the first failure.
+func executeConcurrentHooks(ctx context.Context, hooks []orderedHook, concurrency int) error {
+	var sem chan struct{}
+	if concurrency > 0 {
+		sem = make(chan struct{}, concurrency)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+launch:
 	for _, hook := range hooks {
 		hook := hook
+		if sem != nil {
+			select {
+			case sem <- struct{}{}:
+			case <-gctx.Done():
+				break launch
+			}
+		}
+
 		g.Go(func() error {
-			return hook(ctx)
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			return hook.run(gctx)
 		})
 	}
 
 	err := g.Wait()
 	if err != nil {
-		return fmt.Errorf("hooks execution stopped due to failure: %w", err)
+		return err
 	}
 
-	return nil
-
+	return ctx.Err()
 }
 
 const (
-	hookPreType  = "pre"
-	hookPostType = "post"
+	hookPreType        = "pre"
+	hookPostType       = "post"
+	hookPreDeleteType  = "preDelete"
+	hookPostDeleteType = "postDelete"
 )
 
+func groupHookSpec(group *model.Group, hookType string) *model.GroupHookSpec {
+	switch hookType {
+	case hookPreType:
+		return group.Hooks.Pre
+	case hookPostType:
+		return group.Hooks.Post
+	case hookPreDeleteType:
+		return group.Hooks.PreDelete
+	case hookPostDeleteType:
+		return group.Hooks.PostDelete
+	default:
+		return nil
+	}
+}
+
 var (
 	cmdWhitespaceRegex = regexp.MustCompile(`\s+`)
 	noopHook           = func(ctx context.Context) error { return nil }
 )
 
-func (h hookManager) createHook(group *model.Group, config *model.GroupHookSpec, hookType string) hook {
-	if config == nil || len(config.Cmd) == 0 {
+// hookFactory knows how to create hooks that execute commands, HTTP requests or Jobs.
+type hookFactory struct {
+	jobRunner   JobRunner
+	cmdRunner   CmdRunner
+	httpClient  *http.Client
+	kubectlCmd  string
+	kubeConfig  string
+	kubeContext string
+	recorder    report.Recorder
+	tracer      trace.Tracer
+	logger      log.Logger
+}
+
+// createHook creates a hook that executes the hook command or HTTP request, the group ID
+// is empty on the hooks that don't belong to a group. The received resources are used on
+// the HTTP hooks body, and the received env vars are passed to the command in addition to
+// the common ones.
+func (h hookFactory) createHook(groupID string, config *model.GroupHookSpec, hookType string, resources []hookResource, env ...string) hook {
+	if config == nil {
 		return noopHook
 	}
 
-	// Prepare command string.
-	// TODO(slok): Should we sanitize this on configuration load?.
-	sanitizedCmd := cmdWhitespaceRegex.ReplaceAllString(config.Cmd, " ")
-	sanitizedCmd = strings.Trim(sanitizedCmd, " ")
-	splitCmd := strings.Split(sanitizedCmd, " ")
-	if len(splitCmd) < 1 {
-		h.logger.Errorf("%q is an empty hook command, ignoring", config.Cmd)
+	if config.HTTP != nil {
+		return h.createHTTPHook(groupID, config, hookType, resources)
+	}
+
+	args, err := hookArgs(config)
+	if err != nil {
+		h.logger.Errorf("%s, ignoring", err)
 		return noopHook
 	}
+	cmdStr := config.Cmd
+	if cmdStr == "" {
+		cmdStr = strings.Join(config.Args, " ")
+	}
+
+	// Set the configured env vars in a deterministic order.
+	envKeys := make([]string, 0, len(config.Env))
+	for k := range config.Env {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		env = append(env, fmt.Sprintf("%s=%s", k, config.Env[k]))
+	}
 
 	logger := h.logger.WithValues(log.Kv{
-		"group":     group.ID,
+		"group":     groupID,
 		"hook-type": hookType,
-		"hook-cmd":  splitCmd[0],
+		"hook-cmd":  args[0],
+	})
+
+	return h.newHook(groupID, config, hookType, cmdStr, logger, func(ctx context.Context, output log.Logger) error {
+		return h.runCmd(ctx, output, args, config.Workdir, hookType, groupID, env)
 	})
+}
 
-	return func(ctx context.Context) error {
-		logger.Infof("executing group hook")
+// newHook returns a hook that runs the received hook attempts handling the timeout, the retries,
+// the tracing and the result recording. The attempts output is sent to the received output logger.
+func (h hookFactory) newHook(groupID string, config *model.GroupHookSpec, hookType, cmdStr string, logger log.Logger, run func(ctx context.Context, output log.Logger) error) hook {
+	return func(ctx context.Context) (err error) {
+		logger.Infof("executing hook")
+
+		ctx, span := h.tracer.Start(ctx, "hook", trace.WithAttributes(
+			attribute.String("kahoy.group", groupID),
+			attribute.String("kahoy.hook_type", hookType),
+			attribute.String("kahoy.cmd", cmdStr),
+		))
+
+		// Record the hook result.
+		result := model.HookResult{
+			GroupID:   groupID,
+			Type:      hookType,
+			Cmd:       cmdStr,
+			StartedAt: time.Now().UTC(),
+			ExitCode:  -1,
+		}
+		defer func() {
+			result.Duration = time.Since(result.StartedAt)
+			if err != nil {
+				result.Error = err.Error()
+			}
+			var exitErr *exec.ExitError
+			switch {
+			case err == nil:
+				result.ExitCode = 0
+			case errors.As(err, &exitErr):
+				result.ExitCode = exitErr.ExitCode()
+			}
+			h.recorder.RecordHookResult(ctx, result)
+			tracing.EndSpan(span, err, attribute.Int("kahoy.exit_code", result.ExitCode))
+		}()
 
 		// Add timeout.
 		if config.Timeout > 0 {
@@ -229,44 +536,26 @@ func (h hookManager) createHook(group *model.Group, config *model.GroupHookSpec,
 			ctx = ctxTimeout
 		}
 
-		// Prepare command.
-		cmd := exec.CommandContext(ctx, splitCmd[0], splitCmd[1:]...)
-		out, err := h.cmdRunner.CombinedOutputPipe(cmd)
-		if err != nil {
-			return err
+		// Execute with retries.
+		backoff := config.RetryBackoff
+		if backoff <= 0 {
+			backoff = defaultHookRetryBackoff
 		}
-
-		// Set correct env for the commands.
-		cmd.Env = append(cmd.Env, os.Environ()...)
-		cmd.Env = append(cmd.Env,
-			fmt.Sprintf("KAHOY_KUBECTL_CMD=%s", h.kubectlCmd),
-			fmt.Sprintf("KAHOY_KUBE_CONFIG=%s", h.kubeConfig),
-			fmt.Sprintf("KAHOY_KUBE_CONTEXT=%s", h.kubeContext),
-			fmt.Sprintf("KAHOY_HOOK_TYPE=%s", hookType),
-			fmt.Sprintf("KAHOY_HOOK_GROUP=%s", group.ID),
-		)
-
-		// Execute.
-		err = h.cmdRunner.Start(cmd)
-		if err != nil {
-			return fmt.Errorf("could not start hook: %w", err)
-		}
-
-		// Log in background.
-		go func() {
-			outStream := bufio.NewScanner(out)
-			for outStream.Scan() {
-				select {
-				case <-ctx.Done():
-					break
-				default:
-				}
-				logger.Infof(outStream.Text())
+		for attempt := 0; ; attempt++ {
+			output := newOutputLogger(logger)
+			err = run(ctx, output)
+			output.Flush()
+			if err == nil || attempt >= config.Retries || ctx.Err() != nil {
+				break
 			}
-		}()
 
-		// Wait until the command is done.
-		err = h.cmdRunner.Wait(cmd)
+			logger.Warningf("hook failed, retrying in %s: %s", backoff, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
 		if err != nil {
 			if ctx.Err() == context.DeadlineExceeded {
 				return fmt.Errorf("timeout: %w", err)
@@ -278,4 +567,78 @@ func (h hookManager) createHook(group *model.Group, config *model.GroupHookSpec,
 	}
 }
 
+const defaultHookRetryBackoff = time.Second
+
+// hookArgs returns the command and arguments that will be executed by the hook.
+func hookArgs(config *model.GroupHookSpec) ([]string, error) {
+	if len(config.Args) > 0 {
+		return config.Args, nil
+	}
+
+	// Shell mode, let the shell parse the command.
+	if config.Shell {
+		if strings.TrimSpace(config.Cmd) == "" {
+			return nil, fmt.Errorf("%q is an empty hook command", config.Cmd)
+		}
+		return []string{"sh", "-c", config.Cmd}, nil
+	}
+
+	// Prepare command string.
+	// TODO(slok): Should we sanitize this on configuration load?.
+	sanitizedCmd := cmdWhitespaceRegex.ReplaceAllString(config.Cmd, " ")
+	sanitizedCmd = strings.Trim(sanitizedCmd, " ")
+	if sanitizedCmd == "" {
+		return nil, fmt.Errorf("%q is an empty hook command", config.Cmd)
+	}
+
+	return strings.Split(sanitizedCmd, " "), nil
+}
+
+// runCmd executes a single attempt of the hook command, the command output is sent
+// to the output logger.
+func (h hookFactory) runCmd(ctx context.Context, output log.Logger, args []string, workdir, hookType, groupID string, env []string) error {
+	// Prepare command, relative command paths are resolved against the working directory.
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = workdir
+	out, err := h.cmdRunner.CombinedOutputPipe(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Set correct env for the commands.
+	cmd.Env = append(cmd.Env, os.Environ()...)
+	cmd.Env = append(cmd.Env,
+		fmt.Sprintf("KAHOY_KUBECTL_CMD=%s", h.kubectlCmd),
+		fmt.Sprintf("KAHOY_KUBE_CONFIG=%s", h.kubeConfig),
+		fmt.Sprintf("KAHOY_KUBE_CONTEXT=%s", h.kubeContext),
+		fmt.Sprintf("KAHOY_HOOK_TYPE=%s", hookType),
+		fmt.Sprintf("KAHOY_HOOK_GROUP=%s", groupID),
+	)
+	cmd.Env = append(cmd.Env, env...)
+	// Propagate the trace context to the hook.
+	cmd.Env = append(cmd.Env, tracing.EnvVars(ctx)...)
+
+	// Execute.
+	err = h.cmdRunner.Start(cmd)
+	if err != nil {
+		return fmt.Errorf("could not start hook: %w", err)
+	}
+
+	// Log in background.
+	outDone := make(chan struct{})
+	go func() {
+		defer close(outDone)
+		outStream := bufio.NewScanner(out)
+		for outStream.Scan() {
+			output.Infof("%s", outStream.Text())
+		}
+	}()
+
+	// Wait until the command is done.
+	err = h.cmdRunner.Wait(cmd)
+	<-outDone
+
+	return err
+}
+
 type hook func(ctx context.Context) error
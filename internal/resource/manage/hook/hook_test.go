@@ -4,15 +4,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"github.com/slok/kahoy/internal/internalerrors"
+	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report/reportmock"
 	"github.com/slok/kahoy/internal/resource/manage/hook"
 	"github.com/slok/kahoy/internal/resource/manage/hook/hookmock"
 	"github.com/slok/kahoy/internal/resource/manage/managemock"
@@ -123,6 +135,151 @@ func TestManagerApply(t *testing.T) {
 			},
 		},
 
+		"Having a hook with args, should execute the args without splitting them.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{Args: []string{"cmd1", "--msg", "hello  world"}}}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+
+				// Pre hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd := expCmdMatcher([]string{"cmd1", "--msg", "hello  world"})
+				mcr.On("Start", mock.MatchedBy(expCmd)).Once().Return(nil)
+				mcr.On("Wait", mock.MatchedBy(expCmd)).Once().Return(nil)
+
+				expResources := []model.Resource{{ID: "resource1", GroupID: "group1"}}
+				mrm.On("Apply", mock.Anything, expResources).Once().Return(nil)
+			},
+		},
+
+		"Having a hook in shell mode, should execute the cmd with a shell.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{Cmd: "cmd1 | cmd2 'a b'", Shell: true}}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+
+				// Pre hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd := expCmdMatcher([]string{"sh", "-c", "cmd1 | cmd2 'a b'"})
+				mcr.On("Start", mock.MatchedBy(expCmd)).Once().Return(nil)
+				mcr.On("Wait", mock.MatchedBy(expCmd)).Once().Return(nil)
+
+				expResources := []model.Resource{{ID: "resource1", GroupID: "group1"}}
+				mrm.On("Apply", mock.Anything, expResources).Once().Return(nil)
+			},
+		},
+
+		"Having a hook with env and workdir, should execute the cmd with them.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{
+					Cmd:     "cmd1",
+					Env:     map[string]string{"K1": "V1", "K2": "V2"},
+					Workdir: "/tmp",
+				}}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+
+				// Pre hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd := mock.MatchedBy(func(cmd *exec.Cmd) bool {
+					return expCmdMatcher([]string{"cmd1"})(cmd) && cmd.Dir == "/tmp" && expEnvMatcher([]string{"K1=V1", "K2=V2"})(cmd)
+				})
+				mcr.On("Start", expCmd).Once().Return(nil)
+				mcr.On("Wait", expCmd).Once().Return(nil)
+
+				expResources := []model.Resource{{ID: "resource1", GroupID: "group1"}}
+				mrm.On("Apply", mock.Anything, expResources).Once().Return(nil)
+			},
+		},
+
+		"Having a hook with a relative command path without workdir, should execute the cmd relative to the Kahoy execution dir.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Path: "manifests/group1", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{
+					Cmd: "./scripts/wait.sh",
+				}}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+
+				// Pre hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd := mock.MatchedBy(func(cmd *exec.Cmd) bool {
+					return cmd.Path == "./scripts/wait.sh" && cmd.Dir == ""
+				})
+				mcr.On("Start", expCmd).Once().Return(nil)
+				mcr.On("Wait", expCmd).Once().Return(nil)
+
+				expResources := []model.Resource{{ID: "resource1", GroupID: "group1"}}
+				mrm.On("Apply", mock.Anything, expResources).Once().Return(nil)
+			},
+		},
+
+		"Having a hook with a relative command path, should execute the cmd relative to the workdir.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{
+					Cmd:     "./scripts/check.sh",
+					Workdir: "manifests/group1",
+				}}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+
+				// Pre hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd := mock.MatchedBy(func(cmd *exec.Cmd) bool {
+					return cmd.Path == "./scripts/check.sh" && cmd.Dir == "manifests/group1"
+				})
+				mcr.On("Start", expCmd).Once().Return(nil)
+				mcr.On("Wait", expCmd).Once().Return(nil)
+
+				expResources := []model.Resource{{ID: "resource1", GroupID: "group1"}}
+				mrm.On("Apply", mock.Anything, expResources).Once().Return(nil)
+			},
+		},
+
+		"Having a hook with retries, should retry the failed executions.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{Cmd: "cmd1", Retries: 2, RetryBackoff: time.Millisecond}}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+
+				// Pre hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Times(3).Return(nopR, nil)
+				mcr.On("Start", mock.Anything).Times(3).Return(nil)
+				mcr.On("Wait", mock.Anything).Times(2).Return(errors.New("whatever"))
+				mcr.On("Wait", mock.Anything).Once().Return(nil)
+
+				expResources := []model.Resource{{ID: "resource1", GroupID: "group1"}}
+				mrm.On("Apply", mock.Anything, expResources).Once().Return(nil)
+			},
+		},
+
+		"Having a hook that fails after all the retries, should fail.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{Cmd: "cmd1", Retries: 1, RetryBackoff: time.Millisecond}}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+
+				// Pre hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Times(2).Return(nopR, nil)
+				mcr.On("Start", mock.Anything).Times(2).Return(nil)
+				mcr.On("Wait", mock.Anything).Times(2).Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+
 		"If any of the resources has a post hook, they should execute.": {
 			resources: []model.Resource{
 				{ID: "resource1", GroupID: "group1"},
@@ -298,36 +455,159 @@ func TestManagerApply(t *testing.T) {
 
 func TestManagerDelete(t *testing.T) {
 	tests := map[string]struct {
-		resources []model.Resource
-		mock      func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner)
-		expErr    bool
+		resources    []model.Resource
+		mock         func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner)
+		mockOldGroup func(mogr *storagemock.GroupRepository)
+		expErr       bool
 	}{
 		"If delete has an error, it should fail.": {
 			resources: []model.Resource{
 				{ID: "resource1", GroupID: "group1"},
 			},
 			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(&model.Group{ID: "group1"}, nil)
 				mrm.On("Delete", mock.Anything, mock.Anything).Once().Return(errors.New("whatever"))
 			},
 			expErr: true,
 		},
 
-		"Delete should not execute hooks.": {
+		"Delete should not execute apply hooks.": {
 			resources: []model.Resource{
 				{ID: "resource1", GroupID: "group1"},
 				{ID: "resource2", GroupID: "group2"},
-				{ID: "resource3", GroupID: "group3"},
 			},
 			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{
+					Pre:  &model.GroupHookSpec{Cmd: "cmd1 prehook"},
+					Post: &model.GroupHookSpec{Cmd: "cmd2 posthook"},
+				}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+				mgr.On("GetGroup", mock.Anything, "group2").Once().Return(&model.Group{ID: "group2"}, nil)
+
 				expResources := []model.Resource{
 					{ID: "resource1", GroupID: "group1"},
 					{ID: "resource2", GroupID: "group2"},
-					{ID: "resource3", GroupID: "group3"},
 				}
 				mrm.On("Delete", mock.Anything, expResources).Once().Return(nil)
 			},
 			expErr: false,
 		},
+
+		"Deleting resources of a removed group should delete them without hooks.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "removed"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				mgr.On("GetGroup", mock.Anything, "removed").Once().Return(nil, fmt.Errorf("group is missing: %w", internalerrors.ErrMissing))
+
+				expResources := []model.Resource{{ID: "resource1", GroupID: "removed"}}
+				mrm.On("Delete", mock.Anything, expResources).Once().Return(nil)
+			},
+		},
+
+		"Deleting resources of a removed group should execute the delete hooks of the old group.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "removed"},
+			},
+			mockOldGroup: func(mogr *storagemock.GroupRepository) {
+				removed := &model.Group{ID: "removed", Hooks: model.GroupHooks{
+					PreDelete:  &model.GroupHookSpec{Cmd: "cmd1 predeletehook"},
+					PostDelete: &model.GroupHookSpec{Cmd: "cmd2 postdeletehook"},
+				}}
+				mogr.On("GetGroup", mock.Anything, "removed").Once().Return(removed, nil)
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				// Pre delete hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd := expCmdMatcher([]string{"cmd1", "predeletehook"})
+				mcr.On("Start", mock.MatchedBy(expCmd)).Once().Return(nil)
+				mcr.On("Wait", mock.MatchedBy(expCmd)).Once().Return(nil)
+
+				expResources := []model.Resource{{ID: "resource1", GroupID: "removed"}}
+				mrm.On("Delete", mock.Anything, expResources).Once().Return(nil)
+
+				// Post delete hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd = expCmdMatcher([]string{"cmd2", "postdeletehook"})
+				mcr.On("Start", mock.MatchedBy(expCmd)).Once().Return(nil)
+				mcr.On("Wait", mock.MatchedBy(expCmd)).Once().Return(nil)
+			},
+		},
+
+		"If getting the group has an error, it should fail.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(nil, errors.New("whatever"))
+			},
+			expErr: true,
+		},
+
+		"If any of the resources has a pre and post delete hook, they should execute.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{
+					PreDelete:  &model.GroupHookSpec{Cmd: "cmd1 predeletehook", Timeout: 42 * time.Minute},
+					PostDelete: &model.GroupHookSpec{Cmd: "cmd2 postdeletehook", Timeout: 42 * time.Minute},
+				}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+
+				// Pre delete hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd := expCmdMatcher([]string{"cmd1", "predeletehook"})
+				mcr.On("Start", mock.MatchedBy(expCmd)).Once().Return(nil)
+				mcr.On("Wait", mock.MatchedBy(expCmd)).Once().Return(nil)
+
+				expResources := []model.Resource{{ID: "resource1", GroupID: "group1"}}
+				mrm.On("Delete", mock.Anything, expResources).Once().Return(nil)
+
+				// Post delete hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd = expCmdMatcher([]string{"cmd2", "postdeletehook"})
+				mcr.On("Start", mock.MatchedBy(expCmd)).Once().Return(nil)
+				mcr.On("Wait", mock.MatchedBy(expCmd)).Once().Return(nil)
+			},
+		},
+
+		"If a pre delete hook has an error it should fail without calling delete.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{PreDelete: &model.GroupHookSpec{Cmd: "cmd1 predeletehook"}}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+
+				// Pre delete hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd := expCmdMatcher([]string{"cmd1", "predeletehook"})
+				mcr.On("Start", mock.MatchedBy(expCmd)).Once().Return(nil)
+				mcr.On("Wait", mock.MatchedBy(expCmd)).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+
+		"If a post delete hook has an error it should fail.": {
+			resources: []model.Resource{
+				{ID: "resource1", GroupID: "group1"},
+			},
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository, mcr *hookmock.CmdRunner) {
+				group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{PostDelete: &model.GroupHookSpec{Cmd: "cmd1 postdeletehook"}}}
+				mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+
+				expResources := []model.Resource{{ID: "resource1", GroupID: "group1"}}
+				mrm.On("Delete", mock.Anything, expResources).Once().Return(nil)
+
+				// Post delete hook.
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				expCmd := expCmdMatcher([]string{"cmd1", "postdeletehook"})
+				mcr.On("Start", mock.MatchedBy(expCmd)).Once().Return(nil)
+				mcr.On("Wait", mock.MatchedBy(expCmd)).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
 	}
 
 	for name, test := range tests {
@@ -347,6 +627,12 @@ func TestManagerDelete(t *testing.T) {
 				GroupRepository: mgr,
 				CmdRunner:       mcr,
 			}
+			if test.mockOldGroup != nil {
+				mogr := &storagemock.GroupRepository{}
+				test.mockOldGroup(mogr)
+				config.OldGroupRepository = mogr
+				defer mogr.AssertExpectations(t)
+			}
 			manager, err := hook.NewManager(config)
 			require.NoError(err)
 
@@ -360,6 +646,486 @@ func TestManagerDelete(t *testing.T) {
 			}
 			mrm.AssertExpectations(t)
 			mgr.AssertExpectations(t)
+			mcr.AssertExpectations(t)
+		})
+	}
+}
+
+func TestManagerRecordHookResults(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Get a real exit error from a command.
+	exitCode3Err := exec.Command("sh", "-c", "exit 3").Run()
+
+	// Mocks.
+	mrm := &managemock.ResourceManager{}
+	mgr := &storagemock.GroupRepository{}
+	mcr := &hookmock.CmdRunner{}
+	mr := &reportmock.Recorder{}
+
+	group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{
+		Pre:  &model.GroupHookSpec{Cmd: "pre-hook"},
+		Post: &model.GroupHookSpec{Cmd: "post-hook"},
+	}}
+	mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+	mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(nil)
+	mcr.On("CombinedOutputPipe", mock.Anything).Return(nopR, nil)
+	mcr.On("Start", mock.Anything).Return(nil)
+	mcr.On("Wait", mock.MatchedBy(expCmdMatcher([]string{"pre-hook"}))).Once().Return(nil)
+	mcr.On("Wait", mock.MatchedBy(expCmdMatcher([]string{"post-hook"}))).Once().Return(exitCode3Err)
+
+	expHook := func(hookType, cmd string, exitCode int, expErr bool) interface{} {
+		return mock.MatchedBy(func(h model.HookResult) bool {
+			return h.GroupID == "group1" &&
+				h.Type == hookType &&
+				h.Cmd == cmd &&
+				h.ExitCode == exitCode &&
+				!h.StartedAt.IsZero() &&
+				(h.Error != "") == expErr
+		})
+	}
+	mr.On("RecordHookResult", mock.Anything, expHook("pre", "pre-hook", 0, false)).Once()
+	mr.On("RecordHookResult", mock.Anything, expHook("post", "post-hook", 3, true)).Once()
+
+	// Execute.
+	manager, err := hook.NewManager(hook.ManagerConfig{
+		Manager:         mrm,
+		GroupRepository: mgr,
+		CmdRunner:       mcr,
+		Recorder:        mr,
+	})
+	require.NoError(err)
+
+	err = manager.Apply(context.TODO(), []model.Resource{{ID: "resource1", GroupID: "group1"}})
+
+	// Check.
+	assert.Error(err)
+	mr.AssertExpectations(t)
+}
+
+func TestManagerTraceHooks(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Mocks.
+	mrm := &managemock.ResourceManager{}
+	mgr := &storagemock.GroupRepository{}
+	mcr := &hookmock.CmdRunner{}
+
+	group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{
+		Pre: &model.GroupHookSpec{Cmd: "pre-hook"},
+	}}
+	mgr.On("GetGroup", mock.Anything, "group1").Once().Return(group1, nil)
+	mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(nil)
+	mcr.On("CombinedOutputPipe", mock.Anything).Return(nopR, nil)
+	mcr.On("Wait", mock.Anything).Return(nil)
+
+	// The hook should receive the trace context of the hook span.
+	var gotTraceparent string
+	mcr.On("Start", mock.Anything).Once().Run(func(args mock.Arguments) {
+		for _, env := range args.Get(0).(*exec.Cmd).Env {
+			if strings.HasPrefix(env, "TRACEPARENT=") {
+				gotTraceparent = strings.TrimPrefix(env, "TRACEPARENT=")
+			}
+		}
+	}).Return(nil)
+
+	// Execute.
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	manager, err := hook.NewManager(hook.ManagerConfig{
+		Manager:         mrm,
+		GroupRepository: mgr,
+		CmdRunner:       mcr,
+		Tracer:          tp.Tracer("test"),
+	})
+	require.NoError(err)
+
+	err = manager.Apply(context.TODO(), []model.Resource{{ID: "resource1", GroupID: "group1"}})
+	require.NoError(err)
+
+	// Check.
+	spans := sr.Ended()
+	require.Len(spans, 1)
+	span := spans[0]
+	assert.Equal("hook", span.Name())
+	assert.Contains(span.Attributes(), attribute.String("kahoy.group", "group1"))
+	assert.Contains(span.Attributes(), attribute.String("kahoy.hook_type", "pre"))
+	assert.Contains(span.Attributes(), attribute.Int("kahoy.exit_code", 0))
+
+	expTraceparent := fmt.Sprintf("00-%s-%s-01", span.SpanContext().TraceID(), span.SpanContext().SpanID())
+	assert.Equal(expTraceparent, gotTraceparent)
+}
+
+func TestManagerHookResources(t *testing.T) {
+	newResource := func(id, kind, name string) model.Resource {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("apps/v1")
+		obj.SetKind(kind)
+		obj.SetNamespace("ns1")
+		obj.SetName(name)
+		return model.Resource{ID: id, GroupID: "group1", K8sObject: obj}
+	}
+
+	tests := map[string]struct {
+		delete   bool
+		expIndex string
+	}{
+		"Applying resources should pass the applied resources with the create or update change type to the hooks.": {
+			expIndex: `[{"id":"r1","group":"group1","apiVersion":"apps/v1","kind":"Deployment","namespace":"ns1","name":"app1","change":"update"},{"id":"r2","group":"group1","apiVersion":"apps/v1","kind":"StatefulSet","namespace":"ns1","name":"app2","change":"create"}]`,
+		},
+
+		"Deleting resources should pass the deleted resources with the delete change type to the hooks.": {
+			delete:   true,
+			expIndex: `[{"id":"r1","group":"group1","apiVersion":"apps/v1","kind":"Deployment","namespace":"ns1","name":"app1","change":"delete"},{"id":"r2","group":"group1","apiVersion":"apps/v1","kind":"StatefulSet","namespace":"ns1","name":"app2","change":"delete"}]`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			resources := []model.Resource{
+				newResource("r1", "Deployment", "app1"),
+				newResource("r2", "StatefulSet", "app2"),
+			}
+
+			// Mocks.
+			mrm := &managemock.ResourceManager{}
+			mgr := &storagemock.GroupRepository{}
+			mcr := &hookmock.CmdRunner{}
+			menc := &hookmock.K8sObjectEncoder{}
+
+			group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{
+				Pre:       &model.GroupHookSpec{Cmd: "hook"},
+				PreDelete: &model.GroupHookSpec{Cmd: "hook"},
+			}}
+			mgr.On("GetGroup", mock.Anything, "group1").Return(group1, nil)
+			mrm.On("Apply", mock.Anything, mock.Anything).Return(nil)
+			mrm.On("Delete", mock.Anything, mock.Anything).Return(nil)
+			menc.On("EncodeObjects", mock.Anything, []model.K8sObject{resources[0].K8sObject, resources[1].K8sObject}).Once().Return([]byte("manifest"), nil)
+			mcr.On("CombinedOutputPipe", mock.Anything).Return(nopR, nil)
+			mcr.On("Wait", mock.Anything).Return(nil)
+
+			// Get the files passed to the hook.
+			var gotManifestPath, gotIndexPath, gotManifest, gotIndex string
+			mcr.On("Start", mock.Anything).Once().Run(func(args mock.Arguments) {
+				for _, env := range args.Get(0).(*exec.Cmd).Env {
+					switch {
+					case strings.HasPrefix(env, "KAHOY_HOOK_RESOURCES_MANIFEST="):
+						gotManifestPath = strings.TrimPrefix(env, "KAHOY_HOOK_RESOURCES_MANIFEST=")
+					case strings.HasPrefix(env, "KAHOY_HOOK_RESOURCES_INDEX="):
+						gotIndexPath = strings.TrimPrefix(env, "KAHOY_HOOK_RESOURCES_INDEX=")
+					}
+				}
+				data, _ := ioutil.ReadFile(gotManifestPath)
+				gotManifest = string(data)
+				data, _ = ioutil.ReadFile(gotIndexPath)
+				gotIndex = string(data)
+			}).Return(nil)
+
+			// Execute.
+			manager, err := hook.NewManager(hook.ManagerConfig{
+				Manager:         mrm,
+				GroupRepository: mgr,
+				CmdRunner:       mcr,
+				YAMLEncoder:     menc,
+				OldResources:    []model.Resource{{ID: "r1"}},
+			})
+			require.NoError(err)
+
+			if test.delete {
+				err = manager.Delete(context.TODO(), resources)
+			} else {
+				err = manager.Apply(context.TODO(), resources)
+			}
+			require.NoError(err)
+
+			// Check.
+			assert.Equal("manifest", gotManifest)
+			assert.Equal(test.expIndex, gotIndex)
+
+			// Files should be cleaned after the execution.
+			_, err = os.Stat(gotManifestPath)
+			assert.True(os.IsNotExist(err))
+			_, err = os.Stat(gotIndexPath)
+			assert.True(os.IsNotExist(err))
 		})
 	}
 }
+
+func TestManagerJobHooks(t *testing.T) {
+	newJob := func(group, name string, annotations map[string]string) model.Resource {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("batch/v1")
+		obj.SetKind("Job")
+		obj.SetNamespace("ns1")
+		obj.SetName(name)
+		obj.SetAnnotations(annotations)
+		return model.Resource{ID: "batch/v1/Job/ns1/" + name, GroupID: group, K8sObject: obj}
+	}
+
+	expJob := func(name string) interface{} {
+		return mock.MatchedBy(func(j *batchv1.Job) bool { return j.Namespace == "ns1" && j.Name == name })
+	}
+
+	tests := map[string]struct {
+		jobHooks     []model.Resource
+		delete       bool
+		groupMissing bool
+		mock         func(mjr *hookmock.JobRunner, mrm *managemock.ResourceManager)
+		expErr       bool
+	}{
+		"Applying should run the pre and post apply hook jobs deleting the previous ones by default.": {
+			jobHooks: []model.Resource{
+				newJob("group1", "pre-job", map[string]string{"kahoy.slok.dev/hook": "pre-apply"}),
+				newJob("group1", "post-job", map[string]string{"kahoy.slok.dev/hook": "post-apply"}),
+				newJob("group1", "pre-delete-job", map[string]string{"kahoy.slok.dev/hook": "pre-delete"}),
+				newJob("group2", "other-job", map[string]string{"kahoy.slok.dev/hook": "pre-apply"}),
+			},
+			mock: func(mjr *hookmock.JobRunner, mrm *managemock.ResourceManager) {
+				mjr.On("DeleteJob", mock.Anything, "ns1", "pre-job").Once().Return(nil)
+				mjr.On("RunJob", mock.Anything, expJob("pre-job"), mock.Anything).Once().Return(nil)
+				mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(nil)
+				mjr.On("DeleteJob", mock.Anything, "ns1", "post-job").Once().Return(nil)
+				mjr.On("RunJob", mock.Anything, expJob("post-job"), mock.Anything).Once().Return(nil)
+			},
+		},
+
+		"Deleting should run the pre delete hook jobs deleting them when succeeded.": {
+			jobHooks: []model.Resource{
+				newJob("group1", "pre-job", map[string]string{"kahoy.slok.dev/hook": "pre-apply"}),
+				newJob("group1", "pre-delete-job", map[string]string{
+					"kahoy.slok.dev/hook":               "pre-delete",
+					"kahoy.slok.dev/hook-delete-policy": "hook-succeeded",
+				}),
+			},
+			delete: true,
+			mock: func(mjr *hookmock.JobRunner, mrm *managemock.ResourceManager) {
+				mjr.On("RunJob", mock.Anything, expJob("pre-delete-job"), mock.Anything).Once().Return(nil)
+				mjr.On("DeleteJob", mock.Anything, "ns1", "pre-delete-job").Once().Return(nil)
+				mrm.On("Delete", mock.Anything, mock.Anything).Once().Return(nil)
+			},
+		},
+
+		"Deleting resources of a removed group should run the group pre delete hook jobs.": {
+			jobHooks: []model.Resource{
+				newJob("group1", "pre-delete-job", map[string]string{"kahoy.slok.dev/hook": "pre-delete"}),
+			},
+			delete:       true,
+			groupMissing: true,
+			mock: func(mjr *hookmock.JobRunner, mrm *managemock.ResourceManager) {
+				mjr.On("DeleteJob", mock.Anything, "ns1", "pre-delete-job").Once().Return(nil)
+				mjr.On("RunJob", mock.Anything, expJob("pre-delete-job"), mock.Anything).Once().Return(nil)
+				mrm.On("Delete", mock.Anything, mock.Anything).Once().Return(nil)
+			},
+		},
+
+		"A hook job that exceeds its timeout should fail and be deleted if the policy requires it.": {
+			jobHooks: []model.Resource{
+				newJob("group1", "pre-job", map[string]string{
+					"kahoy.slok.dev/hook":               "pre-apply",
+					"kahoy.slok.dev/hook-delete-policy": "hook-failed",
+					"kahoy.slok.dev/hook-timeout":       "1ms",
+				}),
+			},
+			mock: func(mjr *hookmock.JobRunner, mrm *managemock.ResourceManager) {
+				mjr.On("RunJob", mock.Anything, expJob("pre-job"), mock.Anything).Once().Run(func(args mock.Arguments) {
+					<-args.Get(0).(context.Context).Done()
+				}).Return(context.DeadlineExceeded)
+				expCtx := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil })
+				mjr.On("DeleteJob", expCtx, "ns1", "pre-job").Once().Return(nil)
+			},
+			expErr: true,
+		},
+
+		"A failed hook job should stop the execution and be deleted if the policy requires it.": {
+			jobHooks: []model.Resource{
+				newJob("group1", "pre-job", map[string]string{
+					"kahoy.slok.dev/hook":               "pre-apply",
+					"kahoy.slok.dev/hook-delete-policy": "hook-succeeded,hook-failed",
+				}),
+			},
+			mock: func(mjr *hookmock.JobRunner, mrm *managemock.ResourceManager) {
+				mjr.On("RunJob", mock.Anything, expJob("pre-job"), mock.Anything).Once().Return(fmt.Errorf("whatever"))
+				mjr.On("DeleteJob", mock.Anything, "ns1", "pre-job").Once().Return(nil)
+			},
+			expErr: true,
+		},
+
+		"A failed hook job that doesn't require deletion should not be deleted.": {
+			jobHooks: []model.Resource{
+				newJob("group1", "pre-job", map[string]string{
+					"kahoy.slok.dev/hook":               "pre-apply",
+					"kahoy.slok.dev/hook-delete-policy": "hook-succeeded",
+				}),
+			},
+			mock: func(mjr *hookmock.JobRunner, mrm *managemock.ResourceManager) {
+				mjr.On("RunJob", mock.Anything, expJob("pre-job"), mock.Anything).Once().Return(fmt.Errorf("whatever"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mrm := &managemock.ResourceManager{}
+			mgr := &storagemock.GroupRepository{}
+			mjr := &hookmock.JobRunner{}
+
+			if test.groupMissing {
+				mgr.On("GetGroup", mock.Anything, "group1").Return(nil, fmt.Errorf("group is missing: %w", internalerrors.ErrMissing))
+			} else {
+				mgr.On("GetGroup", mock.Anything, "group1").Return(&model.Group{ID: "group1"}, nil)
+			}
+			test.mock(mjr, mrm)
+
+			// Execute.
+			manager, err := hook.NewManager(hook.ManagerConfig{
+				Manager:         mrm,
+				GroupRepository: mgr,
+				JobHooks:        test.jobHooks,
+				JobRunner:       mjr,
+			})
+			require.NoError(err)
+
+			resources := []model.Resource{{ID: "resource1", GroupID: "group1"}}
+			if test.delete {
+				err = manager.Delete(context.TODO(), resources)
+			} else {
+				err = manager.Apply(context.TODO(), resources)
+			}
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			mjr.AssertExpectations(t)
+			mrm.AssertExpectations(t)
+		})
+	}
+}
+
+func TestManagerHooksOrder(t *testing.T) {
+	tests := map[string]struct {
+		groups      []*model.Group
+		concurrency int
+		expCmds     []string
+	}{
+		"Hooks should be executed by their group order.": {
+			groups: []*model.Group{
+				{ID: "group1", Hooks: model.GroupHooks{Order: 2, Pre: &model.GroupHookSpec{Cmd: "cache-flush"}}},
+				{ID: "group2", Hooks: model.GroupHooks{Order: 1, Pre: &model.GroupHookSpec{Cmd: "db-migration"}}},
+				{ID: "group3", Hooks: model.GroupHooks{Order: 3, Pre: &model.GroupHookSpec{Cmd: "notify"}}},
+			},
+			expCmds: []string{"db-migration", "cache-flush", "notify"},
+		},
+
+		"Hooks with the same order and a concurrency of one should be executed sequentially by group.": {
+			groups: []*model.Group{
+				{ID: "group3", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{Cmd: "cmd3"}}},
+				{ID: "group1", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{Cmd: "cmd1"}}},
+				{ID: "group2", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{Cmd: "cmd2"}}},
+			},
+			concurrency: 1,
+			expCmds:     []string{"cmd1", "cmd2", "cmd3"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mrm := &managemock.ResourceManager{}
+			mgr := &storagemock.GroupRepository{}
+			mcr := &hookmock.CmdRunner{}
+
+			resources := []model.Resource{}
+			for _, g := range test.groups {
+				mgr.On("GetGroup", mock.Anything, g.ID).Return(g, nil)
+				resources = append(resources, model.Resource{ID: "r-" + g.ID, GroupID: g.ID})
+			}
+			mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(nil)
+			mcr.On("CombinedOutputPipe", mock.Anything).Return(nopR, nil)
+			mcr.On("Wait", mock.Anything).Return(nil)
+
+			var gotCmds []string
+			mcr.On("Start", mock.Anything).Run(func(args mock.Arguments) {
+				gotCmds = append(gotCmds, args.Get(0).(*exec.Cmd).Args[0])
+			}).Return(nil)
+
+			// Execute.
+			manager, err := hook.NewManager(hook.ManagerConfig{
+				Manager:         mrm,
+				GroupRepository: mgr,
+				CmdRunner:       mcr,
+				Concurrency:     test.concurrency,
+			})
+			require.NoError(err)
+
+			err = manager.Apply(context.TODO(), resources)
+			require.NoError(err)
+
+			// Check.
+			assert.Equal(test.expCmds, gotCmds)
+		})
+	}
+}
+
+type testLogger struct {
+	log.Logger
+	mu    sync.Mutex
+	infos []string
+}
+
+func (t *testLogger) Infof(format string, args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.infos = append(t.infos, fmt.Sprintf(format, args...))
+}
+
+func (t *testLogger) WithValues(map[string]interface{}) log.Logger { return t }
+
+func TestManagerHookOutput(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Mocks.
+	mrm := &managemock.ResourceManager{}
+	mgr := &storagemock.GroupRepository{}
+	mcr := &hookmock.CmdRunner{}
+
+	group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{Pre: &model.GroupHookSpec{Cmd: "hook"}}}
+	mgr.On("GetGroup", mock.Anything, "group1").Return(group1, nil)
+	mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(nil)
+	mcr.On("CombinedOutputPipe", mock.Anything).Return(strings.NewReader("line1\nline2\nline3\n"), nil)
+	mcr.On("Start", mock.Anything).Return(nil)
+	mcr.On("Wait", mock.Anything).Return(nil)
+
+	// Execute.
+	logger := &testLogger{Logger: log.Noop}
+	manager, err := hook.NewManager(hook.ManagerConfig{
+		Manager:         mrm,
+		GroupRepository: mgr,
+		CmdRunner:       mcr,
+		Logger:          logger,
+	})
+	require.NoError(err)
+
+	err = manager.Apply(context.TODO(), []model.Resource{{ID: "r1", GroupID: "group1"}})
+	require.NoError(err)
+
+	// Check the output has been logged as a single block.
+	assert.Equal([]string{"executing hook", "hook output:\nline1\nline2\nline3"}, logger.infos)
+}
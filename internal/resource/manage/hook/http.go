@@ -0,0 +1,193 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/fieldpath"
+)
+
+// httpHookData is the data used to render the HTTP hooks body template.
+type httpHookData struct {
+	Group     string
+	HookType  string
+	Resources []hookResource
+}
+
+var httpHookTemplateFuncs = template.FuncMap{
+	// json marshals any value, useful to escape strings inside JSON templates.
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// httpHookMaxResponseBody is the maximum response body size that will be read.
+const httpHookMaxResponseBody = 1 << 20
+
+// createHTTPHook creates a hook that executes an HTTP request and checks the response.
+func (h hookFactory) createHTTPHook(groupID string, config *model.GroupHookSpec, hookType string, resources []hookResource) hook {
+	httpConfig := *config.HTTP
+	if httpConfig.Method == "" {
+		httpConfig.Method = http.MethodGet
+	}
+	if resources == nil {
+		resources = []hookResource{}
+	}
+
+	logger := h.logger.WithValues(log.Kv{
+		"group":     groupID,
+		"hook-type": hookType,
+		"hook-url":  httpConfig.URL,
+	})
+
+	// The body is the same for all the attempts.
+	body, bodyErr := renderHTTPHookBody(httpConfig.Body, httpHookData{
+		Group:     groupID,
+		HookType:  hookType,
+		Resources: resources,
+	})
+
+	cmdStr := fmt.Sprintf("%s %s", httpConfig.Method, httpConfig.URL)
+	return h.newHook(groupID, config, hookType, cmdStr, logger, func(ctx context.Context, output log.Logger) error {
+		if bodyErr != nil {
+			return bodyErr
+		}
+
+		return h.runHTTP(ctx, output, httpConfig, body)
+	})
+}
+
+func renderHTTPHookBody(tplStr string, data httpHookData) ([]byte, error) {
+	if tplStr == "" {
+		return nil, nil
+	}
+
+	tpl, err := template.New("body").Funcs(httpHookTemplateFuncs).Option("missingkey=error").Parse(tplStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+
+	var b bytes.Buffer
+	err = tpl.Execute(&b, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not render body template: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// runHTTP executes a single attempt of the HTTP hook, if the hook has a poll interval
+// it will poll until the request succeeds or the context is done.
+func (h hookFactory) runHTTP(ctx context.Context, output log.Logger, config model.HTTPHook, body []byte) error {
+	for {
+		err := h.doHTTPRequest(ctx, output, config, body)
+		if err == nil || config.PollInterval <= 0 {
+			return err
+		}
+
+		output.Infof("request not successful, polling again in %s: %s", config.PollInterval, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(config.PollInterval):
+		}
+	}
+}
+
+func (h hookFactory) doHTTPRequest(ctx context.Context, output log.Logger, config model.HTTPHook, body []byte) error {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, config.Method, config.URL, bodyReader)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	for k, v := range config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, httpHookMaxResponseBody))
+	if err != nil {
+		return fmt.Errorf("could not read response body: %w", err)
+	}
+	output.Infof("%s %s: %d", config.Method, config.URL, resp.StatusCode)
+
+	// Check status code.
+	if !httpStatusExpected(resp.StatusCode, config.ExpectedStatus) {
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	// Check response body.
+	if config.JSONPath == "" {
+		return nil
+	}
+
+	var data interface{}
+	err = json.Unmarshal(respBody, &data)
+	if err != nil {
+		return fmt.Errorf("response body is not valid JSON: %w", err)
+	}
+
+	values, err := fieldpath.Get(data, config.JSONPath)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("%q not found on the response body", config.JSONPath)
+	}
+	if config.ExpectedValue == "" {
+		return nil
+	}
+
+	for _, v := range values {
+		if jsonValueString(v) == config.ExpectedValue {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q is %s, expected %q", config.JSONPath, jsonValueString(values[0]), config.ExpectedValue)
+}
+
+func httpStatusExpected(status int, expected []int) bool {
+	if len(expected) == 0 {
+		return status >= 200 && status < 300
+	}
+
+	for _, s := range expected {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonValueString returns the string representation of a decoded JSON value, strings
+// are returned as they are and the rest as JSON (e.g: `true`, `3`, `{"a":"b"}`).
+func jsonValueString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	data, _ := json.Marshal(v)
+	return string(data)
+}
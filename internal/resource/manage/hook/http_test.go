@@ -0,0 +1,177 @@
+package hook_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/manage/hook"
+	"github.com/slok/kahoy/internal/resource/manage/managemock"
+	"github.com/slok/kahoy/internal/storage/storagemock"
+)
+
+func TestManagerHTTPHooks(t *testing.T) {
+	tests := map[string]struct {
+		hook     func(url string) *model.GroupHookSpec
+		handler  func(calls int, w http.ResponseWriter, r *http.Request)
+		expCalls int
+		expErr   bool
+	}{
+		"A correct request should send the rendered body and headers.": {
+			hook: func(url string) *model.GroupHookSpec {
+				return &model.GroupHookSpec{HTTP: &model.HTTPHook{
+					Method:  http.MethodPost,
+					URL:     url,
+					Headers: map[string]string{"Authorization": "Bearer test"},
+					Body:    `{"group":{{ json .Group }},"type":"{{ .HookType }}","resources":{{ json .Resources }}}`,
+				}}
+			},
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				expBody := `{"group":"group1","type":"pre","resources":[{"id":"r1","group":"group1","apiVersion":"apps/v1","kind":"Deployment","namespace":"ns1","name":"app1","change":"create"}]}`
+				if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer test" || string(body) != expBody {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			},
+			expCalls: 1,
+		},
+
+		"An unexpected status code should fail.": {
+			hook: func(url string) *model.GroupHookSpec {
+				return &model.GroupHookSpec{HTTP: &model.HTTPHook{URL: url}}
+			},
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			expCalls: 1,
+			expErr:   true,
+		},
+
+		"A status code that is on the expected status codes should succeed.": {
+			hook: func(url string) *model.GroupHookSpec {
+				return &model.GroupHookSpec{HTTP: &model.HTTPHook{URL: url, ExpectedStatus: []int{http.StatusOK, http.StatusNotFound}}}
+			},
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			expCalls: 1,
+		},
+
+		"A response with the expected JSON path value should succeed.": {
+			hook: func(url string) *model.GroupHookSpec {
+				return &model.GroupHookSpec{HTTP: &model.HTTPHook{URL: url, JSONPath: `.checks[name="db"].status`, ExpectedValue: "ok"}}
+			},
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"checks":[{"name":"cache","status":"failing"},{"name":"db","status":"ok"}]}`)
+			},
+			expCalls: 1,
+		},
+
+		"A response without the expected JSON path value should fail.": {
+			hook: func(url string) *model.GroupHookSpec {
+				return &model.GroupHookSpec{HTTP: &model.HTTPHook{URL: url, JSONPath: ".ready", ExpectedValue: "true"}}
+			},
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"ready":false}`)
+			},
+			expCalls: 1,
+			expErr:   true,
+		},
+
+		"Polling should request until the response is the expected one.": {
+			hook: func(url string) *model.GroupHookSpec {
+				return &model.GroupHookSpec{
+					Timeout: 5 * time.Second,
+					HTTP:    &model.HTTPHook{URL: url, JSONPath: ".gate", ExpectedValue: "open", PollInterval: time.Millisecond},
+				}
+			},
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				if calls < 3 {
+					fmt.Fprint(w, `{"gate":"closed"}`)
+					return
+				}
+				fmt.Fprint(w, `{"gate":"open"}`)
+			},
+			expCalls: 3,
+		},
+
+		"Polling should fail when the hook times out.": {
+			hook: func(url string) *model.GroupHookSpec {
+				return &model.GroupHookSpec{
+					Timeout: 50 * time.Millisecond,
+					HTTP:    &model.HTTPHook{URL: url, PollInterval: time.Millisecond},
+				}
+			},
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Prepare the server.
+			var mu sync.Mutex
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls++
+				test.handler(calls, w, r)
+			}))
+			defer server.Close()
+
+			// Mocks.
+			mrm := &managemock.ResourceManager{}
+			mgr := &storagemock.GroupRepository{}
+
+			group1 := &model.Group{ID: "group1", Hooks: model.GroupHooks{Pre: test.hook(server.URL)}}
+			mgr.On("GetGroup", mock.Anything, "group1").Return(group1, nil)
+			mrm.On("Apply", mock.Anything, mock.Anything).Return(nil)
+
+			// Execute.
+			manager, err := hook.NewManager(hook.ManagerConfig{
+				Manager:         mrm,
+				GroupRepository: mgr,
+			})
+			require.NoError(err)
+
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("apps/v1")
+			obj.SetKind("Deployment")
+			obj.SetNamespace("ns1")
+			obj.SetName("app1")
+			err = manager.Apply(context.TODO(), []model.Resource{{ID: "r1", GroupID: "group1", K8sObject: obj}})
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			if test.expCalls > 0 {
+				mu.Lock()
+				assert.Equal(test.expCalls, calls)
+				mu.Unlock()
+			}
+		})
+	}
+}
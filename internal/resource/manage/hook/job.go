@@ -0,0 +1,141 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/tracing"
+)
+
+// JobRunner knows how to run Kubernetes Jobs on the cluster.
+type JobRunner interface {
+	// RunJob creates the Job and waits until it finishes, the logs of the Job pods
+	// are sent to the received logger.
+	RunJob(ctx context.Context, job *batchv1.Job, logger log.Logger) error
+	// DeleteJob deletes the Job and its pods, missing Jobs are ignored.
+	DeleteJob(ctx context.Context, ns, name string) error
+}
+
+//go:generate mockery --case underscore --output hookmock --outpkg hookmock --name JobRunner
+
+// jobHookType returns the Job hook type that is executed on the group hook type.
+func jobHookType(hookType string) model.HookType {
+	switch hookType {
+	case hookPreType:
+		return model.HookTypePreApply
+	case hookPostType:
+		return model.HookTypePostApply
+	case hookPreDeleteType:
+		return model.HookTypePreDelete
+	default:
+		return ""
+	}
+}
+
+// newHookJob converts the resource into a Kubernetes Job.
+func newHookJob(r model.Resource) (*batchv1.Job, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(r.K8sObject.DeepCopyObject())
+	if err != nil {
+		return nil, fmt.Errorf("could not convert %q to unstructured: %w", r.ID, err)
+	}
+
+	job := &batchv1.Job{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(content, job)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert %q to a Job: %w", r.ID, err)
+	}
+
+	if job.Namespace == "" {
+		job.Namespace = "default"
+	}
+
+	return job, nil
+}
+
+// createJobHook creates a hook that runs the resource Job on the cluster with the resource
+// hook timeout, and deletes it based on the resource hook delete policies.
+func (h hookFactory) createJobHook(groupID string, r model.Resource, hookType string) hook {
+	logger := h.logger.WithValues(log.Kv{
+		"group":     groupID,
+		"hook-type": hookType,
+		"hook-job":  r.ID,
+	})
+
+	timeout := r.HookTimeout()
+	policies := map[model.HookDeletePolicy]bool{}
+	for _, p := range r.HookDeletePolicies() {
+		policies[p] = true
+	}
+
+	return func(ctx context.Context) (err error) {
+		logger.Infof("executing job hook")
+
+		ctx, span := h.tracer.Start(ctx, "hook", trace.WithAttributes(
+			attribute.String("kahoy.group", groupID),
+			attribute.String("kahoy.hook_type", hookType),
+			attribute.String("kahoy.job", r.ID),
+		))
+
+		job, err := newHookJob(r)
+		if err != nil {
+			tracing.EndSpan(span, err)
+			return err
+		}
+
+		// Record the hook result.
+		result := model.HookResult{
+			GroupID:   groupID,
+			Type:      hookType,
+			Cmd:       fmt.Sprintf("job %s/%s", job.Namespace, job.Name),
+			StartedAt: time.Now().UTC(),
+			ExitCode:  -1,
+		}
+		defer func() {
+			result.Duration = time.Since(result.StartedAt)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.ExitCode = 0
+			}
+			h.recorder.RecordHookResult(ctx, result)
+			tracing.EndSpan(span, err)
+		}()
+
+		if policies[model.HookDeletePolicyBeforeHookCreation] {
+			err = h.jobRunner.DeleteJob(ctx, job.Namespace, job.Name)
+			if err != nil {
+				return fmt.Errorf("could not delete previous hook job: %w", err)
+			}
+		}
+
+		output := newOutputLogger(logger)
+		runCtx, cancel := context.WithTimeout(ctx, timeout)
+		runErr := h.jobRunner.RunJob(runCtx, job, output)
+		if runErr != nil && runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			runErr = fmt.Errorf("hook job timed out after %s: %w", timeout, runErr)
+		}
+		cancel()
+		output.Flush()
+
+		if (runErr == nil && policies[model.HookDeletePolicyHookSucceeded]) || (runErr != nil && policies[model.HookDeletePolicyHookFailed]) {
+			err = h.jobRunner.DeleteJob(ctx, job.Namespace, job.Name)
+			if err != nil {
+				logger.Warningf("could not delete hook job: %s", err)
+			}
+		}
+
+		if runErr != nil {
+			return fmt.Errorf("hook job execution failed: %w", runErr)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,65 @@
+package hook
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/slok/kahoy/internal/log"
+)
+
+// hookOutputBlockLines is the maximum number of hook output lines that are logged together.
+const hookOutputBlockLines = 100
+
+// outputLogger is a logger that buffers the hook output (info messages) and logs it in
+// blocks, so the output of the hooks executed concurrently doesn't interleave on the logs.
+type outputLogger struct {
+	log.Logger
+	out *hookOutput
+}
+
+type hookOutput struct {
+	mu     sync.Mutex
+	lines  []string
+	logger log.Logger
+}
+
+func newOutputLogger(logger log.Logger) outputLogger {
+	return outputLogger{
+		Logger: logger,
+		out:    &hookOutput{logger: logger},
+	}
+}
+
+func (o outputLogger) Infof(format string, args ...interface{}) {
+	o.out.mu.Lock()
+	defer o.out.mu.Unlock()
+
+	o.out.lines = append(o.out.lines, fmt.Sprintf(format, args...))
+	if len(o.out.lines) >= hookOutputBlockLines {
+		o.out.flush()
+	}
+}
+
+func (o outputLogger) WithValues(values map[string]interface{}) log.Logger {
+	return outputLogger{
+		Logger: o.Logger.WithValues(values),
+		out:    o.out,
+	}
+}
+
+// Flush logs the buffered output.
+func (o outputLogger) Flush() {
+	o.out.mu.Lock()
+	defer o.out.mu.Unlock()
+	o.out.flush()
+}
+
+func (h *hookOutput) flush() {
+	if len(h.lines) == 0 {
+		return
+	}
+
+	h.logger.Infof("hook output:\n%s", strings.Join(h.lines, "\n"))
+	h.lines = nil
+}
@@ -0,0 +1,109 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/slok/kahoy/internal/model"
+)
+
+// K8sObjectEncoder knows how to encode K8s objects into Raw Kubernetes compatible formats.
+type K8sObjectEncoder interface {
+	EncodeObjects(ctx context.Context, objs []model.K8sObject) ([]byte, error)
+}
+
+//go:generate mockery --case underscore --output hookmock --outpkg hookmock --name K8sObjectEncoder
+
+// Change types of the resources exposed to the hooks.
+const (
+	resourceChangeCreate = "create"
+	resourceChangeUpdate = "update"
+	resourceChangeDelete = "delete"
+)
+
+const (
+	hookResourcesManifestFile = "resources.yaml"
+	hookResourcesIndexFile    = "resources.json"
+)
+
+type hookResource struct {
+	ID         string `json:"id"`
+	Group      string `json:"group"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Change     string `json:"change"`
+}
+
+// writeHookResources writes the resources as a YAML manifest and a JSON index on a
+// temporary directory. Returns the env vars with the paths of the files that will
+// be passed to the hooks and a function to clean the files.
+func writeHookResources(ctx context.Context, encoder K8sObjectEncoder, resources []model.Resource, changeType func(model.Resource) string) (env []string, cleanup func(), err error) {
+	dir, err := ioutil.TempDir("", "kahoy-hook-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+
+	objs := make([]model.K8sObject, 0, len(resources))
+	for _, r := range resources {
+		objs = append(objs, r.K8sObject)
+	}
+	index := newHookResources(resources, changeType)
+
+	manifest, err := encoder.EncodeObjects(ctx, objs)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("could not encode resources: %w", err)
+	}
+
+	indexData, err := json.Marshal(index)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("could not marshal resources index: %w", err)
+	}
+
+	manifestPath := filepath.Join(dir, hookResourcesManifestFile)
+	indexPath := filepath.Join(dir, hookResourcesIndexFile)
+	for path, data := range map[string][]byte{manifestPath: manifest, indexPath: indexData} {
+		err := ioutil.WriteFile(path, data, 0600)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("could not write %q: %w", path, err)
+		}
+	}
+
+	env = []string{
+		fmt.Sprintf("KAHOY_HOOK_RESOURCES_MANIFEST=%s", manifestPath),
+		fmt.Sprintf("KAHOY_HOOK_RESOURCES_INDEX=%s", indexPath),
+	}
+
+	return env, cleanup, nil
+}
+
+// newHookResources returns the information of the resources exposed to the hooks.
+func newHookResources(resources []model.Resource, changeType func(model.Resource) string) []hookResource {
+	index := make([]hookResource, 0, len(resources))
+	for _, r := range resources {
+		hr := hookResource{
+			ID:     r.ID,
+			Group:  r.GroupID,
+			Change: changeType(r),
+		}
+		if r.K8sObject != nil {
+			gvk := r.K8sObject.GetObjectKind().GroupVersionKind()
+			hr.APIVersion = gvk.GroupVersion().String()
+			hr.Kind = gvk.Kind
+			hr.Namespace = r.K8sObject.GetNamespace()
+			hr.Name = r.K8sObject.GetName()
+		}
+		index = append(index, hr)
+	}
+
+	return index
+}
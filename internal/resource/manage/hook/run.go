@@ -0,0 +1,177 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
+	"github.com/slok/kahoy/internal/tracing"
+)
+
+// RunExecutor knows how to execute the run level hooks.
+type RunExecutor interface {
+	// BeforeRun executes the hook before executing anything.
+	BeforeRun(ctx context.Context) error
+	// AfterRun executes the hook after a correct execution.
+	AfterRun(ctx context.Context) error
+	// OnFailure executes the hook after a failed execution with the execution error
+	// and the resources that have been applied and deleted before failing.
+	OnFailure(ctx context.Context, execErr error, applied, deleted []model.Resource) error
+}
+
+//go:generate mockery --case underscore --output hookmock --outpkg hookmock --name RunExecutor
+
+// NoopRunExecutor doesn't execute anything.
+const NoopRunExecutor = noopRunExecutor(0)
+
+type noopRunExecutor int
+
+func (noopRunExecutor) BeforeRun(context.Context) error { return nil }
+func (noopRunExecutor) AfterRun(context.Context) error  { return nil }
+func (noopRunExecutor) OnFailure(context.Context, error, []model.Resource, []model.Resource) error {
+	return nil
+}
+
+// RunExecutorConfig is the configuration of the run hooks executor.
+type RunExecutorConfig struct {
+	Hooks       model.RunHooksConfig
+	CmdRunner   CmdRunner
+	KubectlCmd  string
+	KubeConfig  string
+	KubeContext string
+	// Recorder will record the result of the executed hooks.
+	Recorder report.Recorder
+	// Tracer is used to trace the hook executions.
+	Tracer trace.Tracer
+	// HTTPClient is the client used by the HTTP hooks.
+	HTTPClient *http.Client
+	Logger     log.Logger
+}
+
+func (c *RunExecutorConfig) defaults() error {
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "hook.RunExecutor"})
+
+	if c.CmdRunner == nil {
+		c.CmdRunner = defaultCmdRunner
+	}
+
+	if c.KubectlCmd == "" {
+		c.KubectlCmd = "kubectl"
+	}
+
+	if c.Recorder == nil {
+		c.Recorder = report.Noop
+	}
+
+	if c.Tracer == nil {
+		c.Tracer = tracing.Noop
+	}
+
+	if c.HTTPClient == nil {
+		c.HTTPClient = http.DefaultClient
+	}
+
+	return nil
+}
+
+const (
+	hookBeforeRunType = "beforeRun"
+	hookAfterRunType  = "afterRun"
+	hookOnFailureType = "onFailure"
+)
+
+type runExecutor struct {
+	beforeRun *model.GroupHookSpec
+	afterRun  *model.GroupHookSpec
+	onFailure *model.GroupHookSpec
+	hooks     hookFactory
+}
+
+// NewRunExecutor returns a new run level hooks executor.
+func NewRunExecutor(config RunExecutorConfig) (RunExecutor, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("could not create run hooks executor: %w", err)
+	}
+
+	return runExecutor{
+		beforeRun: runHookConfigToSpec(config.Hooks.BeforeRun),
+		afterRun:  runHookConfigToSpec(config.Hooks.AfterRun),
+		onFailure: runHookConfigToSpec(config.Hooks.OnFailure),
+		hooks: hookFactory{
+			cmdRunner:   config.CmdRunner,
+			httpClient:  config.HTTPClient,
+			kubectlCmd:  config.KubectlCmd,
+			kubeConfig:  config.KubeConfig,
+			kubeContext: config.KubeContext,
+			recorder:    config.Recorder,
+			tracer:      config.Tracer,
+			logger:      config.Logger,
+		},
+	}, nil
+}
+
+// runHookConfigToSpec maps the run level hook configuration, these hooks don't
+// belong to any group so their base directory is the Kahoy execution directory.
+func runHookConfigToSpec(cfg *model.GroupHookConfigSpec) *model.GroupHookSpec {
+	if cfg == nil {
+		return nil
+	}
+
+	return model.NewGroupHookSpec(*cfg, "")
+}
+
+func (r runExecutor) BeforeRun(ctx context.Context) error {
+	err := r.hooks.createHook("", r.beforeRun, hookBeforeRunType, nil)(ctx)
+	if err != nil {
+		return fmt.Errorf("before run hook error: %w", err)
+	}
+
+	return nil
+}
+
+func (r runExecutor) AfterRun(ctx context.Context) error {
+	err := r.hooks.createHook("", r.afterRun, hookAfterRunType, nil)(ctx)
+	if err != nil {
+		return fmt.Errorf("after run hook error: %w", err)
+	}
+
+	return nil
+}
+
+func (r runExecutor) OnFailure(ctx context.Context, execErr error, applied, deleted []model.Resource) error {
+	errMsg := ""
+	if execErr != nil {
+		errMsg = execErr.Error()
+	}
+
+	err := r.hooks.createHook("", r.onFailure, hookOnFailureType, nil,
+		fmt.Sprintf("KAHOY_RUN_ERROR=%s", errMsg),
+		fmt.Sprintf("KAHOY_APPLIED_RESOURCES=%s", resourceIDs(applied)),
+		fmt.Sprintf("KAHOY_DELETED_RESOURCES=%s", resourceIDs(deleted)),
+	)(ctx)
+	if err != nil {
+		return fmt.Errorf("on failure hook error: %w", err)
+	}
+
+	return nil
+}
+
+// resourceIDs returns the IDs of the resources separated by commas.
+func resourceIDs(resources []model.Resource) string {
+	ids := make([]string, 0, len(resources))
+	for _, r := range resources {
+		ids = append(ids, r.ID)
+	}
+
+	return strings.Join(ids, ",")
+}
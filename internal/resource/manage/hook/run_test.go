@@ -0,0 +1,161 @@
+package hook_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/manage/hook"
+	"github.com/slok/kahoy/internal/resource/manage/hook/hookmock"
+)
+
+// expEnvMatcher returns a matcher ready to be used with mock.MatchedBy
+// to check *exec.Cmd env vars on mocks.
+func expEnvMatcher(expEnv []string) func(cmd *exec.Cmd) bool {
+	return func(cmd *exec.Cmd) bool {
+		for _, exp := range expEnv {
+			found := false
+			for _, env := range cmd.Env {
+				if env == exp {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+func TestRunExecutor(t *testing.T) {
+	hooks := model.RunHooksConfig{
+		BeforeRun: &model.GroupHookConfigSpec{Cmd: "cmd1 before", Timeout: time.Minute},
+		AfterRun:  &model.GroupHookConfigSpec{Cmd: "cmd2 after"},
+		OnFailure: &model.GroupHookConfigSpec{Cmd: "cmd3 failure"},
+	}
+
+	tests := map[string]struct {
+		hooks   model.RunHooksConfig
+		execute func(e hook.RunExecutor) error
+		mock    func(mcr *hookmock.CmdRunner)
+		expErr  bool
+	}{
+		"Without hooks, it should not execute anything.": {
+			execute: func(e hook.RunExecutor) error {
+				err := e.BeforeRun(context.TODO())
+				if err != nil {
+					return err
+				}
+				err = e.AfterRun(context.TODO())
+				if err != nil {
+					return err
+				}
+				return e.OnFailure(context.TODO(), errors.New("whatever"), nil, nil)
+			},
+			mock: func(mcr *hookmock.CmdRunner) {},
+		},
+
+		"Before run should execute the before run hook.": {
+			hooks: hooks,
+			execute: func(e hook.RunExecutor) error {
+				return e.BeforeRun(context.TODO())
+			},
+			mock: func(mcr *hookmock.CmdRunner) {
+				expCmd := mock.MatchedBy(func(cmd *exec.Cmd) bool {
+					return expCmdMatcher([]string{"cmd1", "before"})(cmd) && expEnvMatcher([]string{"KAHOY_HOOK_TYPE=beforeRun", "KAHOY_HOOK_GROUP="})(cmd)
+				})
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				mcr.On("Start", expCmd).Once().Return(nil)
+				mcr.On("Wait", expCmd).Once().Return(nil)
+			},
+		},
+
+		"After run should execute the after run hook.": {
+			hooks: hooks,
+			execute: func(e hook.RunExecutor) error {
+				return e.AfterRun(context.TODO())
+			},
+			mock: func(mcr *hookmock.CmdRunner) {
+				expCmd := mock.MatchedBy(func(cmd *exec.Cmd) bool {
+					return expCmdMatcher([]string{"cmd2", "after"})(cmd) && expEnvMatcher([]string{"KAHOY_HOOK_TYPE=afterRun"})(cmd)
+				})
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				mcr.On("Start", expCmd).Once().Return(nil)
+				mcr.On("Wait", expCmd).Once().Return(nil)
+			},
+		},
+
+		"On failure should execute the on failure hook with the error and the executed resources.": {
+			hooks: hooks,
+			execute: func(e hook.RunExecutor) error {
+				applied := []model.Resource{{ID: "r1"}, {ID: "r2"}}
+				deleted := []model.Resource{{ID: "r3"}}
+				return e.OnFailure(context.TODO(), errors.New("something failed"), applied, deleted)
+			},
+			mock: func(mcr *hookmock.CmdRunner) {
+				expCmd := mock.MatchedBy(func(cmd *exec.Cmd) bool {
+					return expCmdMatcher([]string{"cmd3", "failure"})(cmd) && expEnvMatcher([]string{
+						"KAHOY_HOOK_TYPE=onFailure",
+						"KAHOY_RUN_ERROR=something failed",
+						"KAHOY_APPLIED_RESOURCES=r1,r2",
+						"KAHOY_DELETED_RESOURCES=r3",
+					})(cmd)
+				})
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				mcr.On("Start", expCmd).Once().Return(nil)
+				mcr.On("Wait", expCmd).Once().Return(nil)
+			},
+		},
+
+		"A failing hook should fail.": {
+			hooks: hooks,
+			execute: func(e hook.RunExecutor) error {
+				return e.BeforeRun(context.TODO())
+			},
+			mock: func(mcr *hookmock.CmdRunner) {
+				mcr.On("CombinedOutputPipe", mock.Anything).Once().Return(nopR, nil)
+				mcr.On("Start", mock.Anything).Once().Return(nil)
+				mcr.On("Wait", mock.Anything).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mcr := &hookmock.CmdRunner{}
+			test.mock(mcr)
+
+			// Execute.
+			e, err := hook.NewRunExecutor(hook.RunExecutorConfig{
+				Hooks:     test.hooks,
+				CmdRunner: mcr,
+			})
+			require.NoError(err)
+
+			err = test.execute(e)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mcr.AssertExpectations(t)
+		})
+	}
+}
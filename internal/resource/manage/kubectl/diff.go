@@ -11,9 +11,13 @@ import (
 	"path"
 	"strings"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
 	"github.com/slok/kahoy/internal/resource/manage"
+	"github.com/slok/kahoy/internal/tracing"
 )
 
 // FSManager knows how to manage resources on the fs.
@@ -50,7 +54,11 @@ type DiffManagerConfig struct {
 	CmdRunner                 CmdRunner
 	Out                       io.Writer
 	ErrOut                    io.Writer
-	Logger                    log.Logger
+	// Recorder will record when the diff has found changes.
+	Recorder report.Recorder
+	// Tracer is used to trace the kubectl invocations.
+	Tracer trace.Tracer
+	Logger log.Logger
 }
 
 func (c *DiffManagerConfig) defaults() error {
@@ -87,6 +95,14 @@ func (c *DiffManagerConfig) defaults() error {
 		c.FSManager = stdFSManager{}
 	}
 
+	if c.Recorder == nil {
+		c.Recorder = report.Noop
+	}
+
+	if c.Tracer == nil {
+		c.Tracer = tracing.Noop
+	}
+
 	return nil
 }
 
@@ -98,6 +114,8 @@ type diffManager struct {
 	fsManager   FSManager
 	out         io.Writer
 	errOut      io.Writer
+	recorder    report.Recorder
+	tracer      trace.Tracer
 	logger      log.Logger
 
 	applyArgs  []string
@@ -139,6 +157,8 @@ func NewDiffManager(config DiffManagerConfig) (manage.ResourceManager, error) {
 		fsManager:   config.FSManager,
 		out:         config.Out,
 		errOut:      config.ErrOut,
+		recorder:    config.Recorder,
+		tracer:      config.Tracer,
 		logger:      config.Logger,
 		applyArgs:   applyArgs,
 		deleteArgs:  deleteArgs,
@@ -146,6 +166,11 @@ func NewDiffManager(config DiffManagerConfig) (manage.ResourceManager, error) {
 }
 
 func (d diffManager) Apply(ctx context.Context, resources []model.Resource) error {
+	resources, err := d.withoutExistingCreateOnly(ctx, resources)
+	if err != nil {
+		return err
+	}
+
 	if len(resources) == 0 {
 		return nil
 	}
@@ -172,12 +197,13 @@ func (d diffManager) Apply(ctx context.Context, resources []model.Resource) erro
 	cmd.Stderr = &outErr
 
 	// Execute command.
-	err = d.cmdRunner.Run(cmd)
+	err = runTraced(ctx, d.tracer, d.cmdRunner, cmd, len(resources))
 	if err != nil {
 		exitErr, ok := err.(*exec.ExitError)
 		// No error if our error is 1 exit code, just changes on diff.
 		// Check: https://github.com/kubernetes/kubernetes/pull/87437
 		if ok && exitErr.ExitCode() < 2 {
+			d.recorder.RecordDiffChanges(ctx)
 			return nil
 		}
 
@@ -194,6 +220,49 @@ func (d diffManager) Apply(ctx context.Context, resources []model.Resource) erro
 	return nil
 }
 
+// withoutExistingCreateOnly removes the create-only resources that already exist on the
+// server, these are never changed after being created, so they don't have a diff.
+func (d diffManager) withoutExistingCreateOnly(ctx context.Context, resources []model.Resource) ([]model.Resource, error) {
+	createOnly := []model.K8sObject{}
+	for _, r := range resources {
+		if r.ApplyStrategy() == model.ApplyStrategyCreateOnly {
+			createOnly = append(createOnly, r.K8sObject)
+		}
+	}
+	if len(createOnly) == 0 {
+		return resources, nil
+	}
+
+	existingObjs, err := d.getResourcesFromAPIServer(ctx, createOnly)
+	if err != nil {
+		return nil, fmt.Errorf("could not get create-only resources from the apiserver: %w", err)
+	}
+
+	// The resources without namespace will use the default namespace of the context,
+	// so we match them with the existing ones of any namespace.
+	existing := map[string]bool{}
+	for _, obj := range existingObjs {
+		existing[diffObjectKey(obj, obj.GetNamespace())] = true
+		existing[diffObjectKey(obj, "")] = true
+	}
+
+	filtered := make([]model.Resource, 0, len(resources))
+	for _, r := range resources {
+		if r.ApplyStrategy() == model.ApplyStrategyCreateOnly && existing[diffObjectKey(r.K8sObject, r.K8sObject.GetNamespace())] {
+			d.logger.WithValues(log.Kv{"resource-id": r.ID}).Debugf("ignoring existing create-only resource on diff")
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+
+	return filtered, nil
+}
+
+func diffObjectKey(obj model.K8sObject, ns string) string {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return strings.Join([]string{gvk.Group, gvk.Kind, ns, obj.GetName()}, "/")
+}
+
 // Delete will get the diff for the deleted sources.
 // We can't do the diff for things that will be deleted using Kubectl, also we can't be sure of
 // the resources that are on the server, maybe some of them don't exist neither on the server.
@@ -261,6 +330,7 @@ func (d diffManager) Delete(ctx context.Context, resources []model.Resource) err
 			exitErr, ok := err.(*exec.ExitError)
 			// No error if our error is 1 exit code, just changes on diff.
 			if ok && exitErr.ExitCode() < 2 {
+				d.recorder.RecordDiffChanges(ctx)
 				continue
 			}
 
@@ -302,7 +372,7 @@ func (d diffManager) getResourcesFromAPIServer(ctx context.Context, objs []model
 	cmd.Stderr = &outErr
 
 	// Execute command.
-	err = d.cmdRunner.Run(cmd)
+	err = runTraced(ctx, d.tracer, d.cmdRunner, cmd, len(objs))
 	if err != nil {
 		for _, line := range strings.Split(outErr.String(), "\n") {
 			if line == "" {
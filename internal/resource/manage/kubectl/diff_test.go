@@ -15,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report/reportmock"
 	"github.com/slok/kahoy/internal/resource/manage/kubectl"
 	"github.com/slok/kahoy/internal/resource/manage/kubectl/kubectlmock"
 )
@@ -225,6 +226,164 @@ func TestDiffManagerApply(t *testing.T) {
 	}
 }
 
+func TestDiffManagerApplyCreateOnly(t *testing.T) {
+	tests := map[string]struct {
+		resources []model.Resource
+		mock      func(mke *kubectlmock.K8sObjectEncoder, mkd *kubectlmock.K8sObjectDecoder, mc *kubectlmock.CmdRunner)
+		expErr    bool
+	}{
+		"Having create-only resources that already exist, should not diff them.": {
+			resources: []model.Resource{
+				{ID: "test1", K8sObject: newK8sObject("test1", "ns1")},
+				{ID: "test2", K8sObject: newK8sObjectWithStrategy("test2", "create-only")},
+				{ID: "test3", K8sObject: newK8sObjectWithStrategy("test3", "create-only")},
+			},
+			mock: func(mke *kubectlmock.K8sObjectEncoder, mkd *kubectlmock.K8sObjectDecoder, mc *kubectlmock.CmdRunner) {
+				// Getting server state part.
+				expCreateOnly := []model.K8sObject{newK8sObjectWithStrategy("test2", "create-only"), newK8sObjectWithStrategy("test3", "create-only")}
+				mke.On("EncodeObjects", mock.Anything, expCreateOnly).Once().Return([]byte("test1"), nil)
+				expGet := expCmdMatcher(
+					[]string{"kubectl", "get", "--ignore-not-found=true", "--output", "yaml", "--filename", "-"},
+					"test1",
+				)
+				mc.On("Run", mock.MatchedBy(expGet)).Once().Return(nil)
+				mkd.On("DecodeObjects", mock.Anything, mock.Anything).Once().Return([]model.K8sObject{newK8sObject("test2", "ns1")}, nil)
+
+				// Diff part.
+				expDiff := []model.K8sObject{newK8sObject("test1", "ns1"), newK8sObjectWithStrategy("test3", "create-only")}
+				mke.On("EncodeObjects", mock.Anything, expDiff).Once().Return([]byte("test2"), nil)
+				exp := expCmdMatcher(
+					[]string{"kubectl", "diff", "--force-conflicts=true", "--server-side=true", "--filename", "-"},
+					"test2",
+				)
+				mc.On("Run", mock.MatchedBy(exp)).Once().Return(nil)
+			},
+		},
+
+		"Having only create-only resources that already exist, should not diff anything.": {
+			resources: []model.Resource{
+				{ID: "test1", K8sObject: newK8sObjectWithStrategy("test1", "create-only")},
+			},
+			mock: func(mke *kubectlmock.K8sObjectEncoder, mkd *kubectlmock.K8sObjectDecoder, mc *kubectlmock.CmdRunner) {
+				mke.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test1"), nil)
+				mc.On("Run", mock.Anything).Once().Return(nil)
+				mkd.On("DecodeObjects", mock.Anything, mock.Anything).Once().Return([]model.K8sObject{newK8sObject("test1", "ns1")}, nil)
+			},
+		},
+
+		"Having an error getting the create-only resources from the server, should fail.": {
+			resources: []model.Resource{
+				{ID: "test1", K8sObject: newK8sObjectWithStrategy("test1", "create-only")},
+			},
+			mock: func(mke *kubectlmock.K8sObjectEncoder, mkd *kubectlmock.K8sObjectDecoder, mc *kubectlmock.CmdRunner) {
+				mke.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test1"), nil)
+				mc.On("Run", mock.Anything).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			menc := &kubectlmock.K8sObjectEncoder{}
+			mdec := &kubectlmock.K8sObjectDecoder{}
+			mcmd := &kubectlmock.CmdRunner{}
+			test.mock(menc, mdec, mcmd)
+
+			// Prepare.
+			manager, err := kubectl.NewDiffManager(kubectl.DiffManagerConfig{
+				Out:         ioutil.Discard,
+				YAMLEncoder: menc,
+				YAMLDecoder: mdec,
+				CmdRunner:   mcmd,
+			})
+			require.NoError(err)
+
+			// Execute.
+			err = manager.Apply(context.TODO(), test.resources)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				menc.AssertExpectations(t)
+				mdec.AssertExpectations(t)
+				mcmd.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestDiffManagerApplyRecordChanges(t *testing.T) {
+	// Get a real exit error with the exit code of a diff with changes.
+	exitCode1Err := exec.Command("sh", "-c", "exit 1").Run()
+	exitCode2Err := exec.Command("sh", "-c", "exit 2").Run()
+
+	tests := map[string]struct {
+		cmdErr error
+		mock   func(mr *reportmock.Recorder)
+		expErr bool
+	}{
+		"Not having changes should not record diff changes.": {
+			cmdErr: nil,
+			mock:   func(mr *reportmock.Recorder) {},
+		},
+
+		"Having changes should record diff changes.": {
+			cmdErr: exitCode1Err,
+			mock: func(mr *reportmock.Recorder) {
+				mr.On("RecordDiffChanges", mock.Anything).Once()
+			},
+		},
+
+		"Having an error should not record diff changes.": {
+			cmdErr: exitCode2Err,
+			mock:   func(mr *reportmock.Recorder) {},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			menc := &kubectlmock.K8sObjectEncoder{}
+			mcmd := &kubectlmock.CmdRunner{}
+			mr := &reportmock.Recorder{}
+			menc.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test"), nil)
+			mcmd.On("Run", mock.Anything).Once().Return(test.cmdErr)
+			test.mock(mr)
+
+			// Prepare.
+			manager, err := kubectl.NewDiffManager(kubectl.DiffManagerConfig{
+				Out:         ioutil.Discard,
+				YAMLEncoder: menc,
+				YAMLDecoder: &kubectlmock.K8sObjectDecoder{},
+				CmdRunner:   mcmd,
+				Recorder:    mr,
+			})
+			require.NoError(err)
+
+			// Execute.
+			err = manager.Apply(context.TODO(), []model.Resource{{ID: "test1", K8sObject: newK8sObject("test1", "ns1")}})
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mr.AssertExpectations(t)
+		})
+	}
+}
+
 func TestDiffManagerDelete(t *testing.T) {
 	tests := map[string]struct {
 		config    kubectl.DiffManagerConfig
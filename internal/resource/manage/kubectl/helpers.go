@@ -4,9 +4,14 @@ import (
 	"context"
 	"io"
 	"os/exec"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/tracing"
 )
 
 // CmdRunner knows how to run exec.Cmd commands. Normally we use this
@@ -49,6 +54,31 @@ func (s stdCmdRunner) StdoutPipe(c *exec.Cmd) (io.ReadCloser, error) {
 	return c.StdoutPipe()
 }
 
+// startKubectlSpan starts the span of a kubectl invocation.
+func startKubectlSpan(ctx context.Context, tracer trace.Tracer, cmd *exec.Cmd, resources int) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "kubectl", trace.WithAttributes(
+		attribute.String("kahoy.cmd", strings.Join(cmd.Args, " ")),
+		attribute.Int("kahoy.resources", resources),
+	))
+}
+
+// endKubectlSpan ends the span of a kubectl invocation with the exit code of the command.
+func endKubectlSpan(span trace.Span, cmd *exec.Cmd, err error) {
+	exitCode := -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
+	tracing.EndSpan(span, err, attribute.Int("kahoy.exit_code", exitCode))
+}
+
+// runTraced runs the command tracing the kubectl invocation.
+func runTraced(ctx context.Context, tracer trace.Tracer, runner CmdRunner, cmd *exec.Cmd, resources int) error {
+	_, span := startKubectlSpan(ctx, tracer, cmd, resources)
+	err := runner.Run(cmd)
+	endKubectlSpan(span, cmd, err)
+	return err
+}
+
 // K8sObjectEncoder knows how to encode K8s objects into Raw Kubernetes compatible formats.
 type K8sObjectEncoder interface {
 	EncodeObjects(ctx context.Context, objs []model.K8sObject) ([]byte, error)
@@ -4,15 +4,22 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/slok/kahoy/internal/internalerrors"
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
 	"github.com/slok/kahoy/internal/resource/manage"
+	"github.com/slok/kahoy/internal/tracing"
 )
 
 // ManagerConfig is the configuration for NewManager.
@@ -22,11 +29,18 @@ type ManagerConfig struct {
 	KubeContext               string
 	KubeFieldManager          string
 	DisableKubeForceConflicts bool
-	YAMLEncoder               K8sObjectEncoder
-	CmdRunner                 CmdRunner
-	Out                       io.Writer
-	ErrOut                    io.Writer
-	Logger                    log.Logger
+	// KubeServerDryRun will execute all the commands with server dry-run, so the changes
+	// are validated by the apiserver (e.g: field ownership conflicts) without persisting them.
+	KubeServerDryRun bool
+	YAMLEncoder      K8sObjectEncoder
+	CmdRunner        CmdRunner
+	Out              io.Writer
+	ErrOut           io.Writer
+	// Recorder will record the result of each executed resource parsed from kubectl output.
+	Recorder report.Recorder
+	// Tracer is used to trace the kubectl invocations.
+	Tracer trace.Tracer
+	Logger log.Logger
 }
 
 func (c *ManagerConfig) defaults() error {
@@ -47,6 +61,14 @@ func (c *ManagerConfig) defaults() error {
 	}
 	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "kubectl.Manager"})
 
+	if c.Tracer == nil {
+		c.Tracer = tracing.Noop
+	}
+
+	if c.Recorder == nil {
+		c.Recorder = report.Noop
+	}
+
 	if c.CmdRunner == nil {
 		c.CmdRunner = newStdCmdRunner(c.Logger)
 	}
@@ -64,10 +86,15 @@ type manager struct {
 	cmdRunner   CmdRunner
 	out         io.Writer
 	errOut      io.Writer
+	recorder    report.Recorder
+	tracer      trace.Tracer
 	logger      log.Logger
 
-	applyArgs  []string
-	deleteArgs []string
+	applyArgs        []string
+	createArgs       []string
+	replaceArgs      []string
+	forceReplaceArgs []string
+	deleteArgs       []string
 }
 
 // NewManager returns a resource Manager based on Kubctl that will apply changes.
@@ -84,6 +111,7 @@ func NewManager(config ManagerConfig) (manage.ResourceManager, error) {
 		withForceConflicts(!config.DisableKubeForceConflicts),
 		withFieldManager(config.KubeFieldManager),
 		withServerSide(true),
+		withServerDryRun(config.KubeServerDryRun),
 		withStdIn(),
 	})
 
@@ -93,32 +121,138 @@ func NewManager(config ManagerConfig) (manage.ResourceManager, error) {
 		withConfig(config.KubeConfig),
 		withIgnoreNotFound(true),
 		withWait(false),
+		withServerDryRun(config.KubeServerDryRun),
+		withStdIn(),
+	})
+
+	createArgs := newKubectlCmdArgs([]kubectlCmdOption{
+		withCreateCmd(),
+		withContext(config.KubeContext),
+		withConfig(config.KubeConfig),
+		withServerDryRun(config.KubeServerDryRun),
+		withStdIn(),
+	})
+
+	replaceArgs := newKubectlCmdArgs([]kubectlCmdOption{
+		withReplaceCmd(),
+		withContext(config.KubeContext),
+		withConfig(config.KubeConfig),
+		withServerDryRun(config.KubeServerDryRun),
+		withStdIn(),
+	})
+
+	forceReplaceArgs := newKubectlCmdArgs([]kubectlCmdOption{
+		withReplaceCmd(),
+		withContext(config.KubeContext),
+		withConfig(config.KubeConfig),
+		withForce(true),
+		withServerDryRun(config.KubeServerDryRun),
 		withStdIn(),
 	})
 
 	return manager{
-		kubectlCmd:  config.KubectlCmd,
-		yamlEncoder: config.YAMLEncoder,
-		cmdRunner:   config.CmdRunner,
-		out:         config.Out,
-		errOut:      config.ErrOut,
-		logger:      config.Logger,
-		applyArgs:   applyArgs,
-		deleteArgs:  deleteArgs,
+		kubectlCmd:       config.KubectlCmd,
+		yamlEncoder:      config.YAMLEncoder,
+		cmdRunner:        config.CmdRunner,
+		out:              config.Out,
+		errOut:           config.ErrOut,
+		recorder:         config.Recorder,
+		tracer:           config.Tracer,
+		logger:           config.Logger,
+		applyArgs:        applyArgs,
+		createArgs:       createArgs,
+		replaceArgs:      replaceArgs,
+		forceReplaceArgs: forceReplaceArgs,
+		deleteArgs:       deleteArgs,
 	}, nil
 }
 
+// NewReplacer returns a resource Replacer based on Kubectl that will delete
+// the resources, wait until they are deleted and create them again.
+func NewReplacer(config ManagerConfig) (manage.ResourceReplacer, error) {
+	m, err := NewManager(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.(manager), nil
+}
+
 func (m manager) Apply(ctx context.Context, resources []model.Resource) error {
-	err := m.execute(ctx, resources, m.applyArgs)
+	// Partition resources by their apply strategy, each strategy
+	// needs a different kubectl invocation.
+	resByStrategy := map[model.ApplyStrategy][]model.Resource{}
+	for _, r := range resources {
+		strategy := r.ApplyStrategy()
+		resByStrategy[strategy] = append(resByStrategy[strategy], r)
+	}
+
+	createOnlyRes := resByStrategy[model.ApplyStrategyCreateOnly]
+	replaceRes := resByStrategy[model.ApplyStrategyReplace]
+
+	// Server-side apply (default).
+	err := m.execute(ctx, model.OperationApply, resByStrategy[model.ApplyStrategyServerSide], m.applyArgs)
+	if err != nil {
+		notExecuted := append(append([]model.Resource{}, createOnlyRes...), replaceRes...)
+		return fmt.Errorf("apply cmd failed: %w", manage.WithNotExecutedResources(err, notExecuted))
+	}
+
+	// Create only, the already existing resources are ignored.
+	err = m.create(ctx, createOnlyRes)
+	if err != nil {
+		return fmt.Errorf("create cmd failed: %w", manage.WithNotExecutedResources(err, replaceRes))
+	}
+
+	err = m.replace(ctx, replaceRes)
 	if err != nil {
-		return fmt.Errorf("apply cmd failed: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// replace first creates the missing resources so the replace doesn't fail, and then replaces
+// all of them. Only the final outcome of each resource is recorded, the ones that have been
+// created are recorded as created.
+func (m manager) replace(ctx context.Context, resources []model.Resource) error {
+	if len(resources) == 0 {
+		return nil
+	}
+
+	created := newOutcomeParser(resources)
+	err := m.executeCmd(ctx, resources, m.createArgs, created)
+	if err != nil && !errors.Is(err, errAlreadyExists) {
+		failedIDs := m.recordResults(ctx, model.OperationApply, resources, created, err)
+		return fmt.Errorf("create cmd failed: %w", manage.ResourcesError{ResourceIDs: failedIDs, Err: err})
+	}
+
+	outcomes := newOutcomeParser(resources)
+	err = m.executeCmd(ctx, resources, m.replaceArgs, outcomes)
+	for id, outcome := range created.outcomes {
+		if outcome == model.ResourceOutcomeCreated {
+			outcomes.outcomes[id] = outcome
+		}
+	}
+	failedIDs := m.recordResults(ctx, model.OperationApply, resources, outcomes, err)
+	if err != nil {
+		return fmt.Errorf("replace cmd failed: %w", manage.ResourcesError{ResourceIDs: failedIDs, Err: err})
+	}
+
+	return nil
+}
+
+// create will create the resources ignoring the ones that already exist.
+func (m manager) create(ctx context.Context, resources []model.Resource) error {
+	err := m.execute(ctx, model.OperationApply, resources, m.createArgs)
+	if err != nil && !errors.Is(err, errAlreadyExists) {
+		return err
 	}
 
 	return nil
 }
 
 func (m manager) Delete(ctx context.Context, resources []model.Resource) error {
-	err := m.execute(ctx, resources, m.deleteArgs)
+	err := m.execute(ctx, model.OperationDelete, resources, m.deleteArgs)
 	if err != nil {
 		return fmt.Errorf("delete cmd failed: %w", err)
 	}
@@ -126,11 +260,61 @@ func (m manager) Delete(ctx context.Context, resources []model.Resource) error {
 	return nil
 }
 
-func (m manager) execute(ctx context.Context, resources []model.Resource, cmdArgs []string) error {
+func (m manager) Replace(ctx context.Context, resources []model.Resource) error {
+	err := m.execute(ctx, model.OperationApply, resources, m.forceReplaceArgs)
+	if err != nil {
+		return fmt.Errorf("replace cmd failed: %w", err)
+	}
+
+	return nil
+}
+
+func (m manager) execute(ctx context.Context, op model.Operation, resources []model.Resource, cmdArgs []string) error {
 	if len(resources) == 0 {
 		return nil
 	}
 
+	outcomes := newOutcomeParser(resources)
+	err := m.executeCmd(ctx, resources, cmdArgs, outcomes)
+	failedIDs := m.recordResults(ctx, op, resources, outcomes, err)
+	if err != nil && !errors.Is(err, errAlreadyExists) {
+		return manage.ResourcesError{ResourceIDs: failedIDs, Err: err}
+	}
+
+	return err
+}
+
+// recordResults records the result of each executed resource. The resources without
+// an outcome on kubectl output are the ones that failed, or if the execution didn't
+// fail, the ones that kubectl didn't report (e.g: ignored not found on deletes).
+// It returns the IDs of the failed resources.
+func (m manager) recordResults(ctx context.Context, op model.Operation, resources []model.Resource, outcomes *outcomeParser, err error) []string {
+	failedIDs := []string{}
+	for _, r := range resources {
+		result := model.ResourceResult{Resource: r, Operation: op}
+		outcome, ok := outcomes.outcomes[r.ID]
+		switch {
+		case ok:
+			result.Outcome = outcome
+		case err == nil && op == model.OperationDelete:
+			result.Outcome = model.ResourceOutcomeNotFound
+		case err == nil:
+			result.Outcome = model.ResourceOutcomeApplied
+		case errors.Is(err, errAlreadyExists):
+			result.Outcome = model.ResourceOutcomeUnchanged
+		default:
+			result.Outcome = model.ResourceOutcomeFailed
+			result.Error = err.Error()
+			failedIDs = append(failedIDs, r.ID)
+		}
+
+		m.recorder.RecordResourceResult(ctx, result)
+	}
+
+	return failedIDs
+}
+
+func (m manager) executeCmd(ctx context.Context, resources []model.Resource, cmdArgs []string, outcomes *outcomeParser) error {
 	logger := m.logger.WithValues(log.Kv{"ext-cmd": "kubectl"})
 
 	objs := make([]model.K8sObject, 0, len(resources))
@@ -156,28 +340,210 @@ func (m manager) execute(ctx context.Context, resources []model.Resource, cmdArg
 	}
 
 	// Execute command command in streaming mode with our logger.
+	_, span := startKubectlSpan(ctx, m.tracer, cmd, len(resources))
 	err = m.cmdRunner.Start(cmd)
 	if err != nil {
+		endKubectlSpan(span, cmd, err)
 		return fmt.Errorf("error while starting command: %w", err)
 	}
 
 	// Stream out.
 	stream := bufio.NewScanner(stdoutStream)
 	for stream.Scan() {
-		logger.Infof(stream.Text())
+		line := stream.Text()
+		logger.Infof(line)
+		outcomes.parse(line)
 	}
 
 	err = m.cmdRunner.Wait(cmd)
+	endKubectlSpan(span, cmd, err)
 	if err != nil {
 		stderrData := errOut.String()
+
+		// If all the errors are because of already existing resources, we don't treat them as
+		// regular errors, the caller will decide what to do.
+		if onlyAlreadyExistsErr(stderrData) {
+			logger.Debugf("resources already exist")
+			return fmt.Errorf("%w: %s", errAlreadyExists, err)
+		}
+
 		for _, line := range strings.Split(stderrData, "\n") {
 			if line == "" {
 				continue
 			}
 			logger.Errorf(line)
 		}
+		if conflicts := parseConflicts(stderrData); len(conflicts) > 0 {
+			return fmt.Errorf("%w: error on cmd execution: %s: %s", manage.ConflictError{Conflicts: conflicts}, stderrData, err)
+		}
+		if immutableErrRegex.MatchString(stderrData) {
+			return fmt.Errorf("%w: error on cmd execution: %s: %s", internalerrors.ErrImmutable, stderrData, err)
+		}
+		if transientErrRegex.MatchString(stderrData) {
+			return fmt.Errorf("%w: error on cmd execution: %s: %s", internalerrors.ErrTransient, stderrData, err)
+		}
 		return fmt.Errorf("error on cmd execution: %s: %w", stderrData, err)
 	}
 
 	return nil
 }
+
+// immutableErrRegex matches the errors returned by the apiserver when a change is rejected
+// because it's changing immutable fields (e.g: Job templates, Service `clusterIP`, Deployment
+// selectors or StatefulSet spec fields).
+var immutableErrRegex = regexp.MustCompile(`field is immutable|is immutable after creation|updates to statefulset spec for fields other than`)
+
+// transientErrRegex matches the errors returned by kubectl or the apiserver that are temporary
+// and the same change could succeed if retried (e.g: throttling, admission webhook timeouts,
+// optimistic concurrency conflicts or connection errors).
+var transientErrRegex = regexp.MustCompile(`(?i)too many requests|TooManyRequests|` +
+	`the object has been modified; please apply your changes to the latest version|` +
+	`failed calling webhook.*(timeout|deadline exceeded|connection refused)|` +
+	`connection reset by peer|connection refused|i/o timeout|TLS handshake timeout|unexpected EOF|http2: client connection lost|` +
+	`the server is currently unable to handle the request|ServiceUnavailable|etcdserver: request timed out|` +
+	`Timeout: request did not complete within`)
+
+var errAlreadyExists = errors.New("already exists")
+
+var (
+	outcomeLineRegex        = regexp.MustCompile(`^([^\s/]+)/(\S+) (serverside-applied|created|configured|unchanged|replaced)$`)
+	deletedOutcomeLineRegex = regexp.MustCompile(`^(\S+) "([^"]+)" deleted$`)
+)
+
+var kubectlOutcomes = map[string]model.ResourceOutcome{
+	"serverside-applied": model.ResourceOutcomeApplied,
+	"created":            model.ResourceOutcomeCreated,
+	"configured":         model.ResourceOutcomeConfigured,
+	"unchanged":          model.ResourceOutcomeUnchanged,
+	"replaced":           model.ResourceOutcomeConfigured,
+	"deleted":            model.ResourceOutcomeDeleted,
+}
+
+// outcomeParser parses kubectl output lines into the outcomes of the executed resources, e.g:
+//
+//	deployment.apps/app1 serverside-applied
+//	configmap/app1 created
+//	deployment.apps "app1" deleted
+//
+// kubectl output doesn't have the namespace of the resource, but it processes the resources
+// in the same order they are received, so the lines are matched with the resources in order.
+type outcomeParser struct {
+	resources map[string][]string
+	cursors   map[string]int
+	outcomes  map[string]model.ResourceOutcome
+}
+
+func newOutcomeParser(resources []model.Resource) *outcomeParser {
+	p := &outcomeParser{
+		resources: map[string][]string{},
+		cursors:   map[string]int{},
+		outcomes:  map[string]model.ResourceOutcome{},
+	}
+
+	for _, r := range resources {
+		if r.K8sObject == nil {
+			continue
+		}
+		gvk := r.K8sObject.GetObjectKind().GroupVersionKind()
+		kind := strings.ToLower(gvk.Kind)
+		if gvk.Group != "" {
+			kind = kind + "." + gvk.Group
+		}
+		key := kind + "/" + r.K8sObject.GetName()
+		p.resources[key] = append(p.resources[key], r.ID)
+	}
+
+	return p
+}
+
+func (p *outcomeParser) parse(line string) {
+	line = strings.TrimSpace(line)
+	m := outcomeLineRegex.FindStringSubmatch(line)
+	if m == nil {
+		m = deletedOutcomeLineRegex.FindStringSubmatch(line)
+		if m == nil {
+			return
+		}
+		m = append(m, "deleted")
+	}
+
+	// Each action has its own cursor, a single command could output multiple lines
+	// for the same resource (e.g: `replace --force` deletes and replaces).
+	key := m[1] + "/" + m[2]
+	cursorKey := key + " " + m[3]
+	ids := p.resources[key]
+	i := p.cursors[cursorKey]
+	if i >= len(ids) {
+		return
+	}
+	p.cursors[cursorKey] = i + 1
+	p.outcomes[ids[i]] = kubectlOutcomes[m[3]]
+}
+
+var (
+	conflictManagerRegex = regexp.MustCompile(`conflicts? with "([^"]+)"(?: using [^:]+)?:(?: (.+))?$`)
+	conflictFieldRegex   = regexp.MustCompile(`^- (.+)$`)
+)
+
+// parseConflicts parses the server-side apply field ownership conflicts returned by kubectl, e.g:
+//
+//	error: Apply failed with 1 conflict: conflict with "hpa" using apps/v1: .spec.replicas
+//
+// Or with multiple conflicts:
+//
+//	error: Apply failed with 2 conflicts: conflicts with "helm" using apps/v1:
+//	- .spec.replicas
+//	- .spec.template.spec.containers[name="app"].image
+func parseConflicts(stderrData string) []model.FieldConflict {
+	conflicts := []model.FieldConflict{}
+	var current *model.FieldConflict
+	for _, line := range strings.Split(stderrData, "\n") {
+		line = strings.TrimSpace(line)
+
+		// Fields of the current manager.
+		if current != nil {
+			if m := conflictFieldRegex.FindStringSubmatch(line); m != nil {
+				current.Fields = append(current.Fields, m[1])
+				continue
+			}
+			conflicts = append(conflicts, *current)
+			current = nil
+		}
+
+		m := conflictManagerRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		// Single field conflict on the same line.
+		if m[2] != "" {
+			conflicts = append(conflicts, model.FieldConflict{Manager: m[1], Fields: []string{m[2]}})
+			continue
+		}
+
+		current = &model.FieldConflict{Manager: m[1]}
+	}
+
+	if current != nil {
+		conflicts = append(conflicts, *current)
+	}
+
+	return conflicts
+}
+
+// onlyAlreadyExistsErr returns true if all the errors returned by kubectl are because
+// the resources already exist.
+func onlyAlreadyExistsErr(stderrData string) bool {
+	found := false
+	for _, line := range strings.Split(stderrData, "\n") {
+		if line == "" {
+			continue
+		}
+		if !strings.Contains(line, "(AlreadyExists)") {
+			return false
+		}
+		found = true
+	}
+
+	return found
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"errors"
 	"io/ioutil"
+	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"github.com/slok/kahoy/internal/internalerrors"
 	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report/reportmock"
+	"github.com/slok/kahoy/internal/resource/manage"
 	"github.com/slok/kahoy/internal/resource/manage/kubectl"
 	"github.com/slok/kahoy/internal/resource/manage/kubectl/kubectlmock"
 )
@@ -57,6 +63,79 @@ func TestManagerApply(t *testing.T) {
 			},
 		},
 
+		"Having resources with different apply strategies should apply each strategy with the correct cmd.": {
+			resources: []model.Resource{
+				{ID: "test1", K8sObject: newK8sObjectWithStrategy("test1", "")},
+				{ID: "test2", K8sObject: newK8sObjectWithStrategy("test2", "create-only")},
+				{ID: "test3", K8sObject: newK8sObjectWithStrategy("test3", "replace")},
+				{ID: "test4", K8sObject: newK8sObjectWithStrategy("test4", "server-side")},
+			},
+			mock: func(mk *kubectlmock.K8sObjectEncoder, mc *kubectlmock.CmdRunner) {
+				// Server-side.
+				expSSA := []model.K8sObject{newK8sObjectWithStrategy("test1", ""), newK8sObjectWithStrategy("test4", "server-side")}
+				mk.On("EncodeObjects", mock.Anything, expSSA).Once().Return([]byte("ssa"), nil)
+				exp := expCmdMatcher([]string{"kubectl", "apply", "--force-conflicts=true", "--server-side=true", "--filename", "-"}, "ssa")
+				mc.On("StdoutPipe", mock.MatchedBy(exp)).Once().Return(nopRC, nil)
+				mc.On("Start", mock.MatchedBy(exp)).Once().Return(nil)
+				mc.On("Wait", mock.MatchedBy(exp)).Once().Return(nil)
+
+				// Create only.
+				expCreate := []model.K8sObject{newK8sObjectWithStrategy("test2", "create-only")}
+				mk.On("EncodeObjects", mock.Anything, expCreate).Once().Return([]byte("create"), nil)
+				exp = expCmdMatcher([]string{"kubectl", "create", "--filename", "-"}, "create")
+				mc.On("StdoutPipe", mock.MatchedBy(exp)).Once().Return(nopRC, nil)
+				mc.On("Start", mock.MatchedBy(exp)).Once().Return(nil)
+				mc.On("Wait", mock.MatchedBy(exp)).Once().Return(nil)
+
+				// Replace (create missing and replace).
+				expReplace := []model.K8sObject{newK8sObjectWithStrategy("test3", "replace")}
+				mk.On("EncodeObjects", mock.Anything, expReplace).Times(2).Return([]byte("replace"), nil)
+				exp = expCmdMatcher([]string{"kubectl", "create", "--filename", "-"}, "replace")
+				mc.On("StdoutPipe", mock.MatchedBy(exp)).Once().Return(nopRC, nil)
+				mc.On("Start", mock.MatchedBy(exp)).Once().Return(nil)
+				mc.On("Wait", mock.MatchedBy(exp)).Once().Return(nil)
+				exp = expCmdMatcher([]string{"kubectl", "replace", "--filename", "-"}, "replace")
+				mc.On("StdoutPipe", mock.MatchedBy(exp)).Once().Return(nopRC, nil)
+				mc.On("Start", mock.MatchedBy(exp)).Once().Return(nil)
+				mc.On("Wait", mock.MatchedBy(exp)).Once().Return(nil)
+			},
+		},
+
+		"Having create only resources that already exist should be ignored.": {
+			resources: []model.Resource{
+				{ID: "test1", K8sObject: newK8sObjectWithStrategy("test1", "create-only")},
+			},
+			mock: func(mk *kubectlmock.K8sObjectEncoder, mc *kubectlmock.CmdRunner) {
+				mk.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test"), nil)
+
+				exp := expCmdMatcherWithRetStderr(
+					[]string{"kubectl", "create", "--filename", "-"},
+					`Error from server (AlreadyExists): error when creating "STDIN": secrets "test1" already exists`,
+				)
+				mc.On("StdoutPipe", mock.MatchedBy(exp)).Once().Return(nopRC, nil)
+				mc.On("Start", mock.MatchedBy(exp)).Once().Return(nil)
+				mc.On("Wait", mock.MatchedBy(exp)).Once().Return(errors.New("whatever"))
+			},
+		},
+
+		"Having create only resources that fail with other errors than already existing, should fail.": {
+			resources: []model.Resource{
+				{ID: "test1", K8sObject: newK8sObjectWithStrategy("test1", "create-only")},
+			},
+			mock: func(mk *kubectlmock.K8sObjectEncoder, mc *kubectlmock.CmdRunner) {
+				mk.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test"), nil)
+
+				exp := expCmdMatcherWithRetStderr(
+					[]string{"kubectl", "create", "--filename", "-"},
+					"Error from server (AlreadyExists): error when creating \"STDIN\": secrets \"test1\" already exists\nError from server (Forbidden): whatever",
+				)
+				mc.On("StdoutPipe", mock.MatchedBy(exp)).Once().Return(nopRC, nil)
+				mc.On("Start", mock.MatchedBy(exp)).Once().Return(nil)
+				mc.On("Wait", mock.MatchedBy(exp)).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+
 		"Having an error while encoding objects should stop the execution and fail.": {
 			resources: []model.Resource{{ID: "test1"}},
 			mock: func(mk *kubectlmock.K8sObjectEncoder, mc *kubectlmock.CmdRunner) {
@@ -148,6 +227,25 @@ func TestManagerApply(t *testing.T) {
 			},
 		},
 
+		"Having server dry-run enabled, should set the cmd flag.": {
+			config: kubectl.ManagerConfig{
+				DisableKubeForceConflicts: true,
+				KubeServerDryRun:          true,
+			},
+			resources: []model.Resource{{ID: "test1"}},
+			mock: func(mk *kubectlmock.K8sObjectEncoder, mc *kubectlmock.CmdRunner) {
+				mk.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test"), nil)
+
+				exp := expCmdMatcher(
+					[]string{"kubectl", "apply", "--force-conflicts=false", "--server-side=true", "--dry-run=server", "--filename", "-"},
+					"test",
+				)
+				mc.On("StdoutPipe", mock.MatchedBy(exp)).Once().Return(nopRC, nil)
+				mc.On("Start", mock.MatchedBy(exp)).Once().Return(nil)
+				mc.On("Wait", mock.MatchedBy(exp)).Once().Return(nil)
+			},
+		},
+
 		"Having field manager set, should set the cmd flag.": {
 			config: kubectl.ManagerConfig{
 				KubeFieldManager: "whatever",
@@ -197,6 +295,15 @@ func TestManagerApply(t *testing.T) {
 		})
 	}
 }
+
+func newK8sObjectWithStrategy(name, strategy string) model.K8sObject {
+	obj := newK8sObject(name, "ns1")
+	if strategy != "" {
+		obj.SetAnnotations(map[string]string{"kahoy.slok.dev/apply-strategy": strategy})
+	}
+	return obj
+}
+
 func TestManagerDelete(t *testing.T) {
 	tests := map[string]struct {
 		config    kubectl.ManagerConfig
@@ -335,3 +442,490 @@ func TestManagerDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestManagerApplyImmutableError(t *testing.T) {
+	tests := map[string]struct {
+		stderr          string
+		expErrImmutable bool
+	}{
+		"A regular error should not be an immutable error.": {
+			stderr:          "something went wrong",
+			expErrImmutable: false,
+		},
+
+		"An immutable field error should be an immutable error.": {
+			stderr:          `The Job "test1" is invalid: spec.template: Invalid value: core.PodTemplateSpec{}: field is immutable`,
+			expErrImmutable: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			menc := &kubectlmock.K8sObjectEncoder{}
+			mcmd := &kubectlmock.CmdRunner{}
+			menc.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test"), nil)
+			mcmd.On("StdoutPipe", mock.Anything).Once().Return(nopRC, nil)
+			mcmd.On("Start", mock.Anything).Once().Return(nil)
+			mcmd.On("Wait", mock.Anything).Once().Run(func(args mock.Arguments) {
+				cmd := args.Get(0).(*exec.Cmd)
+				_, _ = cmd.Stderr.Write([]byte(test.stderr))
+			}).Return(errors.New("whatever"))
+
+			// Prepare.
+			manager, err := kubectl.NewManager(kubectl.ManagerConfig{
+				Out:         ioutil.Discard,
+				YAMLEncoder: menc,
+				CmdRunner:   mcmd,
+			})
+			require.NoError(err)
+
+			// Execute.
+			err = manager.Apply(context.TODO(), []model.Resource{{ID: "test1"}})
+
+			// Check.
+			require.Error(err)
+			assert.Equal(test.expErrImmutable, errors.Is(err, internalerrors.ErrImmutable))
+		})
+	}
+}
+
+func TestManagerApplyTransientError(t *testing.T) {
+	tests := map[string]struct {
+		stderr          string
+		expErrTransient bool
+	}{
+		"A regular error should not be a transient error.": {
+			stderr:          `The Deployment "test1" is invalid: spec.replicas: Invalid value: -1`,
+			expErrTransient: false,
+		},
+
+		"An apiserver throttling error should be a transient error.": {
+			stderr:          `Error from server (TooManyRequests): the server has received too many requests and has asked us to try again later`,
+			expErrTransient: true,
+		},
+
+		"An optimistic concurrency error should be a transient error.": {
+			stderr:          `Operation cannot be fulfilled on deployments.apps "test1": the object has been modified; please apply your changes to the latest version and try again`,
+			expErrTransient: true,
+		},
+
+		"A connection error should be a transient error.": {
+			stderr:          `Unable to connect to the server: dial tcp 10.0.0.1:443: i/o timeout`,
+			expErrTransient: true,
+		},
+
+		"An admission webhook timeout should be a transient error.": {
+			stderr:          `Internal error occurred: failed calling webhook "validate.example.com": Post "https://svc.ns.svc:443/validate": context deadline exceeded`,
+			expErrTransient: true,
+		},
+
+		"An unavailable apiserver should be a transient error.": {
+			stderr:          `Error from server (ServiceUnavailable): the server is currently unable to handle the request`,
+			expErrTransient: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			menc := &kubectlmock.K8sObjectEncoder{}
+			mcmd := &kubectlmock.CmdRunner{}
+			menc.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test"), nil)
+			mcmd.On("StdoutPipe", mock.Anything).Once().Return(nopRC, nil)
+			mcmd.On("Start", mock.Anything).Once().Return(nil)
+			mcmd.On("Wait", mock.Anything).Once().Run(func(args mock.Arguments) {
+				cmd := args.Get(0).(*exec.Cmd)
+				_, _ = cmd.Stderr.Write([]byte(test.stderr))
+			}).Return(errors.New("whatever"))
+
+			// Prepare.
+			manager, err := kubectl.NewManager(kubectl.ManagerConfig{
+				Out:         ioutil.Discard,
+				YAMLEncoder: menc,
+				CmdRunner:   mcmd,
+			})
+			require.NoError(err)
+
+			// Execute.
+			err = manager.Apply(context.TODO(), []model.Resource{{ID: "test1"}})
+
+			// Check.
+			require.Error(err)
+			assert.Equal(test.expErrTransient, errors.Is(err, internalerrors.ErrTransient))
+		})
+	}
+}
+
+func TestManagerResults(t *testing.T) {
+	newConfigMap := func(name string) model.K8sObject {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "ns1"},
+		}}
+	}
+
+	tests := map[string]struct {
+		delete      bool
+		resources   []model.Resource
+		stdout      string
+		stderr      string
+		cmdErr      error
+		expOutcomes map[string]model.ResourceOutcome
+	}{
+		"Applied resources should be matched by kind and name in order.": {
+			resources: []model.Resource{
+				{ID: "r1", K8sObject: newK8sObject("test1", "ns1")},
+				{ID: "r2", K8sObject: newK8sObject("test1", "ns2")},
+				{ID: "r3", K8sObject: newConfigMap("test1")},
+			},
+			stdout: "deployment.apps/test1 serverside-applied\ndeployment.apps/test1 serverside-applied\nconfigmap/test1 serverside-applied\n",
+			expOutcomes: map[string]model.ResourceOutcome{
+				"r1": model.ResourceOutcomeApplied,
+				"r2": model.ResourceOutcomeApplied,
+				"r3": model.ResourceOutcomeApplied,
+			},
+		},
+
+		"On a failed apply, the resources without outcome should be the failed ones.": {
+			resources: []model.Resource{
+				{ID: "r1", K8sObject: newK8sObject("test1", "ns1")},
+				{ID: "r2", K8sObject: newK8sObject("test2", "ns1")},
+			},
+			stdout: "deployment.apps/test1 serverside-applied\n",
+			stderr: `Error from server (Invalid): error when creating "STDIN": Deployment.apps "test2" is invalid`,
+			cmdErr: errors.New("whatever"),
+			expOutcomes: map[string]model.ResourceOutcome{
+				"r1": model.ResourceOutcomeApplied,
+				"r2": model.ResourceOutcomeFailed,
+			},
+		},
+
+		"Deleted resources without outcome should be not found.": {
+			delete: true,
+			resources: []model.Resource{
+				{ID: "r1", K8sObject: newK8sObject("test1", "ns1")},
+				{ID: "r2", K8sObject: newK8sObject("test2", "ns1")},
+			},
+			stdout: `deployment.apps "test1" deleted` + "\n",
+			expOutcomes: map[string]model.ResourceOutcome{
+				"r1": model.ResourceOutcomeDeleted,
+				"r2": model.ResourceOutcomeNotFound,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			menc := &kubectlmock.K8sObjectEncoder{}
+			mcmd := &kubectlmock.CmdRunner{}
+			mr := &reportmock.Recorder{}
+			menc.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test"), nil)
+			mcmd.On("StdoutPipe", mock.Anything).Once().Return(ioutil.NopCloser(strings.NewReader(test.stdout)), nil)
+			mcmd.On("Start", mock.Anything).Once().Return(nil)
+			mcmd.On("Wait", mock.Anything).Once().Run(func(args mock.Arguments) {
+				cmd := args.Get(0).(*exec.Cmd)
+				_, _ = cmd.Stderr.Write([]byte(test.stderr))
+			}).Return(test.cmdErr)
+
+			gotOutcomes := map[string]model.ResourceOutcome{}
+			mr.On("RecordResourceResult", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				r := args.Get(1).(model.ResourceResult)
+				gotOutcomes[r.Resource.ID] = r.Outcome
+				assert.Equal(r.Outcome == model.ResourceOutcomeFailed, r.Error != "")
+			})
+
+			// Prepare.
+			manager, err := kubectl.NewManager(kubectl.ManagerConfig{
+				Out:         ioutil.Discard,
+				YAMLEncoder: menc,
+				CmdRunner:   mcmd,
+				Recorder:    mr,
+			})
+			require.NoError(err)
+
+			// Execute.
+			if test.delete {
+				err = manager.Delete(context.TODO(), test.resources)
+			} else {
+				err = manager.Apply(context.TODO(), test.resources)
+			}
+
+			// Check.
+			assert.Equal(test.cmdErr != nil, err != nil)
+			assert.Equal(test.expOutcomes, gotOutcomes)
+
+			// The error should know the failed resources.
+			if err != nil {
+				expFailed := []model.Resource{}
+				for _, r := range test.resources {
+					if test.expOutcomes[r.ID] == model.ResourceOutcomeFailed {
+						expFailed = append(expFailed, r)
+					}
+				}
+				assert.Equal(expFailed, manage.FailedResources(err, test.resources))
+			}
+		})
+	}
+}
+
+func TestManagerReplaceStrategyResults(t *testing.T) {
+	tests := map[string]struct {
+		createStdout string
+		createStderr string
+		createErr    error
+		expResults   []model.ResourceResult
+		expErr       bool
+	}{
+		"Existing resources should only record the replace outcome.": {
+			createStderr: `Error from server (AlreadyExists): error when creating "STDIN": deployments.apps "test1" already exists`,
+			createErr:    errors.New("whatever"),
+			expResults: []model.ResourceResult{
+				{Resource: model.Resource{ID: "r1", K8sObject: newK8sObjectWithStrategy("test1", "replace")}, Operation: model.OperationApply, Outcome: model.ResourceOutcomeConfigured},
+			},
+		},
+
+		"Missing resources should only record the create outcome.": {
+			createStdout: "deployment.apps/test1 created\n",
+			expResults: []model.ResourceResult{
+				{Resource: model.Resource{ID: "r1", K8sObject: newK8sObjectWithStrategy("test1", "replace")}, Operation: model.OperationApply, Outcome: model.ResourceOutcomeCreated},
+			},
+		},
+
+		"Failing the create of the missing resources should only record the failure.": {
+			createStderr: `Error from server (Forbidden): whatever`,
+			createErr:    errors.New("whatever"),
+			expResults: []model.ResourceResult{
+				{Resource: model.Resource{ID: "r1", K8sObject: newK8sObjectWithStrategy("test1", "replace")}, Operation: model.OperationApply, Outcome: model.ResourceOutcomeFailed, Error: "error on cmd execution: Error from server (Forbidden): whatever: whatever"},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			menc := &kubectlmock.K8sObjectEncoder{}
+			mcmd := &kubectlmock.CmdRunner{}
+			mr := &reportmock.Recorder{}
+			menc.On("EncodeObjects", mock.Anything, mock.Anything).Return([]byte("test"), nil)
+
+			expCreate := expCmdMatcher([]string{"kubectl", "create", "--filename", "-"}, "")
+			mcmd.On("StdoutPipe", mock.MatchedBy(expCreate)).Once().Return(ioutil.NopCloser(strings.NewReader(test.createStdout)), nil)
+			mcmd.On("Start", mock.MatchedBy(expCreate)).Once().Return(nil)
+			mcmd.On("Wait", mock.MatchedBy(expCreate)).Once().Run(func(args mock.Arguments) {
+				cmd := args.Get(0).(*exec.Cmd)
+				_, _ = cmd.Stderr.Write([]byte(test.createStderr))
+			}).Return(test.createErr)
+
+			expReplace := expCmdMatcher([]string{"kubectl", "replace", "--filename", "-"}, "")
+			mcmd.On("StdoutPipe", mock.MatchedBy(expReplace)).Maybe().Return(ioutil.NopCloser(strings.NewReader("deployment.apps/test1 replaced\n")), nil)
+			mcmd.On("Start", mock.MatchedBy(expReplace)).Maybe().Return(nil)
+			mcmd.On("Wait", mock.MatchedBy(expReplace)).Maybe().Return(nil)
+
+			gotResults := []model.ResourceResult{}
+			mr.On("RecordResourceResult", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				gotResults = append(gotResults, args.Get(1).(model.ResourceResult))
+			})
+
+			// Prepare.
+			manager, err := kubectl.NewManager(kubectl.ManagerConfig{
+				Out:         ioutil.Discard,
+				YAMLEncoder: menc,
+				CmdRunner:   mcmd,
+				Recorder:    mr,
+			})
+			require.NoError(err)
+
+			// Execute.
+			err = manager.Apply(context.TODO(), []model.Resource{{ID: "r1", K8sObject: newK8sObjectWithStrategy("test1", "replace")}})
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expResults, gotResults)
+			mcmd.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReplacerReplace(t *testing.T) {
+	tests := map[string]struct {
+		config    kubectl.ManagerConfig
+		resources []model.Resource
+		mock      func(mk *kubectlmock.K8sObjectEncoder, mc *kubectlmock.CmdRunner)
+		expErr    bool
+	}{
+		"Not having resources, shouldn't execute anything.": {
+			resources: []model.Resource{},
+			mock:      func(mk *kubectlmock.K8sObjectEncoder, mc *kubectlmock.CmdRunner) {},
+		},
+
+		"Having resources should replace correctly.": {
+			resources: []model.Resource{
+				{ID: "test1", K8sObject: newK8sObject("test1", "ns1")},
+			},
+			mock: func(mk *kubectlmock.K8sObjectEncoder, mc *kubectlmock.CmdRunner) {
+				expK8sResources := []model.K8sObject{newK8sObject("test1", "ns1")}
+				mk.On("EncodeObjects", mock.Anything, expK8sResources).Once().Return([]byte("test"), nil)
+
+				exp := expCmdMatcher(
+					[]string{"kubectl", "replace", "--force=true", "--filename", "-"},
+					"test",
+				)
+				mc.On("StdoutPipe", mock.MatchedBy(exp)).Once().Return(nopRC, nil)
+				mc.On("Start", mock.MatchedBy(exp)).Once().Return(nil)
+				mc.On("Wait", mock.MatchedBy(exp)).Once().Return(nil)
+			},
+		},
+
+		"Having kubectl context and config set, should set the cmd flags.": {
+			config: kubectl.ManagerConfig{
+				KubeContext: "whatever-ctx",
+				KubeConfig:  "whatever-cfg",
+			},
+			resources: []model.Resource{{ID: "test1"}},
+			mock: func(mk *kubectlmock.K8sObjectEncoder, mc *kubectlmock.CmdRunner) {
+				mk.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test"), nil)
+
+				exp := expCmdMatcher(
+					[]string{"kubectl", "replace", "--context", "whatever-ctx", "--kubeconfig", "whatever-cfg", "--force=true", "--filename", "-"},
+					"test",
+				)
+				mc.On("StdoutPipe", mock.MatchedBy(exp)).Once().Return(nopRC, nil)
+				mc.On("Start", mock.MatchedBy(exp)).Once().Return(nil)
+				mc.On("Wait", mock.MatchedBy(exp)).Once().Return(nil)
+			},
+		},
+
+		"Having an error while running the cmd should stop the execution and fail.": {
+			resources: []model.Resource{{ID: "test1"}},
+			mock: func(mk *kubectlmock.K8sObjectEncoder, mc *kubectlmock.CmdRunner) {
+				mk.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return(nil, nil)
+				mc.On("StdoutPipe", mock.Anything).Once().Return(nopRC, nil)
+				mc.On("Start", mock.Anything).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			menc := &kubectlmock.K8sObjectEncoder{}
+			mcmd := &kubectlmock.CmdRunner{}
+			test.mock(menc, mcmd)
+
+			// Prepare.
+			test.config.Out = ioutil.Discard
+			test.config.YAMLEncoder = menc
+			test.config.CmdRunner = mcmd
+			replacer, err := kubectl.NewReplacer(test.config)
+			require.NoError(err)
+
+			// Execute.
+			err = replacer.Replace(context.TODO(), test.resources)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				menc.AssertExpectations(t)
+				mcmd.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestManagerApplyConflictError(t *testing.T) {
+	tests := map[string]struct {
+		stderr       string
+		expConflicts []model.FieldConflict
+	}{
+		"A regular error should not be a conflict error.": {
+			stderr: "something went wrong",
+		},
+
+		"A single conflict should be parsed.": {
+			stderr: `error: Apply failed with 1 conflict: conflict with "kube-controller-manager" using apps/v1: .spec.replicas
+Please review the fields above--they currently have other managers.`,
+			expConflicts: []model.FieldConflict{
+				{Manager: "kube-controller-manager", Fields: []string{".spec.replicas"}},
+			},
+		},
+
+		"Multiple conflicts with multiple managers should be parsed.": {
+			stderr: `error: Apply failed with 3 conflicts: conflicts with "helm" using apps/v1:
+- .spec.replicas
+- .spec.template.spec.containers[name="app"].image
+conflicts with "kubectl":
+- .metadata.labels.app
+Please review the fields above--they currently have other managers.`,
+			expConflicts: []model.FieldConflict{
+				{Manager: "helm", Fields: []string{".spec.replicas", `.spec.template.spec.containers[name="app"].image`}},
+				{Manager: "kubectl", Fields: []string{".metadata.labels.app"}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			menc := &kubectlmock.K8sObjectEncoder{}
+			mcmd := &kubectlmock.CmdRunner{}
+			menc.On("EncodeObjects", mock.Anything, mock.Anything).Once().Return([]byte("test"), nil)
+			mcmd.On("StdoutPipe", mock.Anything).Once().Return(nopRC, nil)
+			mcmd.On("Start", mock.Anything).Once().Return(nil)
+			mcmd.On("Wait", mock.MatchedBy(expCmdMatcherWithRetStderr(
+				[]string{"kubectl", "apply", "--force-conflicts=false", "--server-side=true", "--filename", "-"},
+				test.stderr,
+			))).Once().Return(errors.New("whatever"))
+
+			// Prepare.
+			manager, err := kubectl.NewManager(kubectl.ManagerConfig{
+				DisableKubeForceConflicts: true,
+				Out:                       ioutil.Discard,
+				YAMLEncoder:               menc,
+				CmdRunner:                 mcmd,
+			})
+			require.NoError(err)
+
+			// Execute.
+			err = manager.Apply(context.TODO(), []model.Resource{{ID: "test1"}})
+
+			// Check.
+			require.Error(err)
+			var conflictErr manage.ConflictError
+			if test.expConflicts == nil {
+				assert.False(errors.As(err, &conflictErr))
+				return
+			}
+			require.True(errors.As(err, &conflictErr))
+			assert.True(errors.Is(err, internalerrors.ErrConflict))
+			assert.Equal(test.expConflicts, conflictErr.Conflicts)
+		})
+	}
+}
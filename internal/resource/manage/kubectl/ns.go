@@ -9,9 +9,12 @@ import (
 	"os/exec"
 	"strings"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
 	"github.com/slok/kahoy/internal/resource/manage"
+	"github.com/slok/kahoy/internal/tracing"
 )
 
 // NamespaceEnsurerConfig is the configuration for NewNamespaceEnsurer.
@@ -24,7 +27,9 @@ type NamespaceEnsurerConfig struct {
 	CmdRunner   CmdRunner
 	Out         io.Writer
 	ErrOut      io.Writer
-	Logger      log.Logger
+	// Tracer is used to trace the kubectl invocations.
+	Tracer trace.Tracer
+	Logger log.Logger
 }
 
 func (c *NamespaceEnsurerConfig) defaults() error {
@@ -49,6 +54,10 @@ func (c *NamespaceEnsurerConfig) defaults() error {
 	}
 	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "kubectl.NamespaceEnsurer"})
 
+	if c.Tracer == nil {
+		c.Tracer = tracing.Noop
+	}
+
 	if c.CmdRunner == nil {
 		c.CmdRunner = newStdCmdRunner(c.Logger)
 	}
@@ -64,6 +73,7 @@ type namespaceEnsurer struct {
 	cmdRunner   CmdRunner
 	out         io.Writer
 	errOut      io.Writer
+	tracer      trace.Tracer
 	logger      log.Logger
 }
 
@@ -83,6 +93,7 @@ func NewNamespaceEnsurer(config NamespaceEnsurerConfig) (manage.ResourceManager,
 		cmdRunner:   config.CmdRunner,
 		out:         config.Out,
 		errOut:      config.ErrOut,
+		tracer:      config.Tracer,
 		logger:      config.Logger,
 	}, nil
 }
@@ -129,7 +140,7 @@ func (n namespaceEnsurer) ensureNamespace(ctx context.Context, ns string) error
 		cmd := exec.CommandContext(ctx, n.kubectlCmd, getArgs...)
 		var outErr bytes.Buffer
 		cmd.Stderr = &outErr
-		err := n.cmdRunner.Run(cmd)
+		err := runTraced(ctx, n.tracer, n.cmdRunner, cmd, 0)
 		if err == nil {
 			// Namespace already present.
 			return nil
@@ -157,7 +168,7 @@ func (n namespaceEnsurer) ensureNamespace(ctx context.Context, ns string) error
 		cmd := exec.CommandContext(ctx, n.kubectlCmd, createArgs...)
 		cmd.Stdout = &out
 		cmd.Stderr = &outErr
-		err := n.cmdRunner.Run(cmd)
+		err := runTraced(ctx, n.tracer, n.cmdRunner, cmd, 0)
 		if err != nil {
 			for _, line := range strings.Split(outErr.String(), "\n") {
 				if line == "" {
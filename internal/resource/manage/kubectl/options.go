@@ -45,6 +45,12 @@ func withCreateCmd() kubectlCmdOption {
 	}
 }
 
+func withReplaceCmd() kubectlCmdOption {
+	return func(args []string) []string {
+		return append(args, "replace")
+	}
+}
+
 func withNamespaceKind() kubectlCmdOption {
 	return func(args []string) []string {
 		return append(args, "namespace")
@@ -96,6 +102,15 @@ func withServerSide(serverSide bool) kubectlCmdOption {
 	}
 }
 
+func withServerDryRun(dryRun bool) kubectlCmdOption {
+	return func(args []string) []string {
+		if !dryRun {
+			return args
+		}
+		return append(args, "--dry-run=server")
+	}
+}
+
 func withIgnoreNotFound(ignoreNotFound bool) kubectlCmdOption {
 	return func(args []string) []string {
 		return append(args, fmt.Sprintf("--ignore-not-found=%t", ignoreNotFound))
@@ -119,3 +134,9 @@ func withWait(wait bool) kubectlCmdOption {
 		return append(args, fmt.Sprintf("--wait=%t", wait))
 	}
 }
+
+func withForce(force bool) kubectlCmdOption {
+	return func(args []string) []string {
+		return append(args, fmt.Sprintf("--force=%t", force))
+	}
+}
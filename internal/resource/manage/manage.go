@@ -2,7 +2,11 @@ package manage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/slok/kahoy/internal/internalerrors"
 	"github.com/slok/kahoy/internal/log"
 	"github.com/slok/kahoy/internal/model"
 )
@@ -15,6 +19,88 @@ type ResourceManager interface {
 
 //go:generate mockery --case underscore --output managemock --outpkg managemock --name ResourceManager
 
+// ResourceReplacer knows how to replace resources on clusters, deleting them and
+// creating them again.
+type ResourceReplacer interface {
+	Replace(ctx context.Context, resources []model.Resource) error
+}
+
+//go:generate mockery --case underscore --output managemock --outpkg managemock --name ResourceReplacer
+
+// ConflictError is the error returned when a resource apply has field ownership conflicts
+// with other field managers.
+type ConflictError struct {
+	Conflicts []model.FieldConflict
+}
+
+func (c ConflictError) Error() string {
+	msgs := make([]string, 0, len(c.Conflicts))
+	for _, fc := range c.Conflicts {
+		msgs = append(msgs, fmt.Sprintf("%q manager owns %s", fc.Manager, strings.Join(fc.Fields, ", ")))
+	}
+
+	return fmt.Sprintf("%s: %s", internalerrors.ErrConflict, strings.Join(msgs, "; "))
+}
+
+// Is satisfies errors.Is interface so `internalerrors.ErrConflict` can be used to check
+// conflict errors.
+func (c ConflictError) Is(target error) bool {
+	return target == internalerrors.ErrConflict
+}
+
+// ResourcesError is the error returned when the execution of some of the resources fails,
+// it has the IDs of the resources that have not been executed correctly.
+type ResourcesError struct {
+	ResourceIDs []string
+	Err         error
+}
+
+func (r ResourcesError) Error() string { return r.Err.Error() }
+
+// Unwrap satisfies errors.Unwrap interface so the original error can be checked.
+func (r ResourcesError) Unwrap() error { return r.Err }
+
+// FailedResources returns the resources that have failed based on the error, if the error
+// doesn't know which resources failed, all the resources are returned.
+func FailedResources(err error, resources []model.Resource) []model.Resource {
+	var rErr ResourcesError
+	if !errors.As(err, &rErr) || len(rErr.ResourceIDs) == 0 {
+		return resources
+	}
+
+	ids := map[string]struct{}{}
+	for _, id := range rErr.ResourceIDs {
+		ids[id] = struct{}{}
+	}
+
+	failed := []model.Resource{}
+	for _, r := range resources {
+		if _, ok := ids[r.ID]; ok {
+			failed = append(failed, r)
+		}
+	}
+
+	return failed
+}
+
+// WithNotExecutedResources adds the resources that have not been executed due to the error
+// to the failed resources of the error. If the error doesn't know the failed resources, it
+// is returned as it is.
+func WithNotExecutedResources(err error, resources []model.Resource) error {
+	var rErr ResourcesError
+	if len(resources) == 0 || !errors.As(err, &rErr) {
+		return err
+	}
+
+	ids := make([]string, 0, len(rErr.ResourceIDs)+len(resources))
+	ids = append(ids, rErr.ResourceIDs...)
+	for _, r := range resources {
+		ids = append(ids, r.ID)
+	}
+
+	return ResourcesError{ResourceIDs: ids, Err: err}
+}
+
 type noopManager struct {
 	logger log.Logger
 }
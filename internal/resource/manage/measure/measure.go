@@ -0,0 +1,81 @@
+package measure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/metrics"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/manage"
+)
+
+// ManagerConfig is the configuration of the measure resource manager.
+type ManagerConfig struct {
+	// Manager is the original manager used to apply and delete resources.
+	Manager manage.ResourceManager
+	// Recorder is used to record the metrics.
+	Recorder metrics.Recorder
+	Logger   log.Logger
+}
+
+func (c *ManagerConfig) defaults() error {
+	if c.Manager == nil {
+		return fmt.Errorf("manager is required")
+	}
+
+	if c.Recorder == nil {
+		c.Recorder = metrics.Noop
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "manage.MeasureManager"})
+
+	return nil
+}
+
+// NewManager wraps the application resource manager measuring the quantity of
+// applied and deleted resources by group, and if they failed or not.
+func NewManager(config ManagerConfig) (manage.ResourceManager, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid measure manager configuration: %w", err)
+	}
+
+	return measureManager{
+		manager:  config.Manager,
+		recorder: config.Recorder,
+		logger:   config.Logger,
+	}, nil
+}
+
+type measureManager struct {
+	manager  manage.ResourceManager
+	recorder metrics.Recorder
+	logger   log.Logger
+}
+
+func (m measureManager) Apply(ctx context.Context, resources []model.Resource) error {
+	err := m.manager.Apply(ctx, resources)
+	m.measure(ctx, model.OperationApply, resources, err == nil)
+	return err
+}
+
+func (m measureManager) Delete(ctx context.Context, resources []model.Resource) error {
+	err := m.manager.Delete(ctx, resources)
+	m.measure(ctx, model.OperationDelete, resources, err == nil)
+	return err
+}
+
+func (m measureManager) measure(ctx context.Context, op model.Operation, resources []model.Resource, success bool) {
+	byGroup := map[string]int{}
+	for _, r := range resources {
+		byGroup[r.GroupID]++
+	}
+
+	for groupID, q := range byGroup {
+		m.recorder.AddResourceOperations(ctx, op, groupID, success, q)
+	}
+}
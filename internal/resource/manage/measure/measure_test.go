@@ -0,0 +1,103 @@
+package measure_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/kahoy/internal/metrics/metricsmock"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/manage/managemock"
+	"github.com/slok/kahoy/internal/resource/manage/measure"
+)
+
+func TestManager(t *testing.T) {
+	resources := []model.Resource{
+		{ID: "r1", GroupID: "g1"},
+		{ID: "r2", GroupID: "g1"},
+		{ID: "r3", GroupID: "g2"},
+	}
+
+	tests := map[string]struct {
+		execute func(c measure.ManagerConfig) error
+		mock    func(mm *managemock.ResourceManager, mr *metricsmock.Recorder)
+		expErr  bool
+	}{
+		"Applying resources should measure the applied resources by group.": {
+			execute: func(c measure.ManagerConfig) error {
+				m, err := measure.NewManager(c)
+				if err != nil {
+					return err
+				}
+				return m.Apply(context.TODO(), resources)
+			},
+			mock: func(mm *managemock.ResourceManager, mr *metricsmock.Recorder) {
+				mm.On("Apply", mock.Anything, resources).Once().Return(nil)
+				mr.On("AddResourceOperations", mock.Anything, model.OperationApply, "g1", true, 2).Once()
+				mr.On("AddResourceOperations", mock.Anything, model.OperationApply, "g2", true, 1).Once()
+			},
+		},
+
+		"Failing applying resources should measure the failed resources by group.": {
+			execute: func(c measure.ManagerConfig) error {
+				m, err := measure.NewManager(c)
+				if err != nil {
+					return err
+				}
+				return m.Apply(context.TODO(), resources)
+			},
+			mock: func(mm *managemock.ResourceManager, mr *metricsmock.Recorder) {
+				mm.On("Apply", mock.Anything, resources).Once().Return(fmt.Errorf("whatever"))
+				mr.On("AddResourceOperations", mock.Anything, model.OperationApply, "g1", false, 2).Once()
+				mr.On("AddResourceOperations", mock.Anything, model.OperationApply, "g2", false, 1).Once()
+			},
+			expErr: true,
+		},
+
+		"Deleting resources should measure the deleted resources by group.": {
+			execute: func(c measure.ManagerConfig) error {
+				m, err := measure.NewManager(c)
+				if err != nil {
+					return err
+				}
+				return m.Delete(context.TODO(), resources)
+			},
+			mock: func(mm *managemock.ResourceManager, mr *metricsmock.Recorder) {
+				mm.On("Delete", mock.Anything, resources).Once().Return(nil)
+				mr.On("AddResourceOperations", mock.Anything, model.OperationDelete, "g1", true, 2).Once()
+				mr.On("AddResourceOperations", mock.Anything, model.OperationDelete, "g2", true, 1).Once()
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mm := &managemock.ResourceManager{}
+			mr := &metricsmock.Recorder{}
+			test.mock(mm, mr)
+
+			// Execute.
+			err := test.execute(measure.ManagerConfig{
+				Manager:  mm,
+				Recorder: mr,
+			})
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				require.NoError(err)
+			}
+			mm.AssertExpectations(t)
+			mr.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package replace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/slok/kahoy/internal/internalerrors"
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
+	"github.com/slok/kahoy/internal/resource/manage"
+	"github.com/slok/kahoy/internal/storage"
+)
+
+// ManagerConfig is the configuration of the replace resource manager.
+type ManagerConfig struct {
+	// Manager is the original manager used to apply and delete.
+	Manager manage.ResourceManager
+	// Replacer is used to replace the resources that can't be applied due to immutable fields changes.
+	Replacer        manage.ResourceReplacer
+	GroupRepository storage.GroupRepository
+	Recorder        report.Recorder
+	Logger          log.Logger
+}
+
+func (c *ManagerConfig) defaults() error {
+	if c.Manager == nil {
+		return fmt.Errorf("manager is required")
+	}
+
+	if c.Replacer == nil {
+		return fmt.Errorf("replacer is required")
+	}
+
+	if c.GroupRepository == nil {
+		return fmt.Errorf("group repository is required")
+	}
+
+	if c.Recorder == nil {
+		c.Recorder = report.Noop
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "manage.ReplaceManager"})
+
+	return nil
+}
+
+type replaceManager struct {
+	manager   manage.ResourceManager
+	replacer  manage.ResourceReplacer
+	groupRepo storage.GroupRepository
+	recorder  report.Recorder
+	logger    log.Logger
+}
+
+// NewManager wraps a resource manager and in case of an apply failure due to immutable
+// fields changes, it will replace (delete and create) the resources that opted-in
+// for replacement, using the resource annotation or the group configuration.
+//
+// The replacement is made in the same `Apply` call so the resources are replaced
+// inside the same batch.
+func NewManager(config ManagerConfig) (manage.ResourceManager, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return replaceManager{
+		manager:   config.Manager,
+		replacer:  config.Replacer,
+		groupRepo: config.GroupRepository,
+		recorder:  config.Recorder,
+		logger:    config.Logger,
+	}, nil
+}
+
+func (r replaceManager) Apply(ctx context.Context, resources []model.Resource) error {
+	err := r.manager.Apply(ctx, resources)
+	if err == nil || !errors.Is(err, internalerrors.ErrImmutable) {
+		return err
+	}
+
+	replaceable, rErr := r.replaceableResources(ctx, resources)
+	if rErr != nil {
+		return rErr
+	}
+
+	if len(replaceable) == 0 {
+		return err
+	}
+
+	// We don't know which resources failed, so we apply one by one the ones that can
+	// be replaced and replace the ones that fail due to immutable fields.
+	r.logger.Warningf("immutable fields changes detected, checking %d replaceable resources", len(replaceable))
+	failed := manage.FailedResources(err, resources)
+	checked := map[string]struct{}{}
+	for _, res := range replaceable {
+		checked[res.ID] = struct{}{}
+		err := r.manager.Apply(ctx, []model.Resource{res})
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, internalerrors.ErrImmutable) {
+			return manage.WithNotExecutedResources(err, notChecked(failed, checked))
+		}
+
+		logger := r.logger.WithValues(log.Kv{"resource-id": res.ID, "resource-group-id": res.GroupID})
+		logger.Warningf("resource has immutable fields changes, replacing resource")
+		err = r.replacer.Replace(ctx, []model.Resource{res})
+		if err != nil {
+			return manage.WithNotExecutedResources(fmt.Errorf("could not replace %q resource: %w", res.ID, err), notChecked(failed, checked))
+		}
+		r.recorder.RecordResourceReplaced(ctx, res)
+	}
+
+	// Apply again all the resources so we are sure that all of them have been applied
+	// (e.g: resources with immutable field changes that were not replaceable).
+	return r.manager.Apply(ctx, resources)
+}
+
+func (r replaceManager) Delete(ctx context.Context, resources []model.Resource) error {
+	return r.manager.Delete(ctx, resources)
+}
+
+// replaceableResources returns the resources that can be replaced.
+func (r replaceManager) replaceableResources(ctx context.Context, resources []model.Resource) ([]model.Resource, error) {
+	replaceable := []model.Resource{}
+	for _, res := range resources {
+		group, err := r.groupRepo.GetGroup(ctx, res.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get group %q: %w", res.GroupID, err)
+		}
+
+		if res.ReplaceOnImmutable(group.ReplaceOnImmutable) {
+			replaceable = append(replaceable, res)
+		}
+	}
+
+	return replaceable, nil
+}
+
+// notChecked returns the resources that have not been checked yet.
+func notChecked(resources []model.Resource, checked map[string]struct{}) []model.Resource {
+	res := []model.Resource{}
+	for _, r := range resources {
+		if _, ok := checked[r.ID]; !ok {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
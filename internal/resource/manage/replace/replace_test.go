@@ -0,0 +1,195 @@
+package replace_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/slok/kahoy/internal/internalerrors"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report/reportmock"
+	"github.com/slok/kahoy/internal/resource/manage/managemock"
+	"github.com/slok/kahoy/internal/resource/manage/replace"
+	"github.com/slok/kahoy/internal/storage/storagemock"
+)
+
+func newResource(id, group string, annotations map[string]string) model.Resource {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAnnotations(annotations)
+	return model.Resource{ID: id, GroupID: group, K8sObject: obj}
+}
+
+func TestManagerApply(t *testing.T) {
+	errImmutable := fmt.Errorf("whatever: %w", internalerrors.ErrImmutable)
+	replaceAnnotation := map[string]string{"kahoy.slok.dev/replace-on-immutable": "true"}
+
+	tests := map[string]struct {
+		resources []model.Resource
+		mock      func(mrm *managemock.ResourceManager, mrr *managemock.ResourceReplacer, mgr *storagemock.GroupRepository, mr *reportmock.Recorder)
+		expErr    bool
+	}{
+		"Applying resources without errors shouldn't replace anything.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", replaceAnnotation),
+			},
+			mock: func(mrm *managemock.ResourceManager, mrr *managemock.ResourceReplacer, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(nil)
+			},
+		},
+
+		"Applying resources with a regular error should fail without replacing.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", replaceAnnotation),
+			},
+			mock: func(mrm *managemock.ResourceManager, mrr *managemock.ResourceReplacer, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+
+		"Applying resources with an immutable error and without replaceable resources should fail.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", nil),
+			},
+			mock: func(mrm *managemock.ResourceManager, mrr *managemock.ResourceReplacer, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(errImmutable)
+				mgr.On("GetGroup", mock.Anything, "g1").Once().Return(&model.Group{ID: "g1"}, nil)
+			},
+			expErr: true,
+		},
+
+		"Applying resources with an immutable error should replace the replaceable resources that fail and apply again.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", nil),
+				newResource("r2", "g1", replaceAnnotation),
+				newResource("r3", "g2", nil),
+				newResource("r4", "g2", nil),
+			},
+			mock: func(mrm *managemock.ResourceManager, mrr *managemock.ResourceReplacer, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				all := []model.Resource{
+					newResource("r1", "g1", nil),
+					newResource("r2", "g1", replaceAnnotation),
+					newResource("r3", "g2", nil),
+					newResource("r4", "g2", nil),
+				}
+				mrm.On("Apply", mock.Anything, all).Once().Return(errImmutable)
+
+				mgr.On("GetGroup", mock.Anything, "g1").Times(2).Return(&model.Group{ID: "g1"}, nil)
+				mgr.On("GetGroup", mock.Anything, "g2").Times(2).Return(&model.Group{ID: "g2", ReplaceOnImmutable: true}, nil)
+
+				// Replaceable resources one by one.
+				r2 := []model.Resource{newResource("r2", "g1", replaceAnnotation)}
+				mrm.On("Apply", mock.Anything, r2).Once().Return(errImmutable)
+				mrr.On("Replace", mock.Anything, r2).Once().Return(nil)
+				mr.On("RecordResourceReplaced", mock.Anything, r2[0]).Once()
+
+				r3 := []model.Resource{newResource("r3", "g2", nil)}
+				mrm.On("Apply", mock.Anything, r3).Once().Return(nil)
+
+				r4 := []model.Resource{newResource("r4", "g2", nil)}
+				mrm.On("Apply", mock.Anything, r4).Once().Return(errImmutable)
+				mrr.On("Replace", mock.Anything, r4).Once().Return(nil)
+				mr.On("RecordResourceReplaced", mock.Anything, r4[0]).Once()
+
+				// Apply again all.
+				mrm.On("Apply", mock.Anything, all).Once().Return(nil)
+			},
+		},
+
+		"Having an error while replacing should fail.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", replaceAnnotation),
+			},
+			mock: func(mrm *managemock.ResourceManager, mrr *managemock.ResourceReplacer, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, mock.Anything).Times(2).Return(errImmutable)
+				mgr.On("GetGroup", mock.Anything, "g1").Once().Return(&model.Group{ID: "g1"}, nil)
+				mrr.On("Replace", mock.Anything, mock.Anything).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+
+		"Having a regular error while applying the replaceable resources one by one should fail.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", replaceAnnotation),
+			},
+			mock: func(mrm *managemock.ResourceManager, mrr *managemock.ResourceReplacer, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(errImmutable)
+				mgr.On("GetGroup", mock.Anything, "g1").Once().Return(&model.Group{ID: "g1"}, nil)
+				mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+
+		"If getting a group has an error, it should fail.": {
+			resources: []model.Resource{
+				newResource("r1", "g1", replaceAnnotation),
+			},
+			mock: func(mrm *managemock.ResourceManager, mrr *managemock.ResourceReplacer, mgr *storagemock.GroupRepository, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, mock.Anything).Once().Return(errImmutable)
+				mgr.On("GetGroup", mock.Anything, "g1").Once().Return(nil, errors.New("whatever"))
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mrm := &managemock.ResourceManager{}
+			mrr := &managemock.ResourceReplacer{}
+			mgr := &storagemock.GroupRepository{}
+			mr := &reportmock.Recorder{}
+			test.mock(mrm, mrr, mgr, mr)
+
+			// Execute.
+			manager, err := replace.NewManager(replace.ManagerConfig{
+				Manager:         mrm,
+				Replacer:        mrr,
+				GroupRepository: mgr,
+				Recorder:        mr,
+			})
+			require.NoError(err)
+			err = manager.Apply(context.TODO(), test.resources)
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mrm.AssertExpectations(t)
+			mrr.AssertExpectations(t)
+			mgr.AssertExpectations(t)
+			mr.AssertExpectations(t)
+		})
+	}
+}
+
+func TestManagerDelete(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	resources := []model.Resource{newResource("r1", "g1", nil)}
+	mrm := &managemock.ResourceManager{}
+	mrm.On("Delete", mock.Anything, resources).Once().Return(nil)
+
+	manager, err := replace.NewManager(replace.ManagerConfig{
+		Manager:         mrm,
+		Replacer:        &managemock.ResourceReplacer{},
+		GroupRepository: &storagemock.GroupRepository{},
+	})
+	require.NoError(err)
+
+	err = manager.Delete(context.TODO(), resources)
+	assert.NoError(err)
+	mrm.AssertExpectations(t)
+}
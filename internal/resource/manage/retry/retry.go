@@ -0,0 +1,158 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/slok/kahoy/internal/internalerrors"
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report"
+	"github.com/slok/kahoy/internal/resource/manage"
+)
+
+// ManagerConfig is the configuration of the retry resource manager.
+type ManagerConfig struct {
+	// Manager is the original manager used to apply and delete.
+	Manager manage.ResourceManager
+	// Retries is the maximum number of retries of the resources that fail due to transient errors.
+	Retries int
+	// Backoff is the initial wait between retries, it is doubled on every retry.
+	Backoff  time.Duration
+	Recorder report.Recorder
+	Logger   log.Logger
+}
+
+func (c *ManagerConfig) defaults() error {
+	if c.Manager == nil {
+		return fmt.Errorf("manager is required")
+	}
+
+	if c.Retries < 0 {
+		return fmt.Errorf("retries can't be negative")
+	}
+
+	if c.Backoff < 0 {
+		return fmt.Errorf("backoff can't be negative")
+	}
+
+	if c.Backoff == 0 {
+		c.Backoff = time.Second
+	}
+
+	if c.Recorder == nil {
+		c.Recorder = report.Noop
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "manage.RetryManager"})
+
+	return nil
+}
+
+type retryManager struct {
+	manager  manage.ResourceManager
+	retries  int
+	backoff  time.Duration
+	recorder report.Recorder
+	logger   log.Logger
+}
+
+// NewManager wraps a resource manager and in case of a failure due to transient errors
+// (e.g: apiserver throttling, timeouts...), it will retry the failed resources using
+// an exponential backoff with jitter, up to the configured number of retries.
+//
+// Permanent errors are returned without retrying.
+func NewManager(config ManagerConfig) (manage.ResourceManager, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry manager configuration: %w", err)
+	}
+
+	return retryManager{
+		manager:  config.Manager,
+		retries:  config.Retries,
+		backoff:  config.Backoff,
+		recorder: config.Recorder,
+		logger:   config.Logger,
+	}, nil
+}
+
+func (r retryManager) Apply(ctx context.Context, resources []model.Resource) error {
+	return r.execute(ctx, model.OperationApply, resources, r.manager.Apply)
+}
+
+func (r retryManager) Delete(ctx context.Context, resources []model.Resource) error {
+	return r.execute(ctx, model.OperationDelete, resources, r.manager.Delete)
+}
+
+func (r retryManager) execute(ctx context.Context, op model.Operation, resources []model.Resource, fn func(ctx context.Context, resources []model.Resource) error) error {
+	err := fn(ctx, resources)
+	if err == nil || !errors.Is(err, internalerrors.ErrTransient) || r.retries == 0 {
+		return err
+	}
+
+	// Only retry the failed resources, if we don't know which ones failed, all of them are retried.
+	pending := manage.FailedResources(err, resources)
+	backoff := r.backoff
+	for attempt := 1; attempt <= r.retries; attempt++ {
+		wait := jitter(backoff)
+		r.logger.Warningf("transient error detected, retrying %d resources in %s (attempt %d/%d)", len(pending), wait, attempt, r.retries)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context done while waiting for retry: %w", err)
+		case <-time.After(wait):
+		}
+		backoff *= 2
+
+		rErr := fn(ctx, pending)
+		failed := []model.Resource{}
+		if rErr != nil {
+			failed = manage.FailedResources(rErr, pending)
+		}
+		failedIDs := map[string]struct{}{}
+		for _, res := range failed {
+			failedIDs[res.ID] = struct{}{}
+		}
+
+		for _, res := range pending {
+			retry := model.ResourceRetry{Resource: res, Operation: op, Attempt: attempt}
+			if _, ok := failedIDs[res.ID]; ok {
+				retry.Error = rErr.Error()
+			}
+			r.recorder.RecordResourceRetry(ctx, retry)
+		}
+
+		if rErr == nil {
+			return nil
+		}
+
+		if !errors.Is(rErr, internalerrors.ErrTransient) {
+			return rErr
+		}
+
+		for _, res := range failed {
+			logger := r.logger.WithValues(log.Kv{"resource-id": res.ID, "resource-group-id": res.GroupID})
+			logger.Warningf("resource failed with a transient error on attempt %d: %s", attempt, rErr)
+		}
+		pending = failed
+		err = rErr
+	}
+
+	return fmt.Errorf("%d resources failed after %d retries: %w", len(pending), r.retries, err)
+}
+
+// jitter returns the duration with a random variation of up to +-25%.
+func jitter(d time.Duration) time.Duration {
+	delta := int64(d) / 4
+	if delta <= 0 {
+		return d
+	}
+
+	return d - time.Duration(delta) + time.Duration(rand.Int63n(2*delta))
+}
@@ -0,0 +1,165 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/kahoy/internal/internalerrors"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/report/reportmock"
+	"github.com/slok/kahoy/internal/resource/manage"
+	"github.com/slok/kahoy/internal/resource/manage/managemock"
+	"github.com/slok/kahoy/internal/resource/manage/retry"
+)
+
+func TestManager(t *testing.T) {
+	errTransient := fmt.Errorf("whatever: %w", internalerrors.ErrTransient)
+	r1 := model.Resource{ID: "r1", GroupID: "g1"}
+	r2 := model.Resource{ID: "r2", GroupID: "g1"}
+	r3 := model.Resource{ID: "r3", GroupID: "g2"}
+	all := []model.Resource{r1, r2, r3}
+
+	tests := map[string]struct {
+		retries   int
+		operation string
+		mock      func(mrm *managemock.ResourceManager, mr *reportmock.Recorder)
+		expErr    bool
+	}{
+		"Applying resources without errors shouldn't retry.": {
+			retries:   3,
+			operation: "Apply",
+			mock: func(mrm *managemock.ResourceManager, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, all).Once().Return(nil)
+			},
+		},
+
+		"Applying resources with a permanent error should fail without retrying.": {
+			retries:   3,
+			operation: "Apply",
+			mock: func(mrm *managemock.ResourceManager, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, all).Once().Return(errors.New("whatever"))
+			},
+			expErr: true,
+		},
+
+		"Applying resources with a transient error and retries disabled should fail without retrying.": {
+			retries:   0,
+			operation: "Apply",
+			mock: func(mrm *managemock.ResourceManager, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, all).Once().Return(errTransient)
+			},
+			expErr: true,
+		},
+
+		"Applying resources with a transient error should retry only the failed resources.": {
+			retries:   3,
+			operation: "Apply",
+			mock: func(mrm *managemock.ResourceManager, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, all).Once().Return(failedErr(errTransient, r2, r3))
+
+				// Attempt 1.
+				mrm.On("Apply", mock.Anything, []model.Resource{r2, r3}).Once().Return(failedErr(errTransient, r2))
+				mr.On("RecordResourceRetry", mock.Anything, model.ResourceRetry{Resource: r2, Operation: model.OperationApply, Attempt: 1, Error: errTransient.Error()}).Once()
+				mr.On("RecordResourceRetry", mock.Anything, model.ResourceRetry{Resource: r3, Operation: model.OperationApply, Attempt: 1}).Once()
+
+				// Attempt 2.
+				mrm.On("Apply", mock.Anything, []model.Resource{r2}).Once().Return(nil)
+				mr.On("RecordResourceRetry", mock.Anything, model.ResourceRetry{Resource: r2, Operation: model.OperationApply, Attempt: 2}).Once()
+			},
+		},
+
+		"Applying resources with a transient error without knowing the failed resources should retry all of them.": {
+			retries:   3,
+			operation: "Apply",
+			mock: func(mrm *managemock.ResourceManager, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, all).Once().Return(errTransient)
+
+				// Attempt 1.
+				mrm.On("Apply", mock.Anything, all).Once().Return(nil)
+				mr.On("RecordResourceRetry", mock.Anything, model.ResourceRetry{Resource: r1, Operation: model.OperationApply, Attempt: 1}).Once()
+				mr.On("RecordResourceRetry", mock.Anything, model.ResourceRetry{Resource: r2, Operation: model.OperationApply, Attempt: 1}).Once()
+				mr.On("RecordResourceRetry", mock.Anything, model.ResourceRetry{Resource: r3, Operation: model.OperationApply, Attempt: 1}).Once()
+			},
+		},
+
+		"Applying resources with a transient error that become permanent while retrying should fail.": {
+			retries:   3,
+			operation: "Apply",
+			mock: func(mrm *managemock.ResourceManager, mr *reportmock.Recorder) {
+				mrm.On("Apply", mock.Anything, all).Once().Return(failedErr(errTransient, r1))
+				mrm.On("Apply", mock.Anything, []model.Resource{r1}).Once().Return(errors.New("whatever"))
+				mr.On("RecordResourceRetry", mock.Anything, model.ResourceRetry{Resource: r1, Operation: model.OperationApply, Attempt: 1, Error: "whatever"}).Once()
+			},
+			expErr: true,
+		},
+
+		"Deleting resources with transient errors should fail after exhausting the retries.": {
+			retries:   2,
+			operation: "Delete",
+			mock: func(mrm *managemock.ResourceManager, mr *reportmock.Recorder) {
+				mrm.On("Delete", mock.Anything, all).Once().Return(failedErr(errTransient, r3))
+
+				// Attempt 1.
+				mrm.On("Delete", mock.Anything, []model.Resource{r3}).Once().Return(failedErr(errTransient, r3))
+				mr.On("RecordResourceRetry", mock.Anything, model.ResourceRetry{Resource: r3, Operation: model.OperationDelete, Attempt: 1, Error: errTransient.Error()}).Once()
+
+				// Attempt 2.
+				mrm.On("Delete", mock.Anything, []model.Resource{r3}).Once().Return(failedErr(errTransient, r3))
+				mr.On("RecordResourceRetry", mock.Anything, model.ResourceRetry{Resource: r3, Operation: model.OperationDelete, Attempt: 2, Error: errTransient.Error()}).Once()
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mrm := &managemock.ResourceManager{}
+			mr := &reportmock.Recorder{}
+			test.mock(mrm, mr)
+
+			// Execute.
+			manager, err := retry.NewManager(retry.ManagerConfig{
+				Manager:  mrm,
+				Retries:  test.retries,
+				Backoff:  time.Millisecond,
+				Recorder: mr,
+			})
+			require.NoError(err)
+
+			if test.operation == "Delete" {
+				err = manager.Delete(context.TODO(), all)
+			} else {
+				err = manager.Apply(context.TODO(), all)
+			}
+
+			// Check.
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			mrm.AssertExpectations(t)
+			mr.AssertExpectations(t)
+		})
+	}
+}
+
+func failedErr(err error, resources ...model.Resource) error {
+	ids := []string{}
+	for _, r := range resources {
+		ids = append(ids, r.ID)
+	}
+
+	return manage.ResourcesError{ResourceIDs: ids, Err: err}
+}
@@ -0,0 +1,175 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/slok/kahoy/internal/internalerrors"
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/manage"
+	"github.com/slok/kahoy/internal/storage"
+)
+
+// GroupManagerConfig is the configuration of the group timeout resource manager.
+type GroupManagerConfig struct {
+	// Manager is the original manager used to apply and delete.
+	Manager         manage.ResourceManager
+	GroupRepository storage.GroupRepository
+	// DefaultTimeout is the timeout of the groups without a configured timeout,
+	// 0 means no timeout.
+	DefaultTimeout time.Duration
+	Logger         log.Logger
+}
+
+func (c *GroupManagerConfig) defaults() error {
+	if c.Manager == nil {
+		return fmt.Errorf("manager is required")
+	}
+
+	if c.GroupRepository == nil {
+		return fmt.Errorf("group repository is required")
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "manage.GroupTimeoutManager"})
+
+	return nil
+}
+
+// NewGroupManager wraps the application resource manager ensuring that the
+// resources of the groups with a configured timeout are executed (including
+// the group hooks if the wrapped manager executes them) in their own call
+// with the group timeout instead of the default one.
+//
+// The resources of the groups without timeout are executed together in a single
+// call with the default timeout, concurrently with the groups with timeouts.
+func NewGroupManager(config GroupManagerConfig) (manage.ResourceManager, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid group timeout manager configuration: %w", err)
+	}
+
+	return groupTimeoutManager{
+		manager:        config.Manager,
+		groupRepo:      config.GroupRepository,
+		defaultTimeout: config.DefaultTimeout,
+		logger:         config.Logger,
+	}, nil
+}
+
+type groupTimeoutManager struct {
+	manager        manage.ResourceManager
+	groupRepo      storage.GroupRepository
+	defaultTimeout time.Duration
+	logger         log.Logger
+}
+
+func (g groupTimeoutManager) Apply(ctx context.Context, resources []model.Resource) error {
+	return g.execute(ctx, resources, g.manager.Apply)
+}
+
+func (g groupTimeoutManager) Delete(ctx context.Context, resources []model.Resource) error {
+	return g.execute(ctx, resources, g.manager.Delete)
+}
+
+type groupTimeoutBatch struct {
+	group     *model.Group
+	resources []model.Resource
+}
+
+func (g groupTimeoutManager) execute(ctx context.Context, resources []model.Resource, f func(ctx context.Context, resources []model.Resource) error) error {
+	noTimeout := []model.Resource{}
+	batches := map[string]*groupTimeoutBatch{}
+	for _, r := range resources {
+		// Deleted resources groups could be missing (e.g: the group has been removed).
+		group, err := g.groupRepo.GetGroup(ctx, r.GroupID)
+		if err != nil && !errors.Is(err, internalerrors.ErrMissing) {
+			return fmt.Errorf("could not get group %q: %w", r.GroupID, err)
+		}
+
+		if group == nil || group.Timeout == 0 {
+			noTimeout = append(noTimeout, r)
+			continue
+		}
+
+		b, ok := batches[group.ID]
+		if !ok {
+			b = &groupTimeoutBatch{group: group}
+			batches[group.ID] = b
+		}
+		b.resources = append(b.resources, r)
+	}
+
+	// Without group timeouts we don't split the execution.
+	if len(batches) == 0 {
+		return g.executeDefault(ctx, resources, f)
+	}
+
+	groupIDs := make([]string, 0, len(batches))
+	for id := range batches {
+		groupIDs = append(groupIDs, id)
+	}
+	sort.Strings(groupIDs)
+
+	// Execute all the groups concurrently as if they were executed in the same call,
+	// stopping all of them on the first failure.
+	eg, egctx := errgroup.WithContext(ctx)
+	if len(noTimeout) > 0 {
+		eg.Go(func() error { return g.executeDefault(egctx, noTimeout, f) })
+	}
+	for _, id := range groupIDs {
+		b := *batches[id]
+		eg.Go(func() error { return g.executeGroup(egctx, b, f) })
+	}
+
+	return eg.Wait()
+}
+
+func (g groupTimeoutManager) executeDefault(ctx context.Context, resources []model.Resource, f func(ctx context.Context, resources []model.Resource) error) error {
+	if g.defaultTimeout == 0 {
+		return f(ctx, resources)
+	}
+
+	start := time.Now()
+	dctx, cancel := context.WithTimeout(ctx, g.defaultTimeout)
+	defer cancel()
+
+	err := f(dctx, resources)
+	if err != nil {
+		if dctx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			g.logger.Errorf("context cancelled by deadline after %s", time.Since(start).Round(time.Millisecond))
+			return fmt.Errorf("execution timed out after %s: %w", g.defaultTimeout, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (g groupTimeoutManager) executeGroup(ctx context.Context, b groupTimeoutBatch, f func(ctx context.Context, resources []model.Resource) error) error {
+	start := time.Now()
+	gctx, cancel := context.WithTimeout(ctx, b.group.Timeout)
+	defer cancel()
+
+	err := f(gctx, b.resources)
+	if err != nil {
+		// Only the group timeout, not the parent context ones (e.g: cancelled by other group failure).
+		if gctx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			g.logger.WithValues(log.Kv{"group": b.group.ID}).Errorf("group context cancelled by deadline after %s", time.Since(start).Round(time.Millisecond))
+			return fmt.Errorf("group %q timed out after %s: %w", b.group.ID, b.group.Timeout, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
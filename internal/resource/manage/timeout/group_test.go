@@ -0,0 +1,175 @@
+package timeout_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/kahoy/internal/internalerrors"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/manage/managemock"
+	"github.com/slok/kahoy/internal/resource/manage/timeout"
+	"github.com/slok/kahoy/internal/storage/storagemock"
+)
+
+func TestGroupManager(t *testing.T) {
+	r1 := model.Resource{ID: "r1", GroupID: "g1"}
+	r2 := model.Resource{ID: "r2", GroupID: "g2"}
+	r3 := model.Resource{ID: "r3", GroupID: "g3"}
+	r4 := model.Resource{ID: "r4", GroupID: "g1"}
+	all := []model.Resource{r1, r2, r3, r4}
+
+	withDeadline := mock.MatchedBy(func(ctx context.Context) bool { _, ok := ctx.Deadline(); return ok })
+	withoutDeadline := mock.MatchedBy(func(ctx context.Context) bool { _, ok := ctx.Deadline(); return !ok })
+	waitDeadline := func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }
+
+	withDeadlineAfter := func(d time.Duration) interface{} {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			deadline, ok := ctx.Deadline()
+			return ok && time.Until(deadline) > d
+		})
+	}
+
+	tests := map[string]struct {
+		operation      string
+		defaultTimeout time.Duration
+		mock           func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository)
+		expErr         string
+	}{
+		"Groups without timeout should be executed together without timeout.": {
+			operation: "Apply",
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, mock.Anything).Return(&model.Group{}, nil)
+				mrm.On("Apply", withoutDeadline, all).Once().Return(nil)
+			},
+		},
+
+		"Groups with timeout should be executed in their own executions with timeout.": {
+			operation: "Delete",
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1"}, nil)
+				mgr.On("GetGroup", mock.Anything, "g2").Return(&model.Group{ID: "g2", Timeout: time.Hour}, nil)
+				mgr.On("GetGroup", mock.Anything, "g3").Return(&model.Group{ID: "g3", Timeout: time.Hour}, nil)
+
+				mrm.On("Delete", withoutDeadline, []model.Resource{r1, r4}).Once().Return(nil)
+				mrm.On("Delete", withDeadline, []model.Resource{r2}).Once().Return(nil)
+				mrm.On("Delete", withDeadline, []model.Resource{r3}).Once().Return(nil)
+			},
+		},
+
+		"Groups without timeout should be executed with the default timeout.": {
+			operation:      "Apply",
+			defaultTimeout: time.Hour,
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, mock.Anything).Return(&model.Group{}, nil)
+				mrm.On("Apply", withDeadline, all).Once().Return(nil)
+			},
+		},
+
+		"Groups with a timeout longer than the default timeout should be executed with their own timeout.": {
+			operation:      "Apply",
+			defaultTimeout: time.Millisecond,
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1"}, nil)
+				mgr.On("GetGroup", mock.Anything, "g2").Return(&model.Group{ID: "g2", Timeout: time.Hour}, nil)
+				mgr.On("GetGroup", mock.Anything, "g3").Return(&model.Group{ID: "g3", Timeout: time.Hour}, nil)
+
+				mrm.On("Apply", withDeadline, []model.Resource{r1, r4}).Once().Return(nil)
+				mrm.On("Apply", withDeadlineAfter(time.Minute), []model.Resource{r2}).Once().Return(nil)
+				mrm.On("Apply", withDeadlineAfter(time.Minute), []model.Resource{r3}).Once().Return(nil)
+			},
+		},
+
+		"Groups without timeout that exceed the default timeout should fail.": {
+			operation:      "Apply",
+			defaultTimeout: time.Millisecond,
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, mock.Anything).Return(&model.Group{}, nil)
+				mrm.On("Apply", withDeadline, all).Once().Run(waitDeadline).Return(context.DeadlineExceeded)
+			},
+			expErr: "execution timed out after 1ms: context deadline exceeded",
+		},
+
+		"A group that exceeds its timeout should fail naming the group.": {
+			operation: "Apply",
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, "g1").Return(&model.Group{ID: "g1"}, nil)
+				mgr.On("GetGroup", mock.Anything, "g2").Return(&model.Group{ID: "g2", Timeout: time.Millisecond}, nil)
+				mgr.On("GetGroup", mock.Anything, "g3").Return(&model.Group{ID: "g3", Timeout: time.Hour}, nil)
+
+				mrm.On("Apply", withoutDeadline, []model.Resource{r1, r4}).Once().Return(nil)
+				mrm.On("Apply", withDeadline, []model.Resource{r2}).Once().Run(waitDeadline).Return(context.DeadlineExceeded)
+				mrm.On("Apply", withDeadline, []model.Resource{r3}).Maybe().Return(nil)
+			},
+			expErr: `group "g2" timed out after 1ms: context deadline exceeded`,
+		},
+
+		"A regular error on a group with timeout should fail.": {
+			operation: "Apply",
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, mock.Anything).Return(&model.Group{ID: "g1", Timeout: time.Hour}, nil)
+				mrm.On("Apply", withDeadline, mock.Anything).Once().Return(errors.New("whatever"))
+			},
+			expErr: "whatever",
+		},
+
+		"Missing groups should be executed without timeout.": {
+			operation: "Delete",
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, "g2").Return(&model.Group{ID: "g2", Timeout: time.Hour}, nil)
+				mgr.On("GetGroup", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("whatever: %w", internalerrors.ErrMissing))
+
+				mrm.On("Delete", withoutDeadline, []model.Resource{r1, r3, r4}).Once().Return(nil)
+				mrm.On("Delete", withDeadline, []model.Resource{r2}).Once().Return(nil)
+			},
+		},
+
+		"An error getting a group should fail.": {
+			operation: "Apply",
+			mock: func(mrm *managemock.ResourceManager, mgr *storagemock.GroupRepository) {
+				mgr.On("GetGroup", mock.Anything, mock.Anything).Return(nil, errors.New("whatever"))
+			},
+			expErr: `could not get group "g1": whatever`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mrm := &managemock.ResourceManager{}
+			mgr := &storagemock.GroupRepository{}
+			test.mock(mrm, mgr)
+
+			// Execute.
+			manager, err := timeout.NewGroupManager(timeout.GroupManagerConfig{
+				Manager:         mrm,
+				GroupRepository: mgr,
+				DefaultTimeout:  test.defaultTimeout,
+			})
+			require.NoError(err)
+
+			if test.operation == "Delete" {
+				err = manager.Delete(context.TODO(), all)
+			} else {
+				err = manager.Apply(context.TODO(), all)
+			}
+
+			// Check.
+			if test.expErr != "" {
+				assert.EqualError(err, test.expErr)
+			} else {
+				assert.NoError(err)
+			}
+			mrm.AssertExpectations(t)
+		})
+	}
+}
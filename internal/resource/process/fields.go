@@ -0,0 +1,96 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/fieldpath"
+)
+
+type ignoreFieldsRule struct {
+	kubeTypeRegex *regexp.Regexp
+	groupRegex    *regexp.Regexp
+	paths         []string
+}
+
+func (i ignoreFieldsRule) matches(r model.Resource) bool {
+	if i.kubeTypeRegex != nil && !i.kubeTypeRegex.MatchString(resourceKubeType(r)) {
+		return false
+	}
+
+	if i.groupRegex != nil && !i.groupRegex.MatchString(r.GroupID) {
+		return false
+	}
+
+	return true
+}
+
+// NewIgnoreFieldsProcessor returns a new Resource processor that will remove the fields of
+// the rules from the resources that match the rules Kubernetes type and group.
+// Removing these fields makes Kahoy ignore them when comparing and applying the resources.
+func NewIgnoreFieldsProcessor(rules []model.IgnoreFieldsRule, logger log.Logger) (ResourceProcessor, error) {
+	logger = logger.WithValues(log.Kv{"app-svc": "process.IgnoreFieldsProcessor"})
+
+	compiledRules := make([]ignoreFieldsRule, 0, len(rules))
+	for _, rule := range rules {
+		cr := ignoreFieldsRule{paths: rule.Paths}
+
+		if rule.KubeTypeRegex != "" {
+			r, err := regexp.Compile(rule.KubeTypeRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex %q: %w", rule.KubeTypeRegex, err)
+			}
+			cr.kubeTypeRegex = r
+		}
+
+		if rule.GroupRegex != "" {
+			r, err := regexp.Compile(rule.GroupRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex %q: %w", rule.GroupRegex, err)
+			}
+			cr.groupRegex = r
+		}
+
+		for _, path := range rule.Paths {
+			_, err := fieldpath.Parse(path)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field path: %w", err)
+			}
+		}
+
+		compiledRules = append(compiledRules, cr)
+	}
+
+	return ResourceProcessorFunc(func(ctx context.Context, resources []model.Resource) ([]model.Resource, error) {
+		// If there are no rules, there is nothing to do.
+		if len(compiledRules) == 0 {
+			return resources, nil
+		}
+
+		newRes := make([]model.Resource, 0, len(resources))
+		for _, r := range resources {
+			paths := []string{}
+			for _, rule := range compiledRules {
+				if rule.matches(r) {
+					paths = append(paths, rule.paths...)
+				}
+			}
+
+			if len(paths) > 0 {
+				obj, err := fieldpath.Remove(r.K8sObject, paths)
+				if err != nil {
+					return nil, fmt.Errorf("could not remove ignored fields from %q resource: %w", r.ID, err)
+				}
+				r.K8sObject = obj
+				resourceLogger(logger, r).Debugf("resource fields ignored")
+			}
+
+			newRes = append(newRes, r)
+		}
+
+		return newRes, nil
+	}), nil
+}
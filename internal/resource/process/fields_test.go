@@ -0,0 +1,133 @@
+package process_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/resource/process"
+)
+
+func newReplicatedResource(kubeAPIVersion, kubeType, name, group string, withFields bool) model.Resource {
+	type tm = map[string]interface{}
+
+	obj := &unstructured.Unstructured{
+		Object: tm{
+			"apiVersion": kubeAPIVersion,
+			"kind":       kubeType,
+			"metadata": tm{
+				"name": name,
+				"annotations": tm{
+					"test": "test",
+				},
+			},
+			"spec": tm{},
+		},
+	}
+
+	if withFields {
+		obj.Object["spec"].(tm)["replicas"] = int64(3)
+		obj.Object["metadata"].(tm)["annotations"].(tm)["some-controller/a"] = "a"
+	}
+
+	return model.Resource{ID: name, GroupID: group, K8sObject: obj}
+}
+
+func TestIgnoreFieldsProcessor(t *testing.T) {
+	tests := map[string]struct {
+		rules        []model.IgnoreFieldsRule
+		resources    []model.Resource
+		expResources []model.Resource
+		expErr       bool
+	}{
+		"Not having rules should not change anything.": {
+			resources: []model.Resource{
+				newReplicatedResource("apps/v1", "Deployment", "r1", "g1", true),
+			},
+			expResources: []model.Resource{
+				newReplicatedResource("apps/v1", "Deployment", "r1", "g1", true),
+			},
+		},
+
+		"Having rules should ignore the fields of the resources that match the kube type.": {
+			rules: []model.IgnoreFieldsRule{
+				{KubeTypeRegex: "apps/v1/Deployment", Paths: []string{"spec.replicas", "metadata.annotations[some-controller/*]"}},
+			},
+			resources: []model.Resource{
+				newReplicatedResource("apps/v1", "Deployment", "r1", "g1", true),
+				newReplicatedResource("apps/v1", "StatefulSet", "r2", "g1", true),
+			},
+			expResources: []model.Resource{
+				newReplicatedResource("apps/v1", "Deployment", "r1", "g1", false),
+				newReplicatedResource("apps/v1", "StatefulSet", "r2", "g1", true),
+			},
+		},
+
+		"Having rules should ignore the fields of the resources that match the group.": {
+			rules: []model.IgnoreFieldsRule{
+				{GroupRegex: "^g1$", Paths: []string{"spec.replicas", "metadata.annotations[some-controller/*]"}},
+			},
+			resources: []model.Resource{
+				newReplicatedResource("apps/v1", "Deployment", "r1", "g1", true),
+				newReplicatedResource("apps/v1", "Deployment", "r2", "g2", true),
+			},
+			expResources: []model.Resource{
+				newReplicatedResource("apps/v1", "Deployment", "r1", "g1", false),
+				newReplicatedResource("apps/v1", "Deployment", "r2", "g2", true),
+			},
+		},
+
+		"Having rules with kube type and group should ignore the fields of the resources that match both.": {
+			rules: []model.IgnoreFieldsRule{
+				{KubeTypeRegex: "Deployment", GroupRegex: "^g1$", Paths: []string{"spec.replicas", "metadata.annotations[some-controller/*]"}},
+			},
+			resources: []model.Resource{
+				newReplicatedResource("apps/v1", "Deployment", "r1", "g1", true),
+				newReplicatedResource("apps/v1", "Deployment", "r2", "g2", true),
+				newReplicatedResource("apps/v1", "StatefulSet", "r3", "g1", true),
+			},
+			expResources: []model.Resource{
+				newReplicatedResource("apps/v1", "Deployment", "r1", "g1", false),
+				newReplicatedResource("apps/v1", "Deployment", "r2", "g2", true),
+				newReplicatedResource("apps/v1", "StatefulSet", "r3", "g1", true),
+			},
+		},
+
+		"Invalid kube type regex should fail.": {
+			rules: []model.IgnoreFieldsRule{
+				{KubeTypeRegex: "[", Paths: []string{"spec.replicas"}},
+			},
+			expErr: true,
+		},
+
+		"Invalid paths should fail.": {
+			rules: []model.IgnoreFieldsRule{
+				{Paths: []string{"spec.replicas["}},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			proc, err := process.NewIgnoreFieldsProcessor(test.rules, log.Noop)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			gotResources, err := proc.Process(context.TODO(), test.resources)
+			require.NoError(err)
+			assert.Equal(test.expResources, gotResources)
+		})
+	}
+}
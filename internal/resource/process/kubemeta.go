@@ -34,15 +34,7 @@ func NewExcludeKubeTypeProcessor(kubeTypeRegex []string, logger log.Logger) (Res
 		newRes := []model.Resource{}
 
 		for _, r := range resources {
-			gvk := r.K8sObject.GetObjectKind().GroupVersionKind()
-
-			// Get `extensions/v1beta1/Ingress` or `v1/Pod` style kubernetes type.
-			parts := []string{}
-			if gvk.Group != "" {
-				parts = append(parts, gvk.Group)
-			}
-			parts = append(parts, gvk.Version, gvk.Kind)
-			kubeType := strings.Join(parts, "/")
+			kubeType := resourceKubeType(r)
 
 			// Check if any of the regexes match, if they match, then exclude them.
 			match := false
@@ -174,6 +166,19 @@ func NewKubeAnnotationSelectorProcessor(kubeSelector string, logger log.Logger)
 	}), nil
 }
 
+// resourceKubeType returns `extensions/v1beta1/Ingress` or `v1/Pod` style kubernetes type.
+func resourceKubeType(r model.Resource) string {
+	gvk := r.K8sObject.GetObjectKind().GroupVersionKind()
+
+	parts := []string{}
+	if gvk.Group != "" {
+		parts = append(parts, gvk.Group)
+	}
+	parts = append(parts, gvk.Version, gvk.Kind)
+
+	return strings.Join(parts, "/")
+}
+
 func resourceLogger(l log.Logger, r model.Resource) log.Logger {
 	return l.WithValues(log.Kv{
 		"resource-id":       r.ID,
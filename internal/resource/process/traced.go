@@ -0,0 +1,25 @@
+package process
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/tracing"
+)
+
+// NewTracedResourceProcessor wraps a processor and traces the processing.
+func NewTracedResourceProcessor(tracer trace.Tracer, next ResourceProcessor) ResourceProcessor {
+	return ResourceProcessorFunc(func(ctx context.Context, resources []model.Resource) ([]model.Resource, error) {
+		ctx, span := tracer.Start(ctx, "process", trace.WithAttributes(
+			attribute.Int("kahoy.resources", len(resources)),
+		))
+
+		processed, err := next.Process(ctx, resources)
+		tracing.EndSpan(span, err, attribute.Int("kahoy.processed_resources", len(processed)))
+
+		return processed, err
+	})
+}
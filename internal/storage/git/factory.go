@@ -70,6 +70,15 @@ func (c *RepositoriesConfig) defaults() error {
 	return nil
 }
 
+// Repositories are the old and new repositories based on Git.
+type Repositories struct {
+	Old *fs.Repository
+	New *fs.Repository
+	// OldCommit and NewCommit are the Git commit hashes used for the repositories.
+	OldCommit string
+	NewCommit string
+}
+
 // NewRepositories is a factory that knows how to return two fs repositories based on Git.
 //
 // 1. Loads/clones a git repository (with all its files/worktree) from the fs into memory.
@@ -85,16 +94,16 @@ func (c *RepositoriesConfig) defaults() error {
 // Note: Cloned repos (memory) will have original repo (fs) local branches as remotes because of
 //       the clone, so to use local branch refs, we need to use remote notation, and remote
 //       branches are not supported.
-func NewRepositories(config RepositoriesConfig) (old, new *fs.Repository, err error) {
-	err = config.defaults()
+func NewRepositories(config RepositoriesConfig) (*Repositories, error) {
+	err := config.defaults()
 	if err != nil {
-		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
 	// Load git repo in memory if required.
 	oldGitRepo, newGitRepo, err := loadGitRepositories(config)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not load git repositores: %w", err)
+		return nil, fmt.Errorf("could not load git repositores: %w", err)
 	}
 
 	// Search git before commit if required.
@@ -103,7 +112,7 @@ func NewRepositories(config RepositoriesConfig) (old, new *fs.Repository, err er
 		config.Logger.Debugf("searching git before commit using common parent of HEAD and %s", config.GitDefaultBranch)
 		hash, err := getBeforeCommit(newGitRepo, config.GitDefaultBranch)
 		if err != nil {
-			return nil, nil, fmt.Errorf("could not get git before commit: %w", err)
+			return nil, fmt.Errorf("could not get git before commit: %w", err)
 		}
 		gitBeforeHash = *hash
 	} else {
@@ -113,43 +122,43 @@ func NewRepositories(config RepositoriesConfig) (old, new *fs.Repository, err er
 	// Get both file systems.
 	oldRepoFs, err := oldGitRepo.FileSystem()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	newRepoFs, err := newGitRepo.FileSystem()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Set old git repo in the `before commit` state.
 	err = oldGitRepo.Checkout(&git.CheckoutOptions{Hash: gitBeforeHash})
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Get both repository HEAD refs.
 	oldRef, err := oldGitRepo.Head()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	newRef, err := newGitRepo.Head()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Validations to help the user in case of misusage.
 	// Check old and new repos are not in the same state.
 	if oldRef.Hash() == newRef.Hash() {
-		return nil, nil, fmt.Errorf("old and new repo HEAD ref can't be the same (%s) use 'before commit'", oldRef.Hash())
+		return nil, fmt.Errorf("old and new repo HEAD ref can't be the same (%s) use 'before commit'", oldRef.Hash())
 	}
 
 	// Check our paths on each repo exist.
 	_, err = oldRepoFs.Stat(config.OldRelPath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("old git repo path %q: %w", config.OldRelPath, err)
+		return nil, fmt.Errorf("old git repo path %q: %w", config.OldRelPath, err)
 	}
 	_, err = newRepoFs.Stat(config.NewRelPath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("new git repo path %q: %w", config.NewRelPath, err)
+		return nil, fmt.Errorf("new git repo path %q: %w", config.NewRelPath, err)
 	}
 
 	config.Logger.Debugf("old repository worktree in %q commit", oldRef.Hash())
@@ -174,7 +183,7 @@ func NewRepositories(config RepositoriesConfig) (old, new *fs.Repository, err er
 		ModelFactory: config.ModelFactory,
 	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not create old Git fs %q repository storage: %w", config.OldRelPath, err)
+		return nil, fmt.Errorf("could not create old Git fs %q repository storage: %w", config.OldRelPath, err)
 	}
 
 	newRepo, err := fs.NewRepository(fs.RepositoryConfig{
@@ -191,10 +200,15 @@ func NewRepositories(config RepositoriesConfig) (old, new *fs.Repository, err er
 		ModelFactory: config.ModelFactory,
 	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not create new Git fs %q repository storage: %w", config.OldRelPath, err)
+		return nil, fmt.Errorf("could not create new Git fs %q repository storage: %w", config.OldRelPath, err)
 	}
 
-	return oldRepo, newRepo, nil
+	return &Repositories{
+		Old:       oldRepo,
+		New:       newRepo,
+		OldCommit: oldRef.Hash().String(),
+		NewCommit: newRef.Hash().String(),
+	}, nil
 }
 
 // loadGitRepositories will clone the repositories into memory.
@@ -289,7 +289,7 @@ func TestNewRepositories(t *testing.T) {
 			test.config.GoGitOldRepo = mOld
 			test.config.GoGitNewRepo = mNew
 			test.config.ModelFactory = &model.ResourceAndGroupFactory{}
-			_, _, err := git.NewRepositories(test.config)
+			_, err := git.NewRepositories(test.config)
 
 			// Check.
 			if test.expErr {
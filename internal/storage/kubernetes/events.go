@@ -0,0 +1,184 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/storage"
+)
+
+// EventClient knows how to create Kubernetes events.
+type EventClient interface {
+	CreateEvent(ctx context.Context, event *corev1.Event) error
+}
+
+//go:generate mockery --case underscore --output kubernetesmock --outpkg kubernetesmock --name EventClient
+
+// Event reasons.
+const (
+	EventReasonApplied = "KahoyApplied"
+	EventReasonDeleted = "KahoyDeleted"
+	EventReasonRun     = "KahoyRun"
+)
+
+const (
+	eventComponent              = "kahoy"
+	eventRunIDAnnotation        = "kahoy.slok.dev/run-id"
+	eventGitCommitAnnotation    = "kahoy.slok.dev/git-commit"
+	eventManagedByLabelKey      = "app.kubernetes.io/managed-by"
+	eventManagedByLabelValue    = "kahoy"
+	clusterScopedEventNamespace = metav1.NamespaceDefault
+)
+
+// EventStateRepositoryConfig is the configuration of the EventStateRepository.
+type EventStateRepositoryConfig struct {
+	// Namespace is where the summary event of the execution will be created.
+	Namespace string
+	Client    EventClient
+	Logger    log.Logger
+}
+
+func (c *EventStateRepositoryConfig) defaults() error {
+	if c.Namespace == "" {
+		c.Namespace = "default"
+	}
+
+	if c.Client == nil {
+		return fmt.Errorf("client is required")
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "kubernetes.EventStateRepository"})
+
+	return nil
+}
+
+type eventStateRepository struct {
+	namespace string
+	client    EventClient
+	logger    log.Logger
+}
+
+// NewEventStateRepository returns a state repository that creates Kubernetes events
+// on the applied and deleted resources of the stored state, and a summary event of
+// the execution on the configured namespace.
+func NewEventStateRepository(config EventStateRepositoryConfig) (storage.StateRepository, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return eventStateRepository{
+		namespace: config.Namespace,
+		client:    config.Client,
+		logger:    config.Logger,
+	}, nil
+}
+
+func (e eventStateRepository) StoreState(ctx context.Context, state model.State) error {
+	events := []*corev1.Event{}
+	for _, r := range state.AppliedResources {
+		events = append(events, e.newResourceEvent(state, r, EventReasonApplied, "applied"))
+	}
+	for _, r := range state.DeletedResources {
+		events = append(events, e.newResourceEvent(state, r, EventReasonDeleted, "deleted"))
+	}
+	events = append(events, e.newSummaryEvent(state))
+
+	// Events are best effort, try creating all of them.
+	failed := 0
+	for _, ev := range events {
+		err := e.client.CreateEvent(ctx, ev)
+		if err != nil {
+			failed++
+			e.logger.WithValues(log.Kv{"obj-ns": ev.Namespace, "obj-name": ev.InvolvedObject.Name}).Warningf("could not create event: %s", err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("could not create %d of %d events", failed, len(events))
+	}
+
+	return nil
+}
+
+func (e eventStateRepository) newResourceEvent(state model.State, r model.Resource, reason, action string) *corev1.Event {
+	gvk := r.K8sObject.GetObjectKind().GroupVersionKind()
+	ns := r.K8sObject.GetNamespace()
+	ref := corev1.ObjectReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  ns,
+		Name:       r.K8sObject.GetName(),
+	}
+
+	if ns == "" {
+		ns = clusterScopedEventNamespace
+	}
+
+	msg := fmt.Sprintf("Resource %s by Kahoy run %s%s", action, state.ID, commitInfo(state))
+
+	return newEvent(state, ns, ref, corev1.EventTypeNormal, reason, msg)
+}
+
+func (e eventStateRepository) newSummaryEvent(state model.State) *corev1.Event {
+	ref := corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Name:       e.namespace,
+	}
+
+	eventType := corev1.EventTypeNormal
+	if state.Status != model.ExecutionStatusSuccess {
+		eventType = corev1.EventTypeWarning
+	}
+
+	msg := fmt.Sprintf("Kahoy run %s %s: %d applied, %d deleted, %d released%s",
+		state.ID, state.Status, len(state.AppliedResources), len(state.DeletedResources), len(state.ReleasedResources), commitInfo(state))
+	if state.Error != "" {
+		msg = fmt.Sprintf("%s: %s", msg, state.Error)
+	}
+
+	return newEvent(state, e.namespace, ref, eventType, EventReasonRun, msg)
+}
+
+func newEvent(state model.State, ns string, ref corev1.ObjectReference, eventType, reason, msg string) *corev1.Event {
+	t := metav1.NewTime(state.EndedAt)
+	annotations := map[string]string{eventRunIDAnnotation: state.ID}
+	if state.GitCommit != "" {
+		annotations[eventGitCommitAnnotation] = state.GitCommit
+	}
+
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        strings.ToLower(fmt.Sprintf("%s.%s.%s", ref.Name, ref.Kind, state.ID)),
+			Namespace:   ns,
+			Labels:      map[string]string{eventManagedByLabelKey: eventManagedByLabelValue},
+			Annotations: annotations,
+		},
+		InvolvedObject: ref,
+		Reason:         reason,
+		Message:        msg,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: eventComponent},
+		FirstTimestamp: t,
+		LastTimestamp:  t,
+		Count:          1,
+	}
+}
+
+func commitInfo(state model.State) string {
+	if state.GitCommit == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" (commit %s)", state.GitCommit)
+}
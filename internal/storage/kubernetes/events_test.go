@@ -0,0 +1,149 @@
+package kubernetes_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+
+	internalkubernetes "github.com/slok/kahoy/internal/kubernetes"
+	"github.com/slok/kahoy/internal/log"
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/storage/kubernetes"
+	"github.com/slok/kahoy/internal/storage/kubernetes/kubernetesmock"
+)
+
+func newEventResource(apiVersion, kind, ns, name string) model.Resource {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(ns)
+	obj.SetName(name)
+
+	return model.Resource{ID: name, K8sObject: obj}
+}
+
+func newExpEvent(ns, name, reason, eventType, msg string, ref corev1.ObjectReference, t time.Time) corev1.Event {
+	return corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "kahoy"},
+			Annotations: map[string]string{
+				"kahoy.slok.dev/run-id":     "ID1",
+				"kahoy.slok.dev/git-commit": "1234",
+			},
+		},
+		InvolvedObject: ref,
+		Reason:         reason,
+		Message:        msg,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: "kahoy"},
+		FirstTimestamp: metav1.NewTime(t),
+		LastTimestamp:  metav1.NewTime(t),
+		Count:          1,
+	}
+}
+
+func TestEventStateRepositoryStoreState(t *testing.T) {
+	t0 := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := map[string]struct {
+		state     model.State
+		expEvents []corev1.Event
+	}{
+		"Storing a state should create events on the applied, deleted resources and a summary event.": {
+			state: model.State{
+				ID:        "ID1",
+				EndedAt:   t0,
+				Status:    model.ExecutionStatusSuccess,
+				GitCommit: "1234",
+				AppliedResources: []model.Resource{
+					newEventResource("apps/v1", "Deployment", "ns1", "app1"),
+					newEventResource("rbac.authorization.k8s.io/v1", "ClusterRole", "", "role1"),
+				},
+				DeletedResources: []model.Resource{
+					newEventResource("v1", "Service", "ns2", "svc1"),
+				},
+			},
+			expEvents: []corev1.Event{
+				newExpEvent("default", "default.namespace.id1", "KahoyRun", "Normal",
+					"Kahoy run ID1 success: 2 applied, 1 deleted, 0 released (commit 1234)",
+					corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "default"}, t0),
+				newExpEvent("default", "role1.clusterrole.id1", "KahoyApplied", "Normal",
+					"Resource applied by Kahoy run ID1 (commit 1234)",
+					corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "role1"}, t0),
+				newExpEvent("ns1", "app1.deployment.id1", "KahoyApplied", "Normal",
+					"Resource applied by Kahoy run ID1 (commit 1234)",
+					corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns1", Name: "app1"}, t0),
+				newExpEvent("ns2", "svc1.service.id1", "KahoyDeleted", "Normal",
+					"Resource deleted by Kahoy run ID1 (commit 1234)",
+					corev1.ObjectReference{APIVersion: "v1", Kind: "Service", Namespace: "ns2", Name: "svc1"}, t0),
+			},
+		},
+
+		"Storing a failed state should create a warning summary event with the error.": {
+			state: model.State{
+				ID:        "ID1",
+				EndedAt:   t0,
+				Status:    model.ExecutionStatusFailed,
+				Error:     "whatever",
+				GitCommit: "1234",
+			},
+			expEvents: []corev1.Event{
+				newExpEvent("default", "default.namespace.id1", "KahoyRun", "Warning",
+					"Kahoy run ID1 failed: 0 applied, 0 deleted, 0 released (commit 1234): whatever",
+					corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "default"}, t0),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cli := fake.NewSimpleClientset()
+			repo, err := kubernetes.NewEventStateRepository(kubernetes.EventStateRepositoryConfig{
+				Client: internalkubernetes.NewClient(cli, log.Noop),
+			})
+			require.NoError(err)
+
+			err = repo.StoreState(context.TODO(), test.state)
+			require.NoError(err)
+
+			// Check.
+			gotEvents, err := cli.CoreV1().Events("").List(context.TODO(), metav1.ListOptions{})
+			require.NoError(err)
+			assert.Equal(test.expEvents, gotEvents.Items)
+		})
+	}
+}
+
+func TestEventStateRepositoryStoreStateError(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mc := &kubernetesmock.EventClient{}
+	mc.On("CreateEvent", mock.Anything, mock.MatchedBy(func(e *corev1.Event) bool { return e.Reason == "KahoyApplied" })).Once().Return(fmt.Errorf("whatever"))
+	mc.On("CreateEvent", mock.Anything, mock.MatchedBy(func(e *corev1.Event) bool { return e.Reason == "KahoyRun" })).Once().Return(nil)
+
+	repo, err := kubernetes.NewEventStateRepository(kubernetes.EventStateRepositoryConfig{Client: mc})
+	require.NoError(err)
+
+	// All the events should be created even if some of them fail.
+	err = repo.StoreState(context.TODO(), model.State{
+		ID:               "ID1",
+		AppliedResources: []model.Resource{newEventResource("v1", "Pod", "ns1", "pod1")},
+	})
+	assert.Error(err)
+	mc.AssertExpectations(t)
+}
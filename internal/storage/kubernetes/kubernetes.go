@@ -163,6 +163,15 @@ func (r Repository) StoreState(ctx context.Context, state model.State) error {
 		}
 	}
 
+	// Store moved resources, these could have not been applied, but we need to track
+	// their new group.
+	for _, m := range state.MovedResources {
+		err := r.storeResource(ctx, m.Resource)
+		if err != nil {
+			return fmt.Errorf("could not store moved resource: %w", err)
+		}
+	}
+
 	// Delete deleted resources.
 	for _, res := range state.DeletedResources {
 		err := r.deleteResource(ctx, res)
@@ -171,6 +180,14 @@ func (r Repository) StoreState(ctx context.Context, state model.State) error {
 		}
 	}
 
+	// Released resources are not managed anymore, delete them.
+	for _, res := range state.ReleasedResources {
+		err := r.deleteResource(ctx, res)
+		if err != nil {
+			return fmt.Errorf("could not store released resource: %w", err)
+		}
+	}
+
 	return nil
 }
 
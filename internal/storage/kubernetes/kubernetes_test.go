@@ -624,6 +624,21 @@ func TestRepositoryStoreState(t *testing.T) {
 			},
 		},
 
+		"Having a state with released resources should delete them from the storage.": {
+			state: model.State{
+				ReleasedResources: []model.Resource{
+					newResource("pid1", "gid1", "mp1", "ns1", "name1"),
+				},
+			},
+			config: kubernetes.RepositoryConfig{
+				Namespace: "test-ns",
+				StorageID: "test-st-id",
+			},
+			mock: func(mc *kubernetesmock.K8sClient, ms *kubernetesmock.K8sObjectSerializer) {
+				mc.On("EnsureMissingSecret", mock.Anything, "test-ns", "59d09a913a1718071b37a97cb2caf42d").Once().Return(nil)
+			},
+		},
+
 		"Having an error while deleting resources should fail.": {
 			state: model.State{
 				DeletedResources: []model.Resource{
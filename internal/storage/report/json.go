@@ -42,9 +42,56 @@ type jsonReport struct {
 	// Representation in RFC3339.
 	StartedAt string `json:"started_at"`
 	// Representation in RFC3339.
-	EndedAt          string         `json:"ended_at"`
-	AppliedResources []jsonResource `json:"applied_resources"`
-	DeletedResources []jsonResource `json:"deleted_resources"`
+	EndedAt           string         `json:"ended_at"`
+	AppliedResources  []jsonResource `json:"applied_resources"`
+	DeletedResources  []jsonResource `json:"deleted_resources"`
+	ReleasedResources []jsonResource `json:"released_resources"`
+	ReplacedResources []jsonResource `json:"replaced_resources"`
+	Conflicts         []jsonConflict `json:"conflicts"`
+	MovedResources    []jsonMoved    `json:"moved_resources"`
+	Status            string         `json:"status"`
+	Error             string         `json:"error,omitempty"`
+	GitBeforeCommit   string         `json:"git_before_commit,omitempty"`
+	GitCommit         string         `json:"git_commit,omitempty"`
+	Batches           []jsonBatch    `json:"batches"`
+	ResourceResults   []jsonResult   `json:"resource_results"`
+	HookResults       []jsonHook     `json:"hook_results"`
+	Retries           []jsonRetry    `json:"retries"`
+}
+
+type jsonBatch struct {
+	Operation string   `json:"operation"`
+	Priority  *int     `json:"priority,omitempty"`
+	Resources []string `json:"resources"`
+	// Representation in RFC3339.
+	StartedAt       string  `json:"started_at"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+type jsonResult struct {
+	Resource  jsonResource `json:"resource"`
+	Operation string       `json:"operation"`
+	Outcome   string       `json:"outcome"`
+	Error     string       `json:"error,omitempty"`
+}
+
+type jsonHook struct {
+	Group string `json:"group"`
+	Type  string `json:"type"`
+	Cmd   string `json:"cmd"`
+	// Representation in RFC3339.
+	StartedAt       string  `json:"started_at"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	ExitCode        int     `json:"exit_code"`
+	Error           string  `json:"error,omitempty"`
+}
+
+type jsonRetry struct {
+	Resource  jsonResource `json:"resource"`
+	Operation string       `json:"operation"`
+	Attempt   int          `json:"attempt"`
+	Error     string       `json:"error"`
 }
 
 type jsonResource struct {
@@ -57,6 +104,22 @@ type jsonResource struct {
 	Name       string `json:"name"`
 }
 
+type jsonMoved struct {
+	Resource jsonResource `json:"resource"`
+	OldGroup string       `json:"old_group"`
+}
+
+type jsonConflict struct {
+	Resource      jsonResource        `json:"resource"`
+	Policy        string              `json:"policy"`
+	FieldManagers []jsonFieldConflict `json:"field_managers"`
+}
+
+type jsonFieldConflict struct {
+	Manager string   `json:"manager"`
+	Fields  []string `json:"fields"`
+}
+
 func mapStateToJSON(state model.State) ([]byte, error) {
 	// Map resources.
 	applied := make([]jsonResource, 0, len(state.AppliedResources))
@@ -67,14 +130,96 @@ func mapStateToJSON(state model.State) ([]byte, error) {
 	for _, res := range state.DeletedResources {
 		deleted = append(deleted, mapResourceToJSON(res))
 	}
+	released := make([]jsonResource, 0, len(state.ReleasedResources))
+	for _, res := range state.ReleasedResources {
+		released = append(released, mapResourceToJSON(res))
+	}
+	replaced := make([]jsonResource, 0, len(state.ReplacedResources))
+	for _, res := range state.ReplacedResources {
+		replaced = append(replaced, mapResourceToJSON(res))
+	}
+
+	conflicts := make([]jsonConflict, 0, len(state.Conflicts))
+	for _, c := range state.Conflicts {
+		conflicts = append(conflicts, mapConflictToJSON(c))
+	}
+
+	moved := make([]jsonMoved, 0, len(state.MovedResources))
+	for _, m := range state.MovedResources {
+		moved = append(moved, jsonMoved{
+			Resource: mapResourceToJSON(m.Resource),
+			OldGroup: m.OldGroupID,
+		})
+	}
+
+	batches := make([]jsonBatch, 0, len(state.Batches))
+	for _, b := range state.Batches {
+		ids := make([]string, 0, len(b.Resources))
+		for _, r := range b.Resources {
+			ids = append(ids, r.ID)
+		}
+		batches = append(batches, jsonBatch{
+			Operation:       string(b.Operation),
+			Priority:        b.Priority,
+			Resources:       ids,
+			StartedAt:       b.StartedAt.Format(time.RFC3339),
+			DurationSeconds: b.Duration.Seconds(),
+			Error:           b.Error,
+		})
+	}
+
+	results := make([]jsonResult, 0, len(state.ResourceResults))
+	for _, r := range state.ResourceResults {
+		results = append(results, jsonResult{
+			Resource:  mapResourceToJSON(r.Resource),
+			Operation: string(r.Operation),
+			Outcome:   string(r.Outcome),
+			Error:     r.Error,
+		})
+	}
+
+	hooks := make([]jsonHook, 0, len(state.HookResults))
+	for _, h := range state.HookResults {
+		hooks = append(hooks, jsonHook{
+			Group:           h.GroupID,
+			Type:            h.Type,
+			Cmd:             h.Cmd,
+			StartedAt:       h.StartedAt.Format(time.RFC3339),
+			DurationSeconds: h.Duration.Seconds(),
+			ExitCode:        h.ExitCode,
+			Error:           h.Error,
+		})
+	}
+
+	retries := make([]jsonRetry, 0, len(state.Retries))
+	for _, r := range state.Retries {
+		retries = append(retries, jsonRetry{
+			Resource:  mapResourceToJSON(r.Resource),
+			Operation: string(r.Operation),
+			Attempt:   r.Attempt,
+			Error:     r.Error,
+		})
+	}
 
 	jr := jsonReport{
-		Version:          "v1",
-		ID:               state.ID,
-		StartedAt:        state.StartedAt.Format(time.RFC3339),
-		EndedAt:          state.EndedAt.Format(time.RFC3339),
-		AppliedResources: applied,
-		DeletedResources: deleted,
+		Version:           "v1",
+		ID:                state.ID,
+		StartedAt:         state.StartedAt.Format(time.RFC3339),
+		EndedAt:           state.EndedAt.Format(time.RFC3339),
+		AppliedResources:  applied,
+		DeletedResources:  deleted,
+		ReleasedResources: released,
+		ReplacedResources: replaced,
+		Conflicts:         conflicts,
+		MovedResources:    moved,
+		Status:            string(state.Status),
+		Error:             state.Error,
+		GitBeforeCommit:   state.GitBeforeCommit,
+		GitCommit:         state.GitCommit,
+		Batches:           batches,
+		ResourceResults:   results,
+		HookResults:       hooks,
+		Retries:           retries,
 	}
 
 	data, err := json.Marshal(jr)
@@ -100,3 +245,19 @@ func mapResourceToJSON(res model.Resource) jsonResource {
 
 	return jr
 }
+
+func mapConflictToJSON(c model.ResourceConflict) jsonConflict {
+	managers := make([]jsonFieldConflict, 0, len(c.Conflicts))
+	for _, fc := range c.Conflicts {
+		managers = append(managers, jsonFieldConflict{
+			Manager: fc.Manager,
+			Fields:  fc.Fields,
+		})
+	}
+
+	return jsonConflict{
+		Resource:      mapResourceToJSON(c.Resource),
+		Policy:        string(c.Policy),
+		FieldManagers: managers,
+	}
+}
@@ -35,6 +35,7 @@ func newCustomResource(kubeAPIVersion, kubeType, ns, name, group string) model.R
 func TestStateRepository(t *testing.T) {
 	t0, _ := time.Parse(time.RFC3339, "1912-06-23T01:02:03Z")
 	t1, _ := time.Parse(time.RFC3339, "1912-06-23T01:02:42Z")
+	priority := 100
 
 	tests := map[string]struct {
 		state  model.State
@@ -47,7 +48,7 @@ func TestStateRepository(t *testing.T) {
 				StartedAt: t0,
 				EndedAt:   t1,
 			},
-			expOut: `{"version":"v1","id":"id1","started_at":"1912-06-23T01:02:03Z","ended_at":"1912-06-23T01:02:42Z","applied_resources":[],"deleted_resources":[]}`,
+			expOut: `{"version":"v1","id":"id1","started_at":"1912-06-23T01:02:03Z","ended_at":"1912-06-23T01:02:42Z","applied_resources":[],"deleted_resources":[],"released_resources":[],"replaced_resources":[],"conflicts":[],"moved_resources":[],"status":"","batches":[],"resource_results":[],"hook_results":[],"retries":[]}`,
 		},
 
 		"Having resources should give the correct state without resorces": {
@@ -63,8 +64,66 @@ func TestStateRepository(t *testing.T) {
 					newCustomResource("apps/v1", "Deployment", "ns3", "applied3", "group3"),
 					newCustomResource("rbac.authorization.k8s.io/v1", "Role", "ns4", "applied4", "group4"),
 				},
+				ReleasedResources: []model.Resource{
+					newCustomResource("v1", "PersistentVolumeClaim", "ns5", "released1", "group5"),
+				},
+				ReplacedResources: []model.Resource{
+					newCustomResource("batch/v1", "Job", "ns6", "replaced1", "group6"),
+				},
+				Conflicts: []model.ResourceConflict{
+					{
+						Resource: newCustomResource("apps/v1", "Deployment", "ns7", "conflict1", "group7"),
+						Policy:   model.ConflictPolicySkipField,
+						Conflicts: []model.FieldConflict{
+							{Manager: "hpa", Fields: []string{".spec.replicas"}},
+						},
+					},
+				},
+				MovedResources: []model.MovedResource{
+					{
+						Resource:   newCustomResource("v1", "Service", "ns8", "moved1", "group8"),
+						OldGroupID: "group9",
+					},
+				},
+				Status:          model.ExecutionStatusFailed,
+				Error:           "whatever",
+				GitBeforeCommit: "1234",
+				GitCommit:       "5678",
+				Batches: []model.BatchResult{
+					{
+						Operation: model.OperationApply,
+						Priority:  &priority,
+						Resources: []model.Resource{newCustomResource("v1", "Pod", "ns1", "applied1", "group1")},
+						StartedAt: t0,
+						Duration:  1500 * time.Millisecond,
+						Error:     "whatever",
+					},
+				},
+				ResourceResults: []model.ResourceResult{
+					{
+						Resource:  newCustomResource("v1", "Pod", "ns1", "applied1", "group1"),
+						Operation: model.OperationApply,
+						Outcome:   model.ResourceOutcomeFailed,
+						Error:     "whatever",
+					},
+				},
+				HookResults: []model.HookResult{
+					{GroupID: "group1", Type: "pre", Cmd: "echo", StartedAt: t0, Duration: 2 * time.Second, ExitCode: 0},
+				},
+				Retries: []model.ResourceRetry{
+					{
+						Resource:  newCustomResource("v1", "Pod", "ns1", "applied1", "group1"),
+						Operation: model.OperationApply,
+						Attempt:   1,
+						Error:     "transient error",
+					},
+				},
 			},
-			expOut: `{"version":"v1","id":"id1","started_at":"1912-06-23T01:02:03Z","ended_at":"1912-06-23T01:02:42Z","applied_resources":[{"id":"applied1","group":"group1","gvk":"/v1/Pod","api_version":"v1","kind":"Pod","namespace":"ns1","name":"applied1"},{"id":"applied2","group":"group2","gvk":"networking.k8s.io/v1beta1/Ingress","api_version":"networking.k8s.io/v1beta1","kind":"Ingress","namespace":"ns2","name":"applied2"}],"deleted_resources":[{"id":"applied3","group":"group3","gvk":"apps/v1/Deployment","api_version":"apps/v1","kind":"Deployment","namespace":"ns3","name":"applied3"},{"id":"applied4","group":"group4","gvk":"rbac.authorization.k8s.io/v1/Role","api_version":"rbac.authorization.k8s.io/v1","kind":"Role","namespace":"ns4","name":"applied4"}]}`,
+			expOut: `{"version":"v1","id":"id1","started_at":"1912-06-23T01:02:03Z","ended_at":"1912-06-23T01:02:42Z","applied_resources":[{"id":"applied1","group":"group1","gvk":"/v1/Pod","api_version":"v1","kind":"Pod","namespace":"ns1","name":"applied1"},{"id":"applied2","group":"group2","gvk":"networking.k8s.io/v1beta1/Ingress","api_version":"networking.k8s.io/v1beta1","kind":"Ingress","namespace":"ns2","name":"applied2"}],"deleted_resources":[{"id":"applied3","group":"group3","gvk":"apps/v1/Deployment","api_version":"apps/v1","kind":"Deployment","namespace":"ns3","name":"applied3"},{"id":"applied4","group":"group4","gvk":"rbac.authorization.k8s.io/v1/Role","api_version":"rbac.authorization.k8s.io/v1","kind":"Role","namespace":"ns4","name":"applied4"}],"released_resources":[{"id":"released1","group":"group5","gvk":"/v1/PersistentVolumeClaim","api_version":"v1","kind":"PersistentVolumeClaim","namespace":"ns5","name":"released1"}],"replaced_resources":[{"id":"replaced1","group":"group6","gvk":"batch/v1/Job","api_version":"batch/v1","kind":"Job","namespace":"ns6","name":"replaced1"}],"conflicts":[{"resource":{"id":"conflict1","group":"group7","gvk":"apps/v1/Deployment","api_version":"apps/v1","kind":"Deployment","namespace":"ns7","name":"conflict1"},"policy":"skip-field","field_managers":[{"manager":"hpa","fields":[".spec.replicas"]}]}],"moved_resources":[{"resource":{"id":"moved1","group":"group8","gvk":"/v1/Service","api_version":"v1","kind":"Service","namespace":"ns8","name":"moved1"},"old_group":"group9"}],"status":"failed","error":"whatever","git_before_commit":"1234","git_commit":"5678",` +
+				`"batches":[{"operation":"apply","priority":100,"resources":["applied1"],"started_at":"1912-06-23T01:02:03Z","duration_seconds":1.5,"error":"whatever"}],` +
+				`"resource_results":[{"resource":{"id":"applied1","group":"group1","gvk":"/v1/Pod","api_version":"v1","kind":"Pod","namespace":"ns1","name":"applied1"},"operation":"apply","outcome":"failed","error":"whatever"}],` +
+				`"hook_results":[{"group":"group1","type":"pre","cmd":"echo","started_at":"1912-06-23T01:02:03Z","duration_seconds":2,"exit_code":0}],` +
+				`"retries":[{"resource":{"id":"applied1","group":"group1","gvk":"/v1/Pod","api_version":"v1","kind":"Pod","namespace":"ns1","name":"applied1"},"operation":"apply","attempt":1,"error":"transient error"}]}`,
 		},
 	}
 
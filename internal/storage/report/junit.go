@@ -0,0 +1,195 @@
+package report
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/storage"
+)
+
+type junitStateRepository struct {
+	out io.Writer
+}
+
+// NewJUnitStateRepository returns a new repository that knows how to write states
+// as JUnit XML on the received output in report mode.
+//
+// Each group is a test suite, and each resource action and hook execution of
+// the group is a test case.
+func NewJUnitStateRepository(out io.Writer) storage.StateRepository {
+	return junitStateRepository{out: out}
+}
+
+func (j junitStateRepository) StoreState(ctx context.Context, state model.State) error {
+	data, err := xml.MarshalIndent(mapStateToJUnit(state), "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not map state to JUnit: %w", err)
+	}
+
+	_, err = j.out.Write(append([]byte(xml.Header), data...))
+	if err != nil {
+		return fmt.Errorf("could not write JUnit state: %w", err)
+	}
+
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitExecutionSuite is the suite used for the errors and hooks that are not
+// related with a specific group, like cancellations or run level hooks.
+const junitExecutionSuite = "kahoy"
+
+func mapStateToJUnit(state model.State) junitTestSuites {
+	type suiteData struct {
+		cases    []junitTestCase
+		duration time.Duration
+	}
+	suites := map[string]*suiteData{}
+	getSuite := func(id string) *suiteData {
+		s, ok := suites[id]
+		if !ok {
+			s = &suiteData{}
+			suites[id] = s
+		}
+		return s
+	}
+
+	// Resource actions.
+	for _, r := range state.ResourceResults {
+		tc := junitTestCase{
+			Name:      fmt.Sprintf("%s %s", r.Operation, r.Resource.ID),
+			Classname: r.Resource.GroupID,
+			Time:      junitSeconds(0),
+		}
+		if r.Outcome == model.ResourceOutcomeFailed {
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s failed", r.Operation),
+				Type:    string(r.Operation),
+				Text:    r.Error,
+			}
+		}
+
+		s := getSuite(r.Resource.GroupID)
+		s.cases = append(s.cases, tc)
+	}
+
+	// Hooks.
+	for _, h := range state.HookResults {
+		suiteID := h.GroupID
+		if suiteID == "" {
+			suiteID = junitExecutionSuite
+		}
+
+		tc := junitTestCase{
+			Name:      fmt.Sprintf("%s hook: %s", h.Type, h.Cmd),
+			Classname: suiteID,
+			Time:      junitSeconds(h.Duration),
+		}
+		if h.Error != "" {
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s hook failed with exit code %d", h.Type, h.ExitCode),
+				Type:    "hook",
+				Text:    h.Error,
+			}
+		}
+
+		s := getSuite(suiteID)
+		s.cases = append(s.cases, tc)
+		s.duration += h.Duration
+	}
+
+	// Execution errors that could not be mapped to a resource or a hook.
+	failed := false
+	for _, s := range suites {
+		for _, tc := range s.cases {
+			failed = failed || tc.Failure != nil
+		}
+	}
+	if state.Error != "" && !failed {
+		s := getSuite(junitExecutionSuite)
+		s.cases = append(s.cases, junitTestCase{
+			Name:      "execution",
+			Classname: junitExecutionSuite,
+			Time:      junitSeconds(0),
+			Failure: &junitFailure{
+				Message: fmt.Sprintf("execution %s", state.Status),
+				Type:    string(state.Status),
+				Text:    state.Error,
+			},
+		})
+	}
+
+	// Sort the suites so the output is deterministic.
+	ids := make([]string, 0, len(suites))
+	for id := range suites {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	jts := junitTestSuites{
+		Name:   fmt.Sprintf("kahoy %s", state.ID),
+		Time:   junitSeconds(state.EndedAt.Sub(state.StartedAt)),
+		Suites: []junitTestSuite{},
+	}
+	for _, id := range ids {
+		s := suites[id]
+		failures := 0
+		for _, tc := range s.cases {
+			if tc.Failure != nil {
+				failures++
+			}
+		}
+
+		jts.Suites = append(jts.Suites, junitTestSuite{
+			Name:      id,
+			Tests:     len(s.cases),
+			Failures:  failures,
+			Time:      junitSeconds(s.duration),
+			Timestamp: state.StartedAt.Format("2006-01-02T15:04:05"),
+			Cases:     s.cases,
+		})
+		jts.Tests += len(s.cases)
+		jts.Failures += failures
+	}
+
+	return jts
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,111 @@
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/storage/report"
+)
+
+func TestJUnitStateRepository(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "1912-06-23T01:02:03Z")
+	t1, _ := time.Parse(time.RFC3339, "1912-06-23T01:02:42Z")
+
+	tests := map[string]struct {
+		state  model.State
+		expOut string
+	}{
+		"Having no results should give an empty test suites.": {
+			state: model.State{ID: "id1", StartedAt: t0, EndedAt: t1},
+			expOut: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="kahoy id1" tests="0" failures="0" time="39.000"></testsuites>`,
+		},
+
+		"Having results should give a test suite per group and a test case per resource action and hook.": {
+			state: model.State{
+				ID:        "id1",
+				StartedAt: t0,
+				EndedAt:   t1,
+				Status:    model.ExecutionStatusFailed,
+				Error:     "whatever",
+				ResourceResults: []model.ResourceResult{
+					{Resource: newCustomResource("v1", "Pod", "ns1", "applied1", "group1"), Operation: model.OperationApply, Outcome: model.ResourceOutcomeCreated},
+					{Resource: newCustomResource("v1", "Pod", "ns1", "applied2", "group2"), Operation: model.OperationApply, Outcome: model.ResourceOutcomeFailed, Error: "whatever"},
+				},
+				HookResults: []model.HookResult{
+					{GroupID: "group1", Type: "pre", Cmd: "echo", Duration: 1500 * time.Millisecond},
+				},
+			},
+			expOut: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="kahoy id1" tests="3" failures="1" time="39.000">
+  <testsuite name="group1" tests="2" failures="0" time="1.500" timestamp="1912-06-23T01:02:03">
+    <testcase name="apply applied1" classname="group1" time="0.000"></testcase>
+    <testcase name="pre hook: echo" classname="group1" time="1.500"></testcase>
+  </testsuite>
+  <testsuite name="group2" tests="1" failures="1" time="0.000" timestamp="1912-06-23T01:02:03">
+    <testcase name="apply applied2" classname="group2" time="0.000">
+      <failure message="apply failed" type="apply">whatever</failure>
+    </testcase>
+  </testsuite>
+</testsuites>`,
+		},
+
+		"Having run level hooks should be part of the kahoy test suite.": {
+			state: model.State{
+				ID:        "id1",
+				StartedAt: t0,
+				EndedAt:   t1,
+				Status:    model.ExecutionStatusFailed,
+				Error:     "whatever",
+				HookResults: []model.HookResult{
+					{Type: "beforeRun", Cmd: "echo", Duration: 500 * time.Millisecond, ExitCode: 1, Error: "whatever"},
+				},
+			},
+			expOut: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="kahoy id1" tests="1" failures="1" time="39.000">
+  <testsuite name="kahoy" tests="1" failures="1" time="0.500" timestamp="1912-06-23T01:02:03">
+    <testcase name="beforeRun hook: echo" classname="kahoy" time="0.500">
+      <failure message="beforeRun hook failed with exit code 1" type="hook">whatever</failure>
+    </testcase>
+  </testsuite>
+</testsuites>`,
+		},
+
+		"Having an execution error without failed results should give an execution failure.": {
+			state: model.State{
+				ID:        "id1",
+				StartedAt: t0,
+				EndedAt:   t1,
+				Status:    model.ExecutionStatusCancelled,
+				Error:     "context canceled",
+			},
+			expOut: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="kahoy id1" tests="1" failures="1" time="39.000">
+  <testsuite name="kahoy" tests="1" failures="1" time="0.000" timestamp="1912-06-23T01:02:03">
+    <testcase name="execution" classname="kahoy" time="0.000">
+      <failure message="execution cancelled" type="cancelled">context canceled</failure>
+    </testcase>
+  </testsuite>
+</testsuites>`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotOut bytes.Buffer
+			repo := report.NewJUnitStateRepository(&gotOut)
+			err := repo.StoreState(context.TODO(), test.state)
+
+			if assert.NoError(err) {
+				assert.Equal(test.expOut, gotOut.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/storage"
+)
+
+type markdownStateRepository struct {
+	out io.Writer
+}
+
+// NewMarkdownStateRepository returns a new repository that knows how to write
+// states as a concise Markdown summary on the received output in report mode.
+func NewMarkdownStateRepository(out io.Writer) storage.StateRepository {
+	return markdownStateRepository{out: out}
+}
+
+func (m markdownStateRepository) StoreState(ctx context.Context, state model.State) error {
+	_, err := io.WriteString(m.out, mapStateToMarkdown(state))
+	if err != nil {
+		return fmt.Errorf("could not write Markdown state: %w", err)
+	}
+
+	return nil
+}
+
+// markdownRunGroup is the group used to summarize the run level hooks.
+const markdownRunGroup = "kahoy"
+
+type markdownGroupSummary struct {
+	created, configured, unchanged, deleted, failed int
+	hooks, hooksFailed                              int
+}
+
+func mapStateToMarkdown(state model.State) string {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "## Kahoy report\n\n")
+	fmt.Fprintf(b, "Execution `%s` **%s** in %s.\n", state.ID, state.Status, state.EndedAt.Sub(state.StartedAt))
+	if state.GitCommit != "" {
+		fmt.Fprintf(b, "\nGit commits: `%s`...`%s`.\n", state.GitBeforeCommit, state.GitCommit)
+	}
+	if state.Error != "" {
+		fmt.Fprintf(b, "\nError: `%s`\n", state.Error)
+	}
+
+	// Summarize by group.
+	groups := map[string]*markdownGroupSummary{}
+	getGroup := func(id string) *markdownGroupSummary {
+		g, ok := groups[id]
+		if !ok {
+			g = &markdownGroupSummary{}
+			groups[id] = g
+		}
+		return g
+	}
+
+	failures := []string{}
+	for _, r := range state.ResourceResults {
+		g := getGroup(r.Resource.GroupID)
+		switch r.Outcome {
+		case model.ResourceOutcomeCreated:
+			g.created++
+		case model.ResourceOutcomeConfigured:
+			g.configured++
+		case model.ResourceOutcomeUnchanged:
+			g.unchanged++
+		case model.ResourceOutcomeDeleted, model.ResourceOutcomeNotFound:
+			g.deleted++
+		case model.ResourceOutcomeFailed:
+			g.failed++
+			failures = append(failures, fmt.Sprintf("- `%s` %s `%s`: %s", r.Resource.GroupID, r.Operation, r.Resource.ID, r.Error))
+		}
+	}
+
+	for _, h := range state.HookResults {
+		// Run level hooks don't belong to any group.
+		groupID := h.GroupID
+		if groupID == "" {
+			groupID = markdownRunGroup
+		}
+
+		g := getGroup(groupID)
+		g.hooks++
+		if h.Error != "" {
+			g.hooksFailed++
+			failures = append(failures, fmt.Sprintf("- `%s` %s hook `%s`: %s", groupID, h.Type, h.Cmd, h.Error))
+		}
+	}
+
+	if len(groups) == 0 {
+		fmt.Fprintf(b, "\nNo resources executed.\n")
+		return b.String()
+	}
+
+	ids := make([]string, 0, len(groups))
+	for id := range groups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	fmt.Fprintf(b, "\n| Group | Created | Configured | Unchanged | Deleted | Failed | Hooks |\n")
+	fmt.Fprintf(b, "|---|---|---|---|---|---|---|\n")
+	for _, id := range ids {
+		g := groups[id]
+		fmt.Fprintf(b, "| `%s` | %d | %d | %d | %d | %d | %d/%d |\n", id, g.created, g.configured, g.unchanged, g.deleted, g.failed, g.hooks-g.hooksFailed, g.hooks)
+	}
+
+	if len(failures) > 0 {
+		fmt.Fprintf(b, "\n### Failures\n\n%s\n", strings.Join(failures, "\n"))
+	}
+
+	return b.String()
+}
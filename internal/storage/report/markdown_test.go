@@ -0,0 +1,77 @@
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/kahoy/internal/model"
+	"github.com/slok/kahoy/internal/storage/report"
+)
+
+func TestMarkdownStateRepository(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "1912-06-23T01:02:03Z")
+	t1, _ := time.Parse(time.RFC3339, "1912-06-23T01:02:42Z")
+
+	tests := map[string]struct {
+		state  model.State
+		expOut string
+	}{
+		"Having no results should give the summary without resources.": {
+			state: model.State{ID: "id1", StartedAt: t0, EndedAt: t1, Status: model.ExecutionStatusSuccess},
+			expOut: "## Kahoy report\n\n" +
+				"Execution `id1` **success** in 39s.\n" +
+				"\nNo resources executed.\n",
+		},
+
+		"Having results should give the summary table by group and the failures.": {
+			state: model.State{
+				ID:              "id1",
+				StartedAt:       t0,
+				EndedAt:         t1,
+				Status:          model.ExecutionStatusFailed,
+				Error:           "whatever",
+				GitBeforeCommit: "1234",
+				GitCommit:       "5678",
+				ResourceResults: []model.ResourceResult{
+					{Resource: newCustomResource("v1", "Pod", "ns1", "applied1", "group1"), Operation: model.OperationApply, Outcome: model.ResourceOutcomeCreated},
+					{Resource: newCustomResource("v1", "Pod", "ns1", "applied2", "group1"), Operation: model.OperationApply, Outcome: model.ResourceOutcomeUnchanged},
+					{Resource: newCustomResource("v1", "Pod", "ns1", "deleted1", "group2"), Operation: model.OperationDelete, Outcome: model.ResourceOutcomeDeleted},
+					{Resource: newCustomResource("v1", "Pod", "ns1", "applied3", "group2"), Operation: model.OperationApply, Outcome: model.ResourceOutcomeFailed, Error: "whatever"},
+				},
+				HookResults: []model.HookResult{
+					{GroupID: "group1", Type: "pre", Cmd: "echo"},
+					{GroupID: "group2", Type: "post", Cmd: "false", ExitCode: 1, Error: "exit status 1"},
+				},
+			},
+			expOut: "## Kahoy report\n\n" +
+				"Execution `id1` **failed** in 39s.\n" +
+				"\nGit commits: `1234`...`5678`.\n" +
+				"\nError: `whatever`\n" +
+				"\n| Group | Created | Configured | Unchanged | Deleted | Failed | Hooks |\n" +
+				"|---|---|---|---|---|---|---|\n" +
+				"| `group1` | 1 | 0 | 1 | 0 | 0 | 1/1 |\n" +
+				"| `group2` | 0 | 0 | 0 | 1 | 1 | 0/1 |\n" +
+				"\n### Failures\n\n" +
+				"- `group2` apply `applied3`: whatever\n" +
+				"- `group2` post hook `false`: exit status 1\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotOut bytes.Buffer
+			repo := report.NewMarkdownStateRepository(&gotOut)
+			err := repo.StoreState(context.TODO(), test.state)
+
+			if assert.NoError(err) {
+				assert.Equal(test.expOut, gotOut.String())
+			}
+		})
+	}
+}
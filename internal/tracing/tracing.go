@@ -0,0 +1,155 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/slok/kahoy/internal/log"
+)
+
+// TracerName is the name of the Kahoy tracer.
+const TracerName = "github.com/slok/kahoy"
+
+// Noop is a tracer that doesn't trace.
+var Noop = trace.NewNoopTracerProvider().Tracer(TracerName)
+
+// Provider knows how to provide tracers and flush the traced spans.
+type Provider interface {
+	Tracer() trace.Tracer
+	// Shutdown flushes the pending spans and stops the provider.
+	Shutdown(ctx context.Context) error
+}
+
+// ProviderConfig is the configuration of the provider.
+type ProviderConfig struct {
+	// OTLPEndpoint is the OTLP HTTP collector endpoint URL (e.g: `http://127.0.0.1:4318`),
+	// `http` scheme will use an insecure connection.
+	OTLPEndpoint string
+	// Out is where the spans will be written in JSON (e.g: a file).
+	Out io.Writer
+	// Version is the version of the traced service.
+	Version string
+	Logger  log.Logger
+}
+
+func (c *ProviderConfig) defaults() error {
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"app-svc": "tracing.Provider"})
+
+	return nil
+}
+
+type noopProvider struct{}
+
+func (noopProvider) Tracer() trace.Tracer               { return Noop }
+func (noopProvider) Shutdown(ctx context.Context) error { return nil }
+
+type provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+// NewProvider returns a new tracing provider that exports the spans to the
+// configured OTLP collector and output. If none of them are configured, the
+// provider will not trace.
+func NewProvider(ctx context.Context, config ProviderConfig) (Provider, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if config.OTLPEndpoint == "" && config.Out == nil {
+		return noopProvider{}, nil
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String("kahoy"),
+			semconv.ServiceVersionKey.String(config.Version),
+		)),
+	}
+
+	if config.OTLPEndpoint != "" {
+		exporter, err := newOTLPExporter(ctx, config.OTLPEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("could not create OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+		config.Logger.Infof("traces will be exported to %q", config.OTLPEndpoint)
+	}
+
+	if config.Out != nil {
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(config.Out))
+		if err != nil {
+			return nil, fmt.Errorf("could not create output exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	return provider{tp: sdktrace.NewTracerProvider(opts...)}, nil
+}
+
+func newOTLPExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: missing host", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if path := strings.TrimSuffix(u.Path, "/"); path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(path))
+	}
+
+	return otlptracehttp.New(ctx, opts...)
+}
+
+func (p provider) Tracer() trace.Tracer { return p.tp.Tracer(TracerName) }
+
+func (p provider) Shutdown(ctx context.Context) error { return p.tp.Shutdown(ctx) }
+
+// EnvVars returns the environment variables that propagate the trace context
+// of the received context (e.g: `TRACEPARENT`), to be used on executed commands.
+func EnvVars(ctx context.Context) []string {
+	carrier := propagation.HeaderCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	env := []string{}
+	for _, k := range []string{"traceparent", "tracestate"} {
+		if v := carrier.Get(k); v != "" {
+			env = append(env, fmt.Sprintf("%s=%s", strings.ToUpper(k), v))
+		}
+	}
+
+	return env
+}
+
+// EndSpan ends the span setting the error status if the received error is not nil.
+func EndSpan(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	span.SetAttributes(attrs...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,95 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/kahoy/internal/tracing"
+)
+
+func TestProviderOutput(t *testing.T) {
+	tests := map[string]struct {
+		out       bool
+		expTraced bool
+	}{
+		"Not having exporters configured should not trace.": {
+			out:       false,
+			expTraced: false,
+		},
+
+		"Having an output configured should write the spans on the output.": {
+			out:       true,
+			expTraced: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var out bytes.Buffer
+			config := tracing.ProviderConfig{Version: "test"}
+			if test.out {
+				config.Out = &out
+			}
+
+			provider, err := tracing.NewProvider(context.TODO(), config)
+			require.NoError(err)
+
+			ctx, span := provider.Tracer().Start(context.TODO(), "test-span")
+			gotEnv := tracing.EnvVars(ctx)
+			tracing.EndSpan(span, fmt.Errorf("whatever"))
+
+			err = provider.Shutdown(context.TODO())
+			require.NoError(err)
+
+			// Check.
+			if test.expTraced {
+				expEnv := fmt.Sprintf("TRACEPARENT=00-%s-%s-01", span.SpanContext().TraceID(), span.SpanContext().SpanID())
+				assert.Equal([]string{expEnv}, gotEnv)
+				assert.Contains(out.String(), `"Name":"test-span"`)
+				assert.Contains(out.String(), `"Description":"whatever"`)
+			} else {
+				assert.Empty(gotEnv)
+				assert.Empty(out.String())
+			}
+		})
+	}
+}
+
+func TestProviderInvalidOTLPEndpoint(t *testing.T) {
+	_, err := tracing.NewProvider(context.TODO(), tracing.ProviderConfig{OTLPEndpoint: "127.0.0.1:4318"})
+	assert.Error(t, err)
+}
+
+func TestProviderOTLP(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// OTLP collector stand-in.
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+	}))
+	defer srv.Close()
+
+	provider, err := tracing.NewProvider(context.TODO(), tracing.ProviderConfig{OTLPEndpoint: srv.URL})
+	require.NoError(err)
+
+	_, span := provider.Tracer().Start(context.TODO(), "test-span")
+	span.End()
+	err = provider.Shutdown(context.TODO())
+	require.NoError(err)
+
+	// Check.
+	assert.Equal(http.MethodPost, gotMethod)
+	assert.Equal("/v1/traces", gotPath)
+}